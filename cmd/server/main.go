@@ -1,58 +1,105 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/config"
 	"github.com/minhalzubairi/lang-portal/backend-go/internal/handlers"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
 	"github.com/minhalzubairi/lang-portal/backend-go/internal/service"
 )
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	logging.Infof("Configuration: %s", cfg)
+	logging.SetLevel(cfg.LogLevel)
+
 	// Initialize database
-	if err := service.InitDB("words.db"); err != nil {
+	db, err := service.InitDB(cfg.Database, cfg.Migrate)
+	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
-	defer service.CloseDB()
-
-	r := gin.Default()
+	defer db.Close()
 
-	// API routes group
-	api := r.Group("/api")
-	{
-		// Dashboard routes
-		api.GET("/dashboard/last_study_session", handlers.GetLastStudySession)
-		api.GET("/dashboard/study_progress", handlers.GetStudyProgress)
-		api.GET("/dashboard/quick-stats", handlers.GetQuickStats)
+	svc := service.New(db, service.Options{
+		TokenSecret:        cfg.LaunchSecret,
+		LaunchTokenTTL:     cfg.LaunchTokenTTL,
+		SessionTokenTTL:    cfg.SessionTokenTTL,
+		SessionIdleTimeout: cfg.SessionIdleTimeout,
+		APIBase:            cfg.APIBase,
+	})
+	h := handlers.New(svc)
 
-		// Study activities routes
-		api.GET("/study_activities", handlers.GetStudyActivities)
-		api.GET("/study_activities/:id", handlers.GetStudyActivity)
-		api.GET("/study_activities/:id/study_sessions", handlers.GetStudyActivitySessions)
-		api.POST("/study_activities", handlers.CreateStudyActivity)
+	// Stop on SIGINT or SIGTERM, letting in-flight requests finish first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// Words routes
-		api.GET("/words", handlers.GetWords)
-		api.GET("/words/:id", handlers.GetWord)
+	go svc.WatchIdleSessions(ctx, time.Minute)
 
-		// Groups routes
-		api.GET("/groups", handlers.GetGroups)
-		api.GET("/groups/:id", handlers.GetGroup)
-		api.GET("/groups/:id/words", handlers.GetGroupWords)
-		api.GET("/groups/:id/study_sessions", handlers.GetGroupStudySessions)
+	gin.SetMode(cfg.GinMode)
+	r := gin.New()
+	if logging.Enabled(logging.Info) {
+		r.Use(gin.Logger())
+	}
+	// ErrorHandler answers the errors of everything after it, panics included
+	r.Use(middleware.RequestID(), middleware.ErrorHandler(), middleware.Recovery())
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies: ", err)
+	}
+	forwarded, err := middleware.TrustProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal("Invalid trusted proxies: ", err)
+	}
+	r.Use(forwarded)
+	if len(cfg.CORSOrigins) > 0 {
+		r.Use(middleware.CORS(cfg.CORSOrigins))
+	}
 
-		// Study sessions routes
-		api.GET("/study_sessions", handlers.GetStudySessions)
-		api.GET("/study_sessions/:id", handlers.GetStudySession)
-		api.GET("/study_sessions/:id/words", handlers.GetStudySessionWords)
-		api.POST("/study_sessions/:id/words/:word_id/review", handlers.CreateWordReview)
+	auth := &middleware.Auth{
+		AdminToken:         cfg.AdminToken,
+		VerifySessionToken: svc.VerifySessionToken,
+		Authenticate:       svc.AuthenticateUser,
+	}
+	h.Routes(r, auth)
 
-		// Reset routes
-		api.POST("/reset_history", handlers.ResetHistory)
-		api.POST("/full_reset", handlers.FullReset)
+	srv := &http.Server{
+		Addr:    cfg.Listen,
+		Handler: r,
 	}
+	served := make(chan error, 1)
+	go func() {
+		logging.Infof("Listening on %s", cfg.Listen)
+		if cfg.TLSCert != "" {
+			served <- srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			served <- srv.ListenAndServe()
+		}
+	}()
 
-	if err := r.Run(":8080"); err != nil {
+	select {
+	case err := <-served:
 		log.Fatal("Failed to start server:", err)
+	case <-ctx.Done():
+	}
+	// A second signal stops the server at once
+	stop()
+
+	logging.Infof("Shutting down, waiting up to %s for requests to finish", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logging.Errorf("Error shutting down server: %v", err)
 	}
+	logging.Infof("Server stopped")
 } 
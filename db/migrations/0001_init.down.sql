@@ -0,0 +1,11 @@
+DROP TABLE word_review_items;
+
+DROP TABLE study_activities;
+
+DROP TABLE study_sessions;
+
+DROP TABLE words_groups;
+
+DROP TABLE groups;
+
+DROP TABLE words;
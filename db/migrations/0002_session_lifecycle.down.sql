@@ -0,0 +1,3 @@
+ALTER TABLE study_sessions DROP COLUMN ended_at;
+
+ALTER TABLE study_sessions DROP COLUMN status;
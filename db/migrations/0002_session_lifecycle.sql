@@ -0,0 +1,11 @@
+ALTER TABLE study_sessions ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+ALTER TABLE study_sessions ADD COLUMN ended_at DATETIME;
+
+-- Sessions recorded before the lifecycle existed are treated as completed at their last review
+UPDATE study_sessions
+SET status = 'completed',
+    ended_at = COALESCE(
+        (SELECT MAX(wri.created_at) FROM word_review_items wri WHERE wri.study_session_id = study_sessions.id),
+        created_at
+    );
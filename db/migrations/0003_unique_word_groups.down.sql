@@ -0,0 +1 @@
+DROP INDEX idx_words_groups_word_group;
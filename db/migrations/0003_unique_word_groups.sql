@@ -0,0 +1,7 @@
+-- Remove duplicate word/group pairs, keeping the oldest row of each
+DELETE FROM words_groups
+WHERE id NOT IN (
+    SELECT MIN(id) FROM words_groups GROUP BY word_id, group_id
+);
+
+CREATE UNIQUE INDEX idx_words_groups_word_group ON words_groups (word_id, group_id);
@@ -0,0 +1,3 @@
+DROP INDEX idx_word_schedules_due_at;
+
+DROP TABLE word_schedules;
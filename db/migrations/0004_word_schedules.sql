@@ -0,0 +1,11 @@
+CREATE TABLE word_schedules (
+    word_id INTEGER PRIMARY KEY,
+    ease_factor REAL NOT NULL,
+    interval_days INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX idx_word_schedules_due_at ON word_schedules (due_at);
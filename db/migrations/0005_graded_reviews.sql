@@ -0,0 +1,10 @@
+ALTER TABLE word_review_items ADD COLUMN grade INTEGER;
+
+ALTER TABLE word_review_items ADD COLUMN answer TEXT;
+
+ALTER TABLE word_review_items ADD COLUMN direction TEXT;
+
+ALTER TABLE word_review_items ADD COLUMN response_time_ms INTEGER;
+
+-- Grade earlier boolean reviews the same way the review endpoint does
+UPDATE word_review_items SET grade = CASE WHEN correct THEN 4 ELSE 1 END;
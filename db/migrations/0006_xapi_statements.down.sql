@@ -0,0 +1,5 @@
+DROP INDEX idx_xapi_statements_study_session_id;
+
+DROP INDEX idx_xapi_statements_stored;
+
+DROP TABLE xapi_statements;
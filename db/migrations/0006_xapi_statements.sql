@@ -0,0 +1,20 @@
+CREATE TABLE xapi_statements (
+    id TEXT PRIMARY KEY,
+    actor_id TEXT NOT NULL,
+    verb_id TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    registration TEXT,
+    statement TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    stored DATETIME NOT NULL,
+    voided BOOLEAN NOT NULL DEFAULT 0,
+    study_session_id INTEGER,
+    word_review_item_id INTEGER,
+    review_error TEXT,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (word_review_item_id) REFERENCES word_review_items(id)
+);
+
+CREATE INDEX idx_xapi_statements_stored ON xapi_statements (stored);
+
+CREATE INDEX idx_xapi_statements_study_session_id ON xapi_statements (study_session_id);
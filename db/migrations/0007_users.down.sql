@@ -0,0 +1,45 @@
+-- Only the guest learner's schedules fit the single-learner table
+CREATE TABLE guest_word_schedules (
+    word_id INTEGER PRIMARY KEY,
+    ease_factor REAL NOT NULL,
+    interval_days INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+INSERT INTO guest_word_schedules (word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+SELECT word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+FROM word_schedules
+WHERE user_id = 0;
+
+DROP TABLE word_schedules;
+
+ALTER TABLE guest_word_schedules RENAME TO word_schedules;
+
+CREATE INDEX idx_word_schedules_due_at ON word_schedules (due_at);
+
+-- user_id references users, so it cannot be dropped in place. Sessions of every
+-- learner are kept as sessions of the single learner.
+CREATE TABLE study_sessions_without_users (
+    id INTEGER PRIMARY KEY,
+    group_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    study_activity_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    ended_at DATETIME,
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+
+INSERT INTO study_sessions_without_users (id, group_id, created_at, study_activity_id, status, ended_at)
+SELECT id, group_id, created_at, study_activity_id, status, ended_at
+FROM study_sessions;
+
+DROP TABLE study_sessions;
+
+ALTER TABLE study_sessions_without_users RENAME TO study_sessions;
+
+DROP TABLE login_sessions;
+
+DROP TABLE users;
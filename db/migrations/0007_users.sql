@@ -0,0 +1,51 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    username TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    display_name TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE login_sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_login_sessions_user_id ON login_sessions (user_id);
+
+-- Sessions without a user, including all earlier ones, belong to the guest learner
+ALTER TABLE study_sessions ADD COLUMN user_id INTEGER REFERENCES users(id);
+
+CREATE INDEX idx_study_sessions_user_id ON study_sessions (user_id);
+
+-- Session IDs are reused once the history is reset, so session and launch tokens
+-- also carry a random nonce of the session they were issued for
+ALTER TABLE study_sessions ADD COLUMN nonce TEXT NOT NULL DEFAULT '';
+
+UPDATE study_sessions SET nonce = lower(hex(randomblob(16)));
+
+-- Schedules are kept per learner, with user_id 0 for the guest learner
+CREATE TABLE user_word_schedules (
+    user_id INTEGER NOT NULL DEFAULT 0,
+    word_id INTEGER NOT NULL,
+    ease_factor REAL NOT NULL,
+    interval_days INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+INSERT INTO user_word_schedules (user_id, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+SELECT 0, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+FROM word_schedules;
+
+DROP TABLE word_schedules;
+
+ALTER TABLE user_word_schedules RENAME TO word_schedules;
+
+CREATE INDEX idx_word_schedules_due_at ON word_schedules (due_at);
@@ -0,0 +1,7 @@
+DROP TABLE assignments;
+
+DROP TABLE class_members;
+
+DROP TABLE classes;
+
+ALTER TABLE users DROP COLUMN role;
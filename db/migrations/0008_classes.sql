@@ -0,0 +1,39 @@
+-- Users are either learners or teachers
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'learner';
+
+CREATE TABLE classes (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    teacher_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (teacher_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_classes_teacher_id ON classes (teacher_id);
+
+CREATE TABLE class_members (
+    class_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (class_id, user_id),
+    FOREIGN KEY (class_id) REFERENCES classes(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_class_members_user_id ON class_members (user_id);
+
+-- An assignment asks every member of a class to study a group with an activity
+-- before the due date
+CREATE TABLE assignments (
+    id INTEGER PRIMARY KEY,
+    class_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    study_activity_id INTEGER NOT NULL,
+    due_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (class_id) REFERENCES classes(id),
+    FOREIGN KEY (group_id) REFERENCES groups(id),
+    FOREIGN KEY (study_activity_id) REFERENCES study_activities(id)
+);
+
+CREATE INDEX idx_assignments_class_id ON assignments (class_id);
@@ -0,0 +1,11 @@
+DROP TRIGGER words_search_delete;
+
+DROP TRIGGER words_search_update;
+
+DROP TRIGGER words_search_insert;
+
+DROP TABLE words_search;
+
+ALTER TABLE words DROP COLUMN roman_normalized;
+
+ALTER TABLE words DROP COLUMN arabic_normalized;
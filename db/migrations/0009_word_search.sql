@@ -0,0 +1,50 @@
+-- Full-text index over the vocabulary, kept in sync with words by triggers.
+-- The server stores each word with its Arabic and transliteration normalized, and
+-- the index holds those forms, so searches match whatever tashkeel, letter forms or
+-- transliteration spelling they use. The triggers only copy columns, so any SQLite
+-- client can write words; ones written without normalized forms are indexed as
+-- written until the server next starts and fills them in.
+ALTER TABLE words ADD COLUMN arabic_normalized TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE words ADD COLUMN roman_normalized TEXT NOT NULL DEFAULT '';
+
+CREATE VIRTUAL TABLE words_search USING fts5(
+    arabic,
+    roman,
+    english,
+    tokenize = 'unicode61 remove_diacritics 2',
+    prefix = '2 3'
+);
+
+INSERT INTO words_search (rowid, arabic, roman, english)
+SELECT id, arabic, roman, english
+FROM words;
+
+CREATE TRIGGER words_search_insert AFTER INSERT ON words
+BEGIN
+    INSERT INTO words_search (rowid, arabic, roman, english)
+    VALUES (
+        new.id,
+        COALESCE(NULLIF(new.arabic_normalized, ''), new.arabic),
+        COALESCE(NULLIF(new.roman_normalized, ''), new.roman),
+        new.english
+    );
+END;
+
+CREATE TRIGGER words_search_update
+AFTER UPDATE OF id, arabic, roman, english, arabic_normalized, roman_normalized ON words
+BEGIN
+    DELETE FROM words_search WHERE rowid = old.id;
+    INSERT INTO words_search (rowid, arabic, roman, english)
+    VALUES (
+        new.id,
+        COALESCE(NULLIF(new.arabic_normalized, ''), new.arabic),
+        COALESCE(NULLIF(new.roman_normalized, ''), new.roman),
+        new.english
+    );
+END;
+
+CREATE TRIGGER words_search_delete AFTER DELETE ON words
+BEGIN
+    DELETE FROM words_search WHERE rowid = old.id;
+END;
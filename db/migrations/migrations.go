@@ -0,0 +1,454 @@
+// Package migrations embeds the database schema migrations and applies them,
+// recording each applied migration and its checksum in the migrations table.
+// A migration NNNN_name.sql may be paired with NNNN_name.down.sql, which undoes it.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// downSuffix ends the file name of a down migration
+const downSuffix = ".down.sql"
+
+// ErrNothingApplied is returned by Latest and Rollback when no migration has been applied
+var ErrNothingApplied = errors.New("no migrations have been applied")
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a schema change shipped with the binary. Down is empty when the
+// migration cannot be undone.
+type Migration struct {
+	Name     string
+	SQL      string
+	Down     string
+	Checksum string
+}
+
+// Status is the state of a migration in a database
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+	// Edited is set when the migration changed after it was applied
+	Edited bool
+	// Unknown is set for applied migrations this binary does not ship
+	Unknown bool
+}
+
+// EditedError is returned when an applied migration no longer matches its file
+type EditedError struct {
+	Name string
+}
+
+func (e *EditedError) Error() string {
+	return fmt.Sprintf("migration %s has been edited since it was applied", e.Name)
+}
+
+// UnknownError is returned when the database has a migration this binary does not
+// ship, meaning the schema is newer than the binary
+type UnknownError struct {
+	Name string
+}
+
+func (e *UnknownError) Error() string {
+	return fmt.Sprintf("database has migration %s, which this binary does not know; upgrade the server", e.Name)
+}
+
+// All returns the embedded migrations in the order they are applied
+func All() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	downs := map[string]string{}
+	var ups []string
+	for _, name := range names {
+		if !strings.HasSuffix(name, downSuffix) {
+			ups = append(ups, name)
+			continue
+		}
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		downs[strings.TrimSuffix(name, downSuffix)+".sql"] = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(ups))
+	for _, name := range ups {
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, Migration{
+			Name:     name,
+			SQL:      string(content),
+			Down:     downs[name],
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+		delete(downs, name)
+	}
+	for name := range downs {
+		return nil, fmt.Errorf("down migration for %s has no up migration", name)
+	}
+	return migrations, nil
+}
+
+// Pending verifies db against the embedded migrations without changing it and
+// returns the names of the migrations that have not been applied yet
+func Pending(db *sql.DB) ([]string, error) {
+	plan, err := Plan(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range plan {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// Plan verifies db against the embedded migrations without changing it and
+// returns the migrations Apply would run, in order
+func Plan(db *sql.DB) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := readApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var plan []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Name]; !ok {
+			plan = append(plan, m)
+		}
+	}
+	return plan, nil
+}
+
+// Statuses returns the state of every embedded migration in db, followed by any
+// applied migrations the binary does not ship. It does not change db.
+func Statuses(db *sql.DB) ([]Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := readApplied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, m := range migrations {
+		status := Status{Migration: m}
+		if record, ok := applied[m.Name]; ok {
+			status.Applied = true
+			status.AppliedAt = record.appliedAt
+			status.Edited = record.checksum != "" && record.checksum != m.Checksum
+			delete(applied, m.Name)
+		}
+		statuses = append(statuses, status)
+	}
+
+	var unknown []string
+	for name := range applied {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		statuses = append(statuses, Status{
+			Migration: Migration{Name: name},
+			Applied:   true,
+			AppliedAt: applied[name].appliedAt,
+			Unknown:   true,
+		})
+	}
+	return statuses, nil
+}
+
+// Latest returns the most recently applied migration, which Rollback would undo
+func Latest(db *sql.DB) (*Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := readApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var latest *Migration
+	var latestID int64
+	for i, m := range migrations {
+		if record, ok := applied[m.Name]; ok && (latest == nil || record.id > latestID) {
+			latest = &migrations[i]
+			latestID = record.id
+		}
+	}
+	if latest == nil {
+		return nil, ErrNothingApplied
+	}
+	return latest, nil
+}
+
+// Rollback undoes the most recently applied migration with its down migration
+// and returns it
+func Rollback(db *sql.DB) (*Migration, error) {
+	m, err := Latest(db)
+	if err != nil {
+		return nil, err
+	}
+	if m.Down == "" {
+		return nil, fmt.Errorf("migration %s has no down migration", m.Name)
+	}
+
+	err = inTransaction(db, func(tx *sql.Tx) error {
+		if err := execScript(tx, m.Down); err != nil {
+			return fmt.Errorf("failed to roll back migration %s: %w", m.Name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM migrations WHERE name = ?", m.Name); err != nil {
+			return fmt.Errorf("failed to unrecord migration %s: %w", m.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Apply applies the pending migrations to db, each in its own transaction, and
+// returns their names. It refuses to touch a database whose applied migrations
+// were edited or are newer than the binary. Migrations recorded without a
+// checksum, by older versions, are given the checksum of the embedded file.
+func Apply(db *sql.DB) ([]string, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := readApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range migrations {
+		record, ok := applied[m.Name]
+		if ok {
+			if record.checksum == "" {
+				_, err := db.Exec("UPDATE migrations SET checksum = ? WHERE name = ?", m.Checksum, m.Name)
+				if err != nil {
+					return names, fmt.Errorf("failed to record checksum of migration %s: %w", m.Name, err)
+				}
+			}
+			continue
+		}
+
+		if err := apply(db, m); err != nil {
+			return names, err
+		}
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// ensureTable creates the migrations table, adding the checksum column to tables
+// created before checksums were recorded
+func ensureTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS migrations (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			checksum TEXT
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	hasChecksum, err := hasChecksumColumn(db)
+	if err != nil {
+		return err
+	}
+	if !hasChecksum {
+		if _, err := db.Exec("ALTER TABLE migrations ADD COLUMN checksum TEXT"); err != nil {
+			return fmt.Errorf("failed to add checksum to migrations table: %w", err)
+		}
+	}
+	return nil
+}
+
+// appliedMigration is a row of the migrations table
+type appliedMigration struct {
+	id        int64
+	appliedAt string
+	// checksum is empty for migrations recorded without one
+	checksum string
+}
+
+// readApplied returns the applied migrations by name. A database without a
+// migrations table has none.
+func readApplied(db *sql.DB) (map[string]appliedMigration, error) {
+	applied := map[string]appliedMigration{}
+
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'migrations')").Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up migrations table: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	hasChecksum, err := hasChecksumColumn(db)
+	if err != nil {
+		return nil, err
+	}
+	column := "''"
+	if hasChecksum {
+		column = "COALESCE(checksum, '')"
+	}
+
+	rows, err := db.Query("SELECT id, name, COALESCE(applied_at, ''), " + column + " FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var record appliedMigration
+		if err := rows.Scan(&record.id, &name, &record.appliedAt, &record.checksum); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[name] = record
+	}
+	return applied, rows.Err()
+}
+
+// hasChecksumColumn reports whether the migrations table records checksums
+func hasChecksumColumn(db *sql.DB) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM pragma_table_info('migrations') WHERE name = 'checksum')").Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect migrations table: %w", err)
+	}
+	return exists, nil
+}
+
+// verify checks the applied migrations against the embedded ones
+func verify(migrations []Migration, applied map[string]appliedMigration) error {
+	known := make(map[string]string, len(migrations))
+	for _, m := range migrations {
+		known[m.Name] = m.Checksum
+	}
+
+	names := make([]string, 0, len(applied))
+	for name := range applied {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checksum, ok := known[name]
+		if !ok {
+			return &UnknownError{Name: name}
+		}
+		if applied[name].checksum != "" && applied[name].checksum != checksum {
+			return &EditedError{Name: name}
+		}
+	}
+	return nil
+}
+
+// apply runs a migration and records it in a single transaction
+func apply(db *sql.DB, m Migration) error {
+	return inTransaction(db, func(tx *sql.Tx) error {
+		if err := execScript(tx, m.SQL); err != nil {
+			return fmt.Errorf("failed to execute migration %s: %w", m.Name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO migrations (name, checksum) VALUES (?, ?)", m.Name, m.Checksum); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+		}
+		return nil
+	})
+}
+
+// inTransaction runs fn in a transaction with foreign key enforcement off, since
+// migrations that rebuild or drop tables would otherwise trip over the rows that
+// reference them. SQLite ignores the pragma inside a transaction, so it is set on
+// the connection around it and restored afterwards.
+func inTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("failed to read foreign key setting: %w", err)
+	}
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return fmt.Errorf("failed to disable foreign keys: %w", err)
+		}
+		defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execScript runs each statement of a migration script
+func execScript(tx *sql.Tx, script string) error {
+	for _, stmt := range Split(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package migrations
+
+import (
+	"strings"
+)
+
+// Split splits a script into statements at the semicolons that end them.
+// Semicolons in string literals, quoted identifiers, comments and the bodies of
+// CREATE TRIGGER statements do not end a statement. Statements are returned
+// trimmed and without their semicolon; ones holding only comments are dropped.
+func Split(script string) []string {
+	var statements []string
+	var words []string
+	start := 0
+	hasCode := false
+	caseDepth := 0
+	triggerEnded := false
+
+	isTrigger := func() bool {
+		switch {
+		case len(words) >= 2 && words[0] == "CREATE" && words[1] == "TRIGGER":
+			return true
+		case len(words) >= 3 && words[0] == "CREATE" && (words[1] == "TEMP" || words[1] == "TEMPORARY") && words[2] == "TRIGGER":
+			return true
+		}
+		return false
+	}
+
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// A doubled quote inside a literal reads as the literal closing and reopening
+			end := strings.IndexByte(script[i+1:], c)
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 2
+			}
+			hasCode = true
+
+		case c == '[':
+			end := strings.IndexByte(script[i+1:], ']')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 2
+			}
+			hasCode = true
+
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 1
+			}
+
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 4
+			}
+
+		case isWordByte(c):
+			j := i
+			for j < len(script) && isWordByte(script[j]) {
+				j++
+			}
+			word := strings.ToUpper(script[i:j])
+			if len(words) < 3 {
+				words = append(words, word)
+			}
+			if isTrigger() {
+				switch word {
+				case "CASE":
+					caseDepth++
+				case "END":
+					if caseDepth > 0 {
+						caseDepth--
+					} else {
+						triggerEnded = true
+					}
+				}
+			}
+			i = j
+			hasCode = true
+
+		case c == ';':
+			if isTrigger() && !triggerEnded {
+				i++
+				continue
+			}
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			i++
+			start = i
+			words = words[:0]
+			hasCode = false
+			caseDepth = 0
+			triggerEnded = false
+
+		default:
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+			i++
+		}
+	}
+
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return statements
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
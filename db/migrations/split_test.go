@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			script: "CREATE TABLE a (id INTEGER);\nINSERT INTO a VALUES (1);\n",
+			want:   []string{"CREATE TABLE a (id INTEGER)", "INSERT INTO a VALUES (1)"},
+		},
+		{
+			name:   "last statement without semicolon",
+			script: "DELETE FROM a;\nDELETE FROM b",
+			want:   []string{"DELETE FROM a", "DELETE FROM b"},
+		},
+		{
+			name:   "semicolon in string literal",
+			script: "INSERT INTO a VALUES ('x;y');INSERT INTO a VALUES ('it''s;');",
+			want:   []string{"INSERT INTO a VALUES ('x;y')", "INSERT INTO a VALUES ('it''s;')"},
+		},
+		{
+			name:   "semicolon in quoted identifiers",
+			script: `SELECT "a;b", ` + "`c;d`" + `, [e;f] FROM t;`,
+			want:   []string{`SELECT "a;b", ` + "`c;d`" + `, [e;f] FROM t`},
+		},
+		{
+			name:   "semicolon in comments",
+			script: "-- first; comment\nSELECT 1 /* inline; */;\nSELECT 2;",
+			want:   []string{"-- first; comment\nSELECT 1 /* inline; */", "SELECT 2"},
+		},
+		{
+			name:   "comment only statements dropped",
+			script: "SELECT 1;\n-- trailing comment;\n/* block */;\n",
+			want:   []string{"SELECT 1"},
+		},
+		{
+			name: "trigger body",
+			script: "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n" +
+				"  INSERT INTO b VALUES (new.id);\n" +
+				"  UPDATE c SET n = n + 1;\n" +
+				"END;\nSELECT 1;",
+			want: []string{
+				"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n" +
+					"  INSERT INTO b VALUES (new.id);\n" +
+					"  UPDATE c SET n = n + 1;\n" +
+					"END",
+				"SELECT 1",
+			},
+		},
+		{
+			name: "temporary trigger with case",
+			script: "create temp trigger t after update on a begin\n" +
+				"  update b set v = case when new.x then 1 else 0 end;\n" +
+				"end;\nselect 2;",
+			want: []string{
+				"create temp trigger t after update on a begin\n" +
+					"  update b set v = case when new.x then 1 else 0 end;\n" +
+					"end",
+				"select 2",
+			},
+		},
+		{
+			name:   "empty",
+			script: " \n\t",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package anki reads and writes Anki deck packages (.apkg). A package is a zip
+// archive holding an SQLite collection in the schema 11 layout used by Anki 2.1
+// exports, plus a JSON media manifest.
+package anki
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Names of the entries inside a package
+const (
+	collectionLegacy = "collection.anki2"
+	collectionV21    = "collection.anki21"
+	collectionV21b   = "collection.anki21b"
+	mediaManifest    = "media"
+)
+
+// fieldSeparator joins the fields of a note in the notes.flds column
+const fieldSeparator = "\x1f"
+
+// ErrUnsupportedFormat is returned for packages that only contain the compressed
+// collection written by Anki 2.1.50 and later
+var ErrUnsupportedFormat = errors.New(`package uses the compressed Anki 2.1.50+ format, export it again with "Support older Anki versions" checked`)
+
+// Collection is the content of a package relevant to vocabulary
+type Collection struct {
+	// Decks maps deck IDs to their full names, e.g. "Arabic::Food"
+	Decks map[int64]string
+	// NoteTypes maps note type (model) IDs to their definitions
+	NoteTypes map[int64]NoteType
+	Notes     []Note
+}
+
+// NoteType describes the fields of a kind of note. Templates and CSS are only
+// used when writing a package.
+type NoteType struct {
+	ID        int64
+	Name      string
+	Fields    []string
+	Templates []Template
+	CSS       string
+}
+
+// Template renders one kind of card from a note, e.g. "{{Arabic}}" on the front
+type Template struct {
+	Name  string
+	Front string
+	Back  string
+}
+
+// Note is a single Anki note with its field values as stored, i.e. as HTML
+type Note struct {
+	ID         int64
+	GUID       string
+	NoteTypeID int64
+	Fields     []string
+	Tags       []string
+	// DeckIDs lists the decks holding the cards of the note, in card order
+	DeckIDs []int64
+	// Reviews is the review history of all cards of the note, oldest first
+	Reviews []Review
+}
+
+// Review is one entry of the review log
+type Review struct {
+	Time time.Time
+	// Ease is the answer button pressed: 1 again, 2 hard, 3 good, 4 easy
+	Ease     int
+	Duration time.Duration
+}
+
+// Field returns the value of the named field of a note, or false if its note type
+// has no such field
+func (c *Collection) Field(note Note, name string) (string, bool) {
+	noteType, ok := c.NoteTypes[note.NoteTypeID]
+	if !ok {
+		return "", false
+	}
+	for i, field := range noteType.Fields {
+		if strings.EqualFold(field, name) && i < len(note.Fields) {
+			return note.Fields[i], true
+		}
+	}
+	return "", false
+}
+
+var (
+	soundTag  = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	breakTag  = regexp.MustCompile(`(?i)<br\s*/?>|</(div|p|li)>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+	clozeMark = regexp.MustCompile(`\{\{c\d+::(.*?)(::[^}]*)?\}\}`)
+)
+
+// Text converts a field value to plain text: markup, sound references and cloze
+// markers are removed, entities decoded and whitespace collapsed
+func Text(field string) string {
+	field = soundTag.ReplaceAllString(field, " ")
+	field = breakTag.ReplaceAllString(field, " ")
+	field = htmlTag.ReplaceAllString(field, "")
+	field = clozeMark.ReplaceAllString(field, "$1")
+	field = html.UnescapeString(field)
+	return strings.Join(strings.Fields(field), " ")
+}
+
+// checksum is the duplicate-detection checksum Anki stores in notes.csum: the
+// first 32 bits of the SHA-1 of the sort field
+func checksum(field string) int64 {
+	sum := sha1.Sum([]byte(Text(field)))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
@@ -0,0 +1,211 @@
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReadPackage reads the notes, decks and review history of an .apkg package. The
+// package is copied to a temporary directory because SQLite needs a real file.
+func ReadPackage(r io.Reader) (*Collection, error) {
+	dir, err := os.MkdirTemp("", "apkg-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	packagePath := filepath.Join(dir, "package.apkg")
+	if err := copyToFile(packagePath, r); err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("not an Anki package: %w", err)
+	}
+	defer archive.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		entries[file.Name] = file
+	}
+
+	// Anki 2.1 exports carry a placeholder collection.anki2 next to the real one
+	entry := entries[collectionV21]
+	if entry == nil {
+		entry = entries[collectionLegacy]
+	}
+	if entry == nil {
+		if entries[collectionV21b] != nil {
+			return nil, ErrUnsupportedFormat
+		}
+		return nil, fmt.Errorf("not an Anki package: no collection found")
+	}
+
+	collectionPath := filepath.Join(dir, "collection.db")
+	src, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	err = copyToFile(collectionPath, src)
+	src.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+collectionPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return readCollection(db)
+}
+
+func copyToFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readCollection(db *sql.DB) (*Collection, error) {
+	var modelsJSON, decksJSON string
+	err := db.QueryRow("SELECT models, decks FROM col").Scan(&modelsJSON, &decksJSON)
+	if err != nil {
+		return nil, fmt.Errorf("reading collection: %w", err)
+	}
+
+	var models map[string]struct {
+		ID     int64  `json:"id"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"flds"`
+	}
+	if err := json.Unmarshal([]byte(modelsJSON), &models); err != nil {
+		return nil, fmt.Errorf("reading note types: %w", err)
+	}
+
+	var decks map[string]struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(decksJSON), &decks); err != nil {
+		return nil, fmt.Errorf("reading decks: %w", err)
+	}
+
+	collection := &Collection{
+		Decks:     make(map[int64]string, len(decks)),
+		NoteTypes: make(map[int64]NoteType, len(models)),
+	}
+	for _, deck := range decks {
+		collection.Decks[deck.ID] = deck.Name
+	}
+	for _, model := range models {
+		fields := make([]string, len(model.Fields))
+		for i, field := range model.Fields {
+			fields[i] = field.Name
+		}
+		collection.NoteTypes[model.ID] = NoteType{ID: model.ID, Name: model.Name, Fields: fields}
+	}
+
+	rows, err := db.Query("SELECT id, guid, mid, tags, flds FROM notes ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("reading notes: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int)
+	for rows.Next() {
+		var note Note
+		var tags, fields string
+		if err := rows.Scan(&note.ID, &note.GUID, &note.NoteTypeID, &tags, &fields); err != nil {
+			return nil, fmt.Errorf("reading notes: %w", err)
+		}
+		note.Tags = strings.Fields(tags)
+		note.Fields = strings.Split(fields, fieldSeparator)
+		index[note.ID] = len(collection.Notes)
+		collection.Notes = append(collection.Notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading notes: %w", err)
+	}
+
+	cards, err := db.Query("SELECT nid, did FROM cards ORDER BY nid, ord")
+	if err != nil {
+		return nil, fmt.Errorf("reading cards: %w", err)
+	}
+	defer cards.Close()
+
+	for cards.Next() {
+		var noteID, deckID int64
+		if err := cards.Scan(&noteID, &deckID); err != nil {
+			return nil, fmt.Errorf("reading cards: %w", err)
+		}
+		i, ok := index[noteID]
+		if !ok {
+			continue
+		}
+		note := &collection.Notes[i]
+		if !containsID(note.DeckIDs, deckID) {
+			note.DeckIDs = append(note.DeckIDs, deckID)
+		}
+	}
+	if err := cards.Err(); err != nil {
+		return nil, fmt.Errorf("reading cards: %w", err)
+	}
+
+	// Ease 0 marks manual rescheduling rather than an answer
+	reviews, err := db.Query(`
+		SELECT c.nid, r.id, r.ease, r.time
+		FROM revlog r
+		JOIN cards c ON c.id = r.cid
+		WHERE r.ease BETWEEN 1 AND 4
+		ORDER BY r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("reading review log: %w", err)
+	}
+	defer reviews.Close()
+
+	for reviews.Next() {
+		var noteID, reviewedAt, durationMs int64
+		var review Review
+		if err := reviews.Scan(&noteID, &reviewedAt, &review.Ease, &durationMs); err != nil {
+			return nil, fmt.Errorf("reading review log: %w", err)
+		}
+		i, ok := index[noteID]
+		if !ok {
+			continue
+		}
+		review.Time = time.UnixMilli(reviewedAt).UTC()
+		review.Duration = time.Duration(durationMs) * time.Millisecond
+		collection.Notes[i].Reviews = append(collection.Notes[i].Reviews, review)
+	}
+	if err := reviews.Err(); err != nil {
+		return nil, fmt.Errorf("reading review log: %w", err)
+	}
+
+	return collection, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Deck is the content of a package to write: a single deck of notes sharing one
+// note type. Every note gets one new card per template of the note type.
+type Deck struct {
+	Name     string
+	NoteType NoteType
+	Notes    []ExportNote
+}
+
+// ExportNote is a note to write. Fields are plain text in the order of the note
+// type's fields and are escaped as HTML when written.
+type ExportNote struct {
+	// GUID identifies the note across imports so that importing the same package
+	// again updates notes instead of duplicating them. Derived from the fields
+	// when empty.
+	GUID   string
+	Fields []string
+	Tags   []string
+}
+
+// schema is the schema 11 collection layout understood by every Anki 2.1 release
+const schema = `
+CREATE TABLE col (
+	id integer PRIMARY KEY, crt integer NOT NULL, mod integer NOT NULL, scm integer NOT NULL,
+	ver integer NOT NULL, dty integer NOT NULL, usn integer NOT NULL, ls integer NOT NULL,
+	conf text NOT NULL, models text NOT NULL, decks text NOT NULL, dconf text NOT NULL, tags text NOT NULL
+);
+CREATE TABLE notes (
+	id integer PRIMARY KEY, guid text NOT NULL, mid integer NOT NULL, mod integer NOT NULL,
+	usn integer NOT NULL, tags text NOT NULL, flds text NOT NULL, sfld integer NOT NULL,
+	csum integer NOT NULL, flags integer NOT NULL, data text NOT NULL
+);
+CREATE TABLE cards (
+	id integer PRIMARY KEY, nid integer NOT NULL, did integer NOT NULL, ord integer NOT NULL,
+	mod integer NOT NULL, usn integer NOT NULL, type integer NOT NULL, queue integer NOT NULL,
+	due integer NOT NULL, ivl integer NOT NULL, factor integer NOT NULL, reps integer NOT NULL,
+	lapses integer NOT NULL, left integer NOT NULL, odue integer NOT NULL, odid integer NOT NULL,
+	flags integer NOT NULL, data text NOT NULL
+);
+CREATE TABLE revlog (
+	id integer PRIMARY KEY, cid integer NOT NULL, usn integer NOT NULL, ease integer NOT NULL,
+	ivl integer NOT NULL, lastIvl integer NOT NULL, factor integer NOT NULL, time integer NOT NULL,
+	type integer NOT NULL
+);
+CREATE TABLE graves (usn integer NOT NULL, oid integer NOT NULL, type integer NOT NULL);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// defaultDeckConfig is Anki's stock deck options group
+const defaultDeckConfig = `{"1": {
+	"id": 1, "mod": 0, "name": "Default", "usn": 0, "maxTaken": 60, "autoplay": true,
+	"timer": 0, "replayq": true, "dyn": false,
+	"new": {"bury": false, "delays": [1, 10], "initialFactor": 2500, "ints": [1, 4, 0], "order": 1, "perDay": 20},
+	"lapse": {"delays": [10], "leechAction": 1, "leechFails": 8, "minInt": 1, "mult": 0},
+	"rev": {"bury": false, "ease4": 1.3, "ivlFct": 1, "maxIvl": 36500, "perDay": 200, "hardFactor": 1.2}
+}}`
+
+const latexPre = "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n" +
+	"\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n" +
+	"\\setlength{\\parindent}{0in}\n\\begin{document}\n"
+
+// WritePackage writes deck as an .apkg package. The collection is built in a
+// temporary directory and then zipped into w.
+func WritePackage(w io.Writer, deck *Deck) error {
+	if len(deck.NoteType.Fields) == 0 || len(deck.NoteType.Templates) == 0 {
+		return fmt.Errorf("note type %q needs at least one field and one template", deck.NoteType.Name)
+	}
+
+	dir, err := os.MkdirTemp("", "apkg-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	collectionPath := filepath.Join(dir, "collection.anki2")
+	if err := writeCollection(collectionPath, deck, time.Now()); err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	entry, err := archive.Create(collectionLegacy)
+	if err != nil {
+		return err
+	}
+	collection, err := os.Open(collectionPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, collection)
+	collection.Close()
+	if err != nil {
+		return err
+	}
+
+	// The package carries no media files
+	media, err := archive.Create(mediaManifest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(media, "{}"); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeCollection(path string, deck *Deck, now time.Time) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("creating collection: %w", err)
+	}
+
+	nowMs := now.UnixMilli()
+	deckID := nowMs
+	noteTypeID := deck.NoteType.ID
+	if noteTypeID == 0 {
+		// A stable ID lets Anki recognise the note type on later imports
+		noteTypeID = 1_500_000_000_000 + checksum(deck.NoteType.Name)
+	}
+
+	models, err := json.Marshal(map[string]interface{}{
+		fmt.Sprint(noteTypeID): noteTypeJSON(deck.NoteType, noteTypeID, deckID, now.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+	decks, err := json.Marshal(map[string]interface{}{
+		"1":                deckJSON(1, "Default", now.Unix()),
+		fmt.Sprint(deckID): deckJSON(deckID, deck.Name, now.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+	conf, err := json.Marshal(map[string]interface{}{
+		"nextPos":       len(deck.Notes) + 1,
+		"estTimes":      true,
+		"activeDecks":   []int64{1},
+		"sortType":      "noteFld",
+		"timeLim":       0,
+		"sortBackwards": false,
+		"addToCur":      true,
+		"curDeck":       1,
+		"newSpread":     0,
+		"dueCounts":     true,
+		"curModel":      fmt.Sprint(noteTypeID),
+		"collapseTime":  1200,
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO col (id, crt, mod, scm, ver, dty, usn, ls, conf, models, decks, dconf, tags)
+		VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Truncate(24*time.Hour).Unix(), nowMs, nowMs,
+		string(conf), string(models), string(decks), defaultDeckConfig)
+	if err != nil {
+		return fmt.Errorf("writing collection: %w", err)
+	}
+
+	for i, note := range deck.Notes {
+		fields := make([]string, len(deck.NoteType.Fields))
+		for j := range fields {
+			if j < len(note.Fields) {
+				fields[j] = html.EscapeString(note.Fields[j])
+			}
+		}
+		guid := note.GUID
+		if guid == "" {
+			guid = GUID(strings.Join(fields, fieldSeparator))
+		}
+		tags := ""
+		if len(note.Tags) > 0 {
+			tags = " " + strings.Join(note.Tags, " ") + " "
+		}
+
+		noteID := nowMs + int64(i)
+		_, err := tx.Exec(`
+			INSERT INTO notes (id, guid, mid, mod, usn, tags, flds, sfld, csum, flags, data)
+			VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			noteID, guid, noteTypeID, now.Unix(), tags,
+			strings.Join(fields, fieldSeparator), Text(fields[0]), checksum(fields[0]))
+		if err != nil {
+			return fmt.Errorf("writing note %d: %w", i+1, err)
+		}
+
+		for ord := range deck.NoteType.Templates {
+			cardID := nowMs + int64(i*len(deck.NoteType.Templates)+ord)
+			_, err := tx.Exec(`
+				INSERT INTO cards (
+					id, nid, did, ord, mod, usn, type, queue, due,
+					ivl, factor, reps, lapses, left, odue, odid, flags, data
+				)
+				VALUES (?, ?, ?, ?, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+				cardID, noteID, deckID, ord, now.Unix(), i+1)
+			if err != nil {
+				return fmt.Errorf("writing cards of note %d: %w", i+1, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func noteTypeJSON(noteType NoteType, id, deckID, mod int64) map[string]interface{} {
+	fields := make([]map[string]interface{}, len(noteType.Fields))
+	for i, name := range noteType.Fields {
+		fields[i] = map[string]interface{}{
+			"name": name, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		}
+	}
+
+	templates := make([]map[string]interface{}, len(noteType.Templates))
+	required := make([][]interface{}, len(noteType.Templates))
+	for i, template := range noteType.Templates {
+		templates[i] = map[string]interface{}{
+			"name": template.Name, "ord": i, "qfmt": template.Front, "afmt": template.Back,
+			"bqfmt": "", "bafmt": "", "did": nil, "bfont": "", "bsize": 0,
+		}
+		// A card is generated when any field on its front is non-empty
+		var fieldOrds []int
+		for j, name := range noteType.Fields {
+			if strings.Contains(template.Front, "{{"+name+"}}") {
+				fieldOrds = append(fieldOrds, j)
+			}
+		}
+		required[i] = []interface{}{i, "any", fieldOrds}
+	}
+
+	return map[string]interface{}{
+		"id": id, "name": noteType.Name, "type": 0, "mod": mod, "usn": -1,
+		"sortf": 0, "did": deckID, "tmpls": templates, "flds": fields, "css": noteType.CSS,
+		"latexPre": latexPre, "latexPost": "\\end{document}", "latexsvg": false,
+		"req": required, "tags": []string{}, "vers": []string{},
+	}
+}
+
+func deckJSON(id int64, name string, mod int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id": id, "name": name, "mod": mod, "usn": -1, "desc": "", "dyn": 0, "conf": 1,
+		"collapsed": false, "browserCollapsed": false, "extendNew": 0, "extendRev": 0,
+		"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+	}
+}
+
+// GUID derives a short, stable note GUID from a key such as the ID of the record
+// the note was exported from
+func GUID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return base64.RawStdEncoding.EncodeToString(sum[:8])
+}
@@ -0,0 +1,143 @@
+// Package answer grades a learner's typed answer against the accepted answers.
+package answer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Verdict is the outcome of checking an answer
+type Verdict string
+
+const (
+	Correct       Verdict = "correct"
+	AlmostCorrect Verdict = "almost_correct"
+	Incorrect     Verdict = "incorrect"
+)
+
+// Result describes how close an answer came to the nearest accepted answer
+type Result struct {
+	Verdict Verdict
+	// Exact is set when the answer matched without any normalization
+	Exact bool
+	// Expected is the accepted answer closest to the learner's answer
+	Expected string
+	// Distance is the edit distance between the normalized answer and Expected
+	Distance int
+}
+
+// Check compares an answer against each accepted answer after applying normalize
+// to both. Answers within a small edit distance of an accepted answer are almost
+// correct: one edit for short answers and roughly one per five letters otherwise.
+func Check(given string, accepted []string, normalize func(string) string) Result {
+	given = trimAnswer(given)
+	normalizedGiven := normalize(given)
+
+	best := Result{Verdict: Incorrect, Distance: -1}
+	for _, candidate := range accepted {
+		if strings.EqualFold(given, trimAnswer(candidate)) {
+			return Result{Verdict: Correct, Exact: true, Expected: candidate}
+		}
+
+		normalized := normalize(candidate)
+		if normalized == "" {
+			continue
+		}
+		distance := Levenshtein(normalizedGiven, normalized)
+		if best.Distance < 0 || distance < best.Distance {
+			best.Expected = candidate
+			best.Distance = distance
+		}
+	}
+
+	switch {
+	case best.Distance == 0:
+		best.Verdict = Correct
+	case best.Distance > 0 && best.Distance <= tolerance(best.Expected, normalize):
+		best.Verdict = AlmostCorrect
+	}
+	if best.Distance < 0 {
+		best.Distance = 0
+	}
+	return best
+}
+
+// trimAnswer removes surrounding whitespace and punctuation such as a final full stop
+func trimAnswer(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+}
+
+// tolerance returns how many edits still count as almost correct
+func tolerance(expected string, normalize func(string) string) int {
+	allowed := len([]rune(normalize(expected))) / 5
+	if allowed < 1 {
+		allowed = 1
+	}
+	return allowed
+}
+
+// Levenshtein returns the number of single-rune insertions, deletions and
+// substitutions needed to turn a into b
+func Levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// English lowercases an English answer, removes punctuation and drops a leading
+// article or infinitive "to", so "The book." matches "book" and "to eat" matches "eat"
+func English(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	if len(fields) > 1 {
+		switch fields[0] {
+		case "a", "an", "the", "to":
+			fields = fields[1:]
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// Alternatives splits a stored answer such as "hello, hi / hey" into its accepted forms
+func Alternatives(s string) []string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == '/'
+	})
+	alternatives := []string{s}
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" && part != s {
+			alternatives = append(alternatives, part)
+		}
+	}
+	return alternatives
+}
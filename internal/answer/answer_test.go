@@ -0,0 +1,152 @@
+package answer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     string
+		accepted  []string
+		normalize func(string) string
+		want      Result
+	}{
+		{
+			name:      "exact",
+			given:     "book",
+			accepted:  []string{"book"},
+			normalize: English,
+			want:      Result{Verdict: Correct, Exact: true, Expected: "book"},
+		},
+		{
+			name:      "exact ignores case and final punctuation",
+			given:     " Book. ",
+			accepted:  []string{"book"},
+			normalize: English,
+			want:      Result{Verdict: Correct, Exact: true, Expected: "book"},
+		},
+		{
+			name:      "article dropped",
+			given:     "The book.",
+			accepted:  []string{"book"},
+			normalize: English,
+			want:      Result{Verdict: Correct, Expected: "book"},
+		},
+		{
+			name:      "typo",
+			given:     "libary",
+			accepted:  []string{"library"},
+			normalize: English,
+			want:      Result{Verdict: AlmostCorrect, Expected: "library", Distance: 1},
+		},
+		{
+			name:      "nearest alternative",
+			given:     "hye",
+			accepted:  []string{"hello", "hey"},
+			normalize: English,
+			want:      Result{Verdict: Incorrect, Expected: "hey", Distance: 2},
+		},
+		{
+			name:      "wrong",
+			given:     "table",
+			accepted:  []string{"book"},
+			normalize: English,
+			want:      Result{Verdict: Incorrect, Expected: "book", Distance: 5},
+		},
+		{
+			name:      "arabic without tashkeel",
+			given:     "كتاب",
+			accepted:  []string{"كِتَاب"},
+			normalize: arabic.Normalize,
+			want:      Result{Verdict: Correct, Expected: "كِتَاب"},
+		},
+		{
+			name:      "arabic with a missing letter",
+			given:     "كتب",
+			accepted:  []string{"كِتَاب"},
+			normalize: arabic.Normalize,
+			want:      Result{Verdict: AlmostCorrect, Expected: "كِتَاب", Distance: 1},
+		},
+		{
+			name:      "transliteration marks",
+			given:     "maʿa salāma",
+			accepted:  []string{"ma'a salama"},
+			normalize: arabic.NormalizeRoman,
+			want:      Result{Verdict: Correct, Expected: "ma'a salama"},
+		},
+		{
+			name:      "nothing accepted",
+			given:     "book",
+			normalize: English,
+			want:      Result{Verdict: Incorrect},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(tt.given, tt.accepted, tt.normalize); got != tt.want {
+				t.Errorf("Check(%q, %q) = %+v, want %+v", tt.given, tt.accepted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"book", "", 4},
+		{"", "book", 4},
+		{"kitten", "sitting", 3},
+		{"كتاب", "كتب", 1},
+		{"same", "same", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEnglish(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"The Book.", "book"},
+		{"to eat", "eat"},
+		{"an apple", "apple"},
+		{"a", "a"},
+		{"don't go!", "don't go"},
+		{"  good   morning ", "good morning"},
+	}
+
+	for _, tt := range tests {
+		if got := English(tt.in); got != tt.want {
+			t.Errorf("English(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAlternatives(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"hello", []string{"hello"}},
+		{"hello, hi / hey", []string{"hello, hi / hey", "hello", "hi", "hey"}},
+		{"eat; ", []string{"eat; ", "eat"}},
+	}
+
+	for _, tt := range tests {
+		if got := Alternatives(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Alternatives(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package arabic normalizes Arabic script and its Latin transliterations so that
+// spelling variants learners commonly type compare equal.
+package arabic
+
+import (
+	"strings"
+	"unicode"
+)
+
+const tatweel = 'ـ'
+
+// letterForms maps letter variants onto the form used for comparison
+var letterForms = map[rune]rune{
+	'آ': 'ا', // alef with madda -> alef
+	'أ': 'ا', // alef with hamza above -> alef
+	'إ': 'ا', // alef with hamza below -> alef
+	'ٱ': 'ا', // alef wasla -> alef
+	'ة': 'ه', // ta marbuta -> ha
+	'ى': 'ي', // alef maqsura -> ya
+	'ؤ': 'و', // waw with hamza -> waw
+	'ئ': 'ي', // ya with hamza -> ya
+}
+
+// isTashkeel reports whether r is a harakat or Quranic annotation mark
+func isTashkeel(r rune) bool {
+	return (r >= 'ً' && r <= 'ٟ') ||
+		r == 'ٰ' ||
+		(r >= 'ۖ' && r <= 'ۭ')
+}
+
+// Normalize strips tashkeel and tatweel from Arabic text, unifies alef, hamza,
+// ta marbuta and alef maqsura forms, removes punctuation and collapses whitespace.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case isTashkeel(r) || r == tatweel:
+			continue
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			b.WriteRune(' ')
+		default:
+			if form, ok := letterForms[r]; ok {
+				r = form
+			}
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// latinLetters maps the accented letters used by transliteration schemes to plain ASCII
+var latinLetters = map[rune]rune{
+	'ā': 'a', 'á': 'a', 'â': 'a',
+	'ī': 'i', 'í': 'i', 'î': 'i',
+	'ū': 'u', 'ú': 'u', 'û': 'u',
+	'ḥ': 'h', 'ḫ': 'h',
+	'ṣ': 's', 'š': 's',
+	'ḍ': 'd', 'ḏ': 'd',
+	'ṭ': 't', 'ṯ': 't',
+	'ẓ': 'z', 'ż': 'z',
+	'ġ': 'g',
+}
+
+// NormalizeRoman lowercases a transliteration, drops the apostrophes and marks used
+// for hamza and ayn, removes accents and hyphens, and collapses doubled letters, so
+// "ma'a salama", "maʿa salāma" and "maa salama" all compare equal.
+func NormalizeRoman(s string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range strings.ToLower(s) {
+		if form, ok := latinLetters[r]; ok {
+			r = form
+		}
+		switch {
+		case unicode.Is(unicode.Lm, r):
+			// ʿ and ʾ are modifier letters, but mark ayn and hamza like apostrophes
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if r == last {
+				continue
+			}
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			r = ' '
+		default:
+			// apostrophes, ʿ, ʾ and other marks carry no letter of their own
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// IsArabicScript reports whether s contains at least one Arabic letter
+func IsArabicScript(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Arabic, r) && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
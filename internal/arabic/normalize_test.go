@@ -0,0 +1,68 @@
+package arabic
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"tashkeel", "مَكْتَبَةٌ", "مكتبه"},
+		{"hamza on alef", "أَحْمَد", "احمد"},
+		{"alef with madda", "آمن", "امن"},
+		{"alef maqsura", "إلى", "الي"},
+		{"hamza on waw and ya", "مؤمن سائل", "مومن سايل"},
+		{"tatweel", "كـتـاب", "كتاب"},
+		{"punctuation and whitespace", " مرحبا،  يا صديقي! ", "مرحبا يا صديقي"},
+		{"punctuation only", "؟!.", ""},
+		{"plain text unchanged", "كتاب", "كتاب"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRoman(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"apostrophe", "ma'a salama", "ma salama"},
+		{"ayn mark and macron", "maʿa salāma", "ma salama"},
+		{"doubled letter", "maa salama", "ma salama"},
+		{"hamza mark and hyphen", "as-salamu ʾalaykum", "as salamu alaykum"},
+		{"dotted letters and case", "Ḥabībī", "habibi"},
+		{"underscores and spaces", "  kitab_jadid ", "kitab jadid"},
+		{"digits", "arba3a", "arba3a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeRoman(tt.in); got != tt.want {
+				t.Errorf("NormalizeRoman(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsArabicScript(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"كتاب", true},
+		{"the كتاب", true},
+		{"kitab", false},
+		{"،؟", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsArabicScript(tt.in); got != tt.want {
+			t.Errorf("IsArabicScript(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package config loads the server configuration. Settings start from their
+// defaults and are overridden, in turn, by an optional YAML or TOML file,
+// LANG_PORTAL_* environment variables and command-line flags.
+//
+// Every setting has one name in three spellings: the flag -gin-mode, the file key
+// gin_mode and the environment variable LANG_PORTAL_GIN_MODE.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// EnvPrefix starts the names of the environment variables read by Load
+const EnvPrefix = "LANG_PORTAL_"
+
+// Gin modes accepted by GinMode
+var ginModes = map[string]bool{"debug": true, "release": true, "test": true}
+
+// legacyEnv maps settings to the environment variables older versions read. They
+// apply when the LANG_PORTAL_* variable is not set.
+var legacyEnv = map[string]string{
+	"gin-mode": "GIN_MODE",
+}
+
+// secrets are the settings whose values are not logged
+var secrets = map[string]bool{"admin-token": true, "launch-secret": true}
+
+// Config is the configuration of the server
+type Config struct {
+	Database string
+	Listen   string
+	// Migrate applies pending migrations at startup
+	Migrate bool
+	GinMode string
+	// LogLevel is one of debug, info, warn or error
+	LogLevel string
+	// CORSOrigins are the origins browsers may call the API from; "*" allows any
+	CORSOrigins []string
+	// TrustedProxies are the addresses whose X-Forwarded-For, X-Forwarded-Proto and
+	// X-Forwarded-Host headers are believed. None are trusted when empty.
+	TrustedProxies []string
+	// TLSCert and TLSKey serve HTTPS when both are set
+	TLSCert string
+	TLSKey  string
+	// ShutdownTimeout is how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration
+
+	AdminToken         string
+	LaunchSecret       string
+	LaunchTokenTTL     time.Duration
+	SessionTokenTTL    time.Duration
+	SessionIdleTimeout time.Duration
+	APIBase            string
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Database:           "words.db",
+		Listen:             ":8080",
+		Migrate:            true,
+		GinMode:            "debug",
+		LogLevel:           "info",
+		ShutdownTimeout:    15 * time.Second,
+		LaunchTokenTTL:     service.DefaultLaunchTokenTTL,
+		SessionTokenTTL:    service.DefaultSessionTokenTTL,
+		SessionIdleTimeout: service.DefaultSessionIdleTimeout,
+	}
+}
+
+// bind registers a flag for every setting of c, with its current value as default
+func bind(fs *flag.FlagSet, c *Config) {
+	fs.StringVar(&c.Database, "database", c.Database, "path of the SQLite database")
+	fs.StringVar(&c.Listen, "listen", c.Listen, "address to listen on")
+	fs.BoolVar(&c.Migrate, "migrate", c.Migrate, "apply pending database migrations at startup")
+	fs.StringVar(&c.GinMode, "gin-mode", c.GinMode, "Gin mode: debug, release or test")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "lowest level logged: debug, info, warn or error")
+	fs.Var((*listValue)(&c.CORSOrigins), "cors-origins", "comma-separated origins allowed to call the API from browsers (* for any)")
+	fs.Var((*listValue)(&c.TrustedProxies), "trusted-proxies", "comma-separated proxy addresses or CIDRs whose forwarding headers are trusted")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "TLS certificate file; serves HTTPS together with -tls-key")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "TLS private key file")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "how long in-flight requests may take to finish on shutdown")
+	fs.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "token required by admin endpoints (disabled when empty)")
+	fs.StringVar(&c.LaunchSecret, "launch-secret", c.LaunchSecret, "secret for signing launch and session tokens (random per run when empty)")
+	fs.DurationVar(&c.LaunchTokenTTL, "launch-token-ttl", c.LaunchTokenTTL, "how long launch tokens stay valid")
+	fs.DurationVar(&c.SessionTokenTTL, "session-token-ttl", c.SessionTokenTTL, "how long session tokens stay valid")
+	fs.DurationVar(&c.SessionIdleTimeout, "session-idle-timeout", c.SessionIdleTimeout, "abandon study sessions without reviews for this long")
+	fs.StringVar(&c.APIBase, "api-base", c.APIBase, "API URL substituted for {api_base} in launch URLs (default: the URL the request used)")
+}
+
+// Load reads the configuration from the command-line arguments, the environment
+// and the file named by -config or LANG_PORTAL_CONFIG. It exits on invalid flags,
+// like the flag package does.
+func Load(args []string) (*Config, error) {
+	cli := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	bind(cli, Default())
+	path := cli.String("config", os.Getenv(EnvPrefix+"CONFIG"), "YAML or TOML configuration file")
+	cli.Parse(args)
+
+	cfg := Default()
+	settings := flag.NewFlagSet("config", flag.ContinueOnError)
+	bind(settings, cfg)
+
+	if *path != "" {
+		if err := loadFile(settings, *path); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	settings.VisitAll(func(f *flag.Flag) {
+		name := EnvVar(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok && legacyEnv[f.Name] != "" {
+			name = legacyEnv[f.Name]
+			value, ok = os.LookupEnv(name)
+		}
+		if ok && err == nil {
+			if setErr := settings.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid %s %q: %w", name, value, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cli.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			// Flags were validated when parsed
+			settings.Set(f.Name, f.Value.String())
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// EnvVar returns the environment variable of a setting
+func EnvVar(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Validate checks the settings that flags cannot check on their own
+func (c *Config) Validate() error {
+	if !ginModes[c.GinMode] {
+		return fmt.Errorf("invalid gin mode %q: must be debug, release or test", c.GinMode)
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tls-cert and tls-key must be set together")
+	}
+	return nil
+}
+
+// String lists the settings as name=value pairs, hiding secrets
+func (c *Config) String() string {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	bind(fs, c)
+
+	var pairs []string
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secrets[f.Name] && value != "" {
+			value = "[hidden]"
+		}
+		pairs = append(pairs, f.Name+"="+strconv.Quote(value))
+	})
+	return strings.Join(pairs, " ")
+}
+
+// loadFile applies the settings in a YAML or TOML file, chosen by its extension
+func loadFile(settings *flag.FlagSet, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := strings.ReplaceAll(key, "_", "-")
+		if settings.Lookup(name) == nil || strings.Contains(key, "-") {
+			return fmt.Errorf("config file %s: unknown setting %q", path, key)
+		}
+		value, err := fileValue(values[key])
+		if err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		if err := settings.Set(name, value); err != nil {
+			return fmt.Errorf("config file %s: invalid %s %q: %w", path, key, value, err)
+		}
+	}
+	return nil
+}
+
+// fileValue turns a value decoded from a config file into the text a flag accepts
+func fileValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return "", errors.New("list items must be strings")
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// listValue is a flag holding a comma-separated list
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+    "bytes"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// ExportArchive handles the GET /api/export endpoint
+func (h *Handler) ExportArchive(c *gin.Context) {
+    format := strings.ToLower(c.DefaultQuery("format", service.ArchiveFormatJSON))
+
+    // Build the archive first so failures can still be reported as JSON
+    var buf bytes.Buffer
+    if err := h.svc.ExportArchive(&buf, format); err != nil {
+        c.Error(err)
+        return
+    }
+
+    contentType := "application/json"
+    if format == service.ArchiveFormatZip {
+        contentType = "application/zip"
+    }
+    filename := fmt.Sprintf("lang-portal-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+    c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+    c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// maxArchiveSize is the largest archive POST /api/import accepts
+const maxArchiveSize = 100 << 20
+
+// RestoreArchive handles the POST /api/import endpoint
+func (h *Handler) RestoreArchive(c *gin.Context) {
+    body, _, ok := importSource(c, maxArchiveSize)
+    if !ok {
+        return
+    }
+    defer body.Close()
+
+    report, err := h.svc.RestoreArchive(body)
+    if err != nil {
+        uploadError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
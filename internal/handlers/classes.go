@@ -0,0 +1,155 @@
+package handlers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// GetClasses handles the GET /api/classes endpoint
+func (h *Handler) GetClasses(c *gin.Context) {
+    page, perPage, err := pageParams(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    classes, pagination, err := h.svc.GetClasses(currentUser(c), page, perPage)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "items":      classes,
+        "pagination": pagination,
+    })
+}
+
+// CreateClass handles the POST /api/classes endpoint
+func (h *Handler) CreateClass(c *gin.Context) {
+    var req service.ClassRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    class, err := h.svc.CreateClass(currentUser(c), &req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, class)
+}
+
+// GetClass handles the GET /api/classes/:id endpoint
+func (h *Handler) GetClass(c *gin.Context) {
+    id, ok := paramID(c, "id", "class")
+    if !ok {
+        return
+    }
+
+    class, err := h.svc.GetClass(id, currentUser(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, class)
+}
+
+// AddClassMembers handles the POST /api/classes/:id/members endpoint
+func (h *Handler) AddClassMembers(c *gin.Context) {
+    changeClassMembers(c, h.svc.AddClassMembers)
+}
+
+// RemoveClassMembers handles the DELETE /api/classes/:id/members endpoint
+func (h *Handler) RemoveClassMembers(c *gin.Context) {
+    changeClassMembers(c, h.svc.RemoveClassMembers)
+}
+
+func changeClassMembers(c *gin.Context, change func(classID, teacherID int64, usernames []string) (*service.ClassMembersResponse, error)) {
+    id, ok := paramID(c, "id", "class")
+    if !ok {
+        return
+    }
+
+    var req service.ClassMembersRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    result, err := change(id, currentUser(c), req.Usernames)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// GetClassAssignments handles the GET /api/classes/:id/assignments endpoint
+func (h *Handler) GetClassAssignments(c *gin.Context) {
+    id, ok := paramID(c, "id", "class")
+    if !ok {
+        return
+    }
+
+    assignments, err := h.svc.GetClassAssignments(id, currentUser(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"items": assignments})
+}
+
+// CreateAssignment handles the POST /api/classes/:id/assignments endpoint
+func (h *Handler) CreateAssignment(c *gin.Context) {
+    id, ok := paramID(c, "id", "class")
+    if !ok {
+        return
+    }
+
+    var req service.AssignmentRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    assignment, err := h.svc.CreateAssignment(id, currentUser(c), &req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, assignment)
+}
+
+// GetAssignmentReport handles the GET /api/assignments/:id/report endpoint
+func (h *Handler) GetAssignmentReport(c *gin.Context) {
+    id, ok := paramID(c, "id", "assignment")
+    if !ok {
+        return
+    }
+
+    report, err := h.svc.GetAssignmentReport(id, currentUser(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
+
+// GetOpenAssignments handles the GET /api/me/assignments endpoint
+func (h *Handler) GetOpenAssignments(c *gin.Context) {
+    assignments, err := h.svc.GetOpenAssignments(currentUser(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"items": assignments})
+}
+
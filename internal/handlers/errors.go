@@ -0,0 +1,43 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+)
+
+// GetErrorCodes handles the GET /api/errors endpoint
+func (h *Handler) GetErrorCodes(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"items": middleware.ErrorCodes})
+}
+
+// paramID parses an ID route parameter naming an entity, recording
+// INVALID_<ENTITY>_ID when it is not a number
+func paramID(c *gin.Context, param, entity string) (int64, bool) {
+    id, err := strconv.ParseInt(c.Param(param), 10, 64)
+    if err != nil {
+        c.Error(&middleware.Error{
+            Code:    "INVALID_" + strings.ToUpper(entity) + "_ID",
+            Message: "Invalid " + entity + " ID",
+        })
+        return 0, false
+    }
+    return id, true
+}
+
+// bindJSON decodes the JSON request body into req, recording INVALID_REQUEST when
+// it does not fit
+func bindJSON(c *gin.Context, req interface{}) bool {
+    if err := c.ShouldBindJSON(req); err != nil {
+        c.Error(&middleware.Error{
+            Code:    "INVALID_REQUEST",
+            Message: "Invalid request body",
+            Details: err.Error(),
+        })
+        return false
+    }
+    return true
+}
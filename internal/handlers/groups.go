@@ -1,27 +1,31 @@
 package handlers
 
 import (
-    "log"
+    "bytes"
+    "mime"
     "net/http"
-    "strconv"
-    "database/sql"
 
     "github.com/gin-gonic/gin"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
 )
 
 // GetGroups handles the GET /api/groups endpoint
-func GetGroups(c *gin.Context) {
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+func (h *Handler) GetGroups(c *gin.Context) {
+    page, perPage, err := pageParams(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    opts, err := listOptions(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    groups, pagination, err := service.GetGroups(page, perPage)
+    groups, pagination, err := h.svc.GetGroups(opts, page, perPage)
     if err != nil {
-        log.Printf("Error getting groups: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "GROUPS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -32,30 +36,15 @@ func GetGroups(c *gin.Context) {
 }
 
 // GetGroup handles the GET /api/groups/:id endpoint
-func GetGroup(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid group ID",
-            "code":  "INVALID_GROUP_ID",
-        })
+func (h *Handler) GetGroup(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
         return
     }
 
-    group, err := service.GetGroup(id)
+    group, err := h.svc.GetGroup(id)
     if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Group not found",
-                "code":  "GROUP_NOT_FOUND",
-            })
-            return
-        }
-        log.Printf("Error getting group %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "GROUP_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -63,26 +52,27 @@ func GetGroup(c *gin.Context) {
 }
 
 // GetGroupWords handles the GET /api/groups/:id/words endpoint
-func GetGroupWords(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+func (h *Handler) GetGroupWords(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    page, perPage, err := pageParams(c)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid group ID",
-            "code":  "INVALID_GROUP_ID",
-        })
+        c.Error(err)
         return
     }
 
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+    opts, err := listOptions(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    words, pagination, err := service.GetGroupWords(id, page, perPage)
+    words, pagination, err := h.svc.GetGroupWords(id, currentUser(c), opts, page, perPage)
     if err != nil {
-        log.Printf("Error getting words for group %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "GROUP_WORDS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -93,26 +83,27 @@ func GetGroupWords(c *gin.Context) {
 }
 
 // GetGroupStudySessions handles the GET /api/groups/:id/study_sessions endpoint
-func GetGroupStudySessions(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+func (h *Handler) GetGroupStudySessions(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    page, perPage, err := pageParams(c)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid group ID",
-            "code":  "INVALID_GROUP_ID",
-        })
+        c.Error(err)
         return
     }
 
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+    opts, err := listOptions(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    sessions, pagination, err := service.GetGroupStudySessions(id, page, perPage)
+    sessions, pagination, err := h.svc.GetGroupStudySessions(id, currentUser(c), opts, page, perPage)
     if err != nil {
-        log.Printf("Error getting study sessions for group %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "GROUP_SESSIONS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -121,3 +112,105 @@ func GetGroupStudySessions(c *gin.Context) {
         "pagination": pagination,
     })
 }
+
+// CreateGroup handles the POST /api/groups endpoint
+func (h *Handler) CreateGroup(c *gin.Context) {
+    var req service.GroupRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    group, err := h.svc.CreateGroup(&req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, group)
+}
+
+// UpdateGroup handles the PUT /api/groups/:id endpoint
+func (h *Handler) UpdateGroup(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    var req service.GroupRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    group, err := h.svc.UpdateGroup(id, &req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup handles the DELETE /api/groups/:id endpoint
+func (h *Handler) DeleteGroup(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    result, err := h.svc.DeleteGroup(id)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// AddGroupWords handles the POST /api/groups/:id/words endpoint
+func (h *Handler) AddGroupWords(c *gin.Context) {
+    changeGroupWords(c, h.svc.AddGroupWords)
+}
+
+// RemoveGroupWords handles the DELETE /api/groups/:id/words endpoint
+func (h *Handler) RemoveGroupWords(c *gin.Context) {
+    changeGroupWords(c, h.svc.RemoveGroupWords)
+}
+
+func changeGroupWords(c *gin.Context, change func(groupID int64, wordIDs []int64) (*service.GroupWordsResponse, error)) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    var req service.GroupWordsRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    result, err := change(id, req.WordIDs)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// ExportGroupAnki handles the GET /api/groups/:id/export/anki endpoint
+func (h *Handler) ExportGroupAnki(c *gin.Context) {
+    id, ok := paramID(c, "id", "group")
+    if !ok {
+        return
+    }
+
+    // Build the package first so failures can still be reported as JSON
+    var buf bytes.Buffer
+    filename, err := h.svc.ExportGroupAnki(id, &buf)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+    c.Data(http.StatusOK, "application/apkg", buf.Bytes())
+}
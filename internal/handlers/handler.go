@@ -0,0 +1,15 @@
+package handlers
+
+import (
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// Handler serves the HTTP API on top of a Service
+type Handler struct {
+    svc *service.Service
+}
+
+// New returns a Handler that serves the API with svc
+func New(svc *service.Service) *Handler {
+    return &Handler{svc: svc}
+}
@@ -1,31 +1,18 @@
 package handlers
 
 import (
-    "database/sql"
-    "log"
     "net/http"
-    "strconv"
 
     "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
 )
 
 // GetLastStudySession handles the GET /api/dashboard/last_study_session endpoint
-func GetLastStudySession(c *gin.Context) {
-    session, err := service.GetLastStudySession()
+func (h *Handler) GetLastStudySession(c *gin.Context) {
+    session, err := h.svc.GetLastStudySession(currentUser(c))
     if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "No study sessions found",
-                "code":  "NO_STUDY_SESSIONS",
-            })
-            return
-        }
-        log.Printf("Error getting last study session: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "LAST_SESSION_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -33,14 +20,10 @@ func GetLastStudySession(c *gin.Context) {
 }
 
 // GetStudyProgress handles the GET /api/dashboard/study_progress endpoint
-func GetStudyProgress(c *gin.Context) {
-    progress, err := service.GetStudyProgress()
+func (h *Handler) GetStudyProgress(c *gin.Context) {
+    progress, err := h.svc.GetStudyProgress(currentUser(c))
     if err != nil {
-        log.Printf("Error getting study progress: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "STUDY_PROGRESS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -48,14 +31,10 @@ func GetStudyProgress(c *gin.Context) {
 }
 
 // GetQuickStats handles the GET /api/dashboard/quick-stats endpoint
-func GetQuickStats(c *gin.Context) {
-    stats, err := service.GetQuickStats()
+func (h *Handler) GetQuickStats(c *gin.Context) {
+    stats, err := h.svc.GetQuickStats(currentUser(c))
     if err != nil {
-        log.Printf("Error getting quick stats: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "QUICK_STATS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -63,17 +42,22 @@ func GetQuickStats(c *gin.Context) {
 }
 
 // GetStudyActivities handles the GET /api/study_activities endpoint
-func GetStudyActivities(c *gin.Context) {
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+func (h *Handler) GetStudyActivities(c *gin.Context) {
+    page, perPage, err := pageParams(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    activities, pagination, err := service.GetStudyActivities(page, perPage)
+    opts, err := listOptions(c)
     if err != nil {
-        log.Printf("Error getting study activities: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "STUDY_ACTIVITIES_FETCH_ERROR",
-        })
+        c.Error(err)
+        return
+    }
+
+    activities, pagination, err := h.svc.GetStudyActivities(currentUser(c), opts, page, perPage)
+    if err != nil {
+        c.Error(err)
         return
     }
 
@@ -92,56 +76,42 @@ func GetStudyActivities(c *gin.Context) {
 
 
 // GetStudyActivity handles the GET /api/study_activities/:id endpoint
-func GetStudyActivity(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid activity ID",
-            "code":  "INVALID_ACTIVITY_ID",
-        })
+func (h *Handler) GetStudyActivity(c *gin.Context) {
+    id, ok := paramID(c, "id", "activity")
+    if !ok {
         return
     }
 
-    activity, err := service.GetStudyActivity(id)
+    activity, err := h.svc.GetStudyActivity(id, currentUser(c))
     if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Activity not found",
-                "code":  "ACTIVITY_NOT_FOUND",
-            })
-            return
-        }
-        log.Printf("Error getting activity %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "ACTIVITY_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
     c.JSON(http.StatusOK, activity)
 }
 // GetStudyActivitySessions handles the GET /api/study_activities/:id/study_sessions endpoint
-func GetStudyActivitySessions(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+func (h *Handler) GetStudyActivitySessions(c *gin.Context) {
+    id, ok := paramID(c, "id", "activity")
+    if !ok {
+        return
+    }
+
+    page, perPage, err := pageParams(c)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid activity ID",
-            "code":  "INVALID_ACTIVITY_ID",
-        })
+        c.Error(err)
         return
     }
 
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+    opts, err := listOptions(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    sessions, pagination, err := service.GetStudyActivitySessions(id, page, perPage)
+    sessions, pagination, err := h.svc.GetStudyActivitySessions(id, currentUser(c), opts, page, perPage)
     if err != nil {
-        log.Printf("Error getting study sessions for activity %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "ACTIVITY_SESSIONS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -151,80 +121,159 @@ func GetStudyActivitySessions(c *gin.Context) {
     })
 }
 // CreateStudyActivity handles the POST /api/study_activities endpoint
-func CreateStudyActivity(c *gin.Context) {
+func (h *Handler) CreateStudyActivity(c *gin.Context) {
     var req service.CreateActivityRequest
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid request body",
-            "code":  "INVALID_REQUEST",
-            "details": err.Error(),
-        })
+    if !bindJSON(c, &req) {
         return
     }
 
-    activity, err := service.CreateStudyActivity(&req)
+    activity, err := h.svc.CreateStudyActivity(&req)
     if err != nil {
-        log.Printf("Error creating study activity: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "ACTIVITY_CREATE_ERROR",
-        })
+        c.Error(err)
         return
     }
 
     c.JSON(http.StatusCreated, activity)
 }
-// GetStudySession handles the GET /api/study_sessions/:id endpoint
-func GetStudySession(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+// LaunchStudyActivity handles the POST /api/study_activities/:id/launch endpoint.
+// With ?redirect=true it answers with a redirect to the launch URL instead of the
+// session.
+func (h *Handler) LaunchStudyActivity(c *gin.Context) {
+    id, ok := paramID(c, "id", "activity")
+    if !ok {
+        return
+    }
+
+    var req service.LaunchActivityRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    session, err := h.svc.CreateStudySession(req.GroupID, id, currentUser(c), requestAPIBase(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    if c.Query("redirect") == "true" {
+        c.Redirect(http.StatusSeeOther, session.LaunchURL)
+        return
+    }
+
+    c.JSON(http.StatusCreated, session)
+}
+
+// VerifyLaunchToken handles the POST /api/launch/verify endpoint. A launched
+// activity exchanges the token from its launch URL for the session it belongs to.
+func (h *Handler) VerifyLaunchToken(c *gin.Context) {
+    var req service.LaunchTokenRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    session, err := h.svc.VerifyLaunchToken(req.Token)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid session ID",
-            "code":  "INVALID_SESSION_ID",
-        })
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, session)
+}
+
+// requestAPIBase returns the absolute URL of the API as the client reached it. The
+// headers set by a reverse proxy are only honoured on requests from a trusted one.
+func requestAPIBase(c *gin.Context) string {
+    scheme := "http"
+    if c.Request.TLS != nil {
+        scheme = "https"
+    }
+    host := c.Request.Host
+    if c.GetBool(middleware.ForwardedKey) {
+        if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+            scheme = proto
+        }
+        if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+            host = forwarded
+        }
+    }
+    return scheme + "://" + host + "/api"
+}
+
+// GetStudySession handles the GET /api/study_sessions/:id endpoint
+func (h *Handler) GetStudySession(c *gin.Context) {
+    id, ok := paramID(c, "id", "session")
+    if !ok {
         return
     }
 
-    session, err := service.GetStudySession(id)
+    session, err := h.svc.GetStudySession(id, currentUser(c))
     if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Study session not found",
-                "code":  "SESSION_NOT_FOUND",
-            })
+        c.Error(err)
+        return
+    }
+
+    if c.Query("include") == "statements" {
+        session.Statements, err = h.svc.GetStudySessionStatements(id)
+        if err != nil {
+            c.Error(err)
             return
         }
-        log.Printf("Error getting study session %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "SESSION_FETCH_ERROR",
-        })
+    }
+
+    c.JSON(http.StatusOK, session)
+}
+// CreateStudySession handles the POST /api/study_sessions endpoint
+func (h *Handler) CreateStudySession(c *gin.Context) {
+    var req service.CreateStudySessionRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    session, err := h.svc.CreateStudySession(req.GroupID, req.StudyActivityID, currentUser(c), requestAPIBase(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, session)
+}
+// CompleteStudySession handles the POST /api/study_sessions/:id/complete endpoint
+func (h *Handler) CompleteStudySession(c *gin.Context) {
+    id, ok := paramID(c, "id", "session")
+    if !ok {
+        return
+    }
+
+    session, err := h.svc.CompleteStudySession(id)
+    if err != nil {
+        c.Error(err)
         return
     }
 
     c.JSON(http.StatusOK, session)
 }
 // GetStudySessionWords handles the GET /api/study_sessions/:id/words endpoint
-func GetStudySessionWords(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+func (h *Handler) GetStudySessionWords(c *gin.Context) {
+    id, ok := paramID(c, "id", "session")
+    if !ok {
+        return
+    }
+
+    cursor, perPage, err := cursorParams(c)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid session ID",
-            "code":  "INVALID_SESSION_ID",
-        })
+        c.Error(err)
         return
     }
 
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+    opts, err := listOptions(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    words, pagination, err := service.GetStudySessionWords(id, page, perPage)
+    words, pagination, err := h.svc.GetStudySessionWords(id, currentUser(c), opts, cursor, perPage)
     if err != nil {
-        log.Printf("Error getting words for session %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "SESSION_WORDS_FETCH_ERROR",
-        })
+        c.Error(err)
         return
     }
 
@@ -234,17 +283,22 @@ func GetStudySessionWords(c *gin.Context) {
     })
 }
 // GetStudySessions handles the GET /api/study_sessions endpoint
-func GetStudySessions(c *gin.Context) {
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+func (h *Handler) GetStudySessions(c *gin.Context) {
+    cursor, perPage, err := cursorParams(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    sessions, pagination, err := service.GetStudySessions(page, perPage)
+    opts, err := listOptions(c)
     if err != nil {
-        log.Printf("Error getting study sessions: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "STUDY_SESSIONS_FETCH_ERROR",
-        })
+        c.Error(err)
+        return
+    }
+
+    sessions, pagination, err := h.svc.GetStudySessions(currentUser(c), opts, cursor, perPage)
+    if err != nil {
+        c.Error(err)
         return
     }
 
@@ -254,69 +308,60 @@ func GetStudySessions(c *gin.Context) {
     })
 }
 // CreateWordReview handles the POST /api/study_sessions/:id/words/:word_id/review endpoint
-func CreateWordReview(c *gin.Context) {
-    sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid session ID",
-            "code":  "INVALID_SESSION_ID",
-        })
+func (h *Handler) CreateWordReview(c *gin.Context) {
+    sessionID, ok := paramID(c, "id", "session")
+    if !ok {
         return
     }
 
-    wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid word ID",
-            "code":  "INVALID_WORD_ID",
-        })
+    wordID, ok := paramID(c, "word_id", "word")
+    if !ok {
         return
     }
 
     var req service.CreateWordReviewRequest
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid request body",
-            "code":  "INVALID_REQUEST",
-            "details": err.Error(),
-        })
+    if !bindJSON(c, &req) {
         return
     }
 
-    review, err := service.CreateWordReview(sessionID, wordID, &req)
+    review, err := h.svc.CreateWordReview(sessionID, wordID, &req)
     if err != nil {
-        if err.Error() == "session not found" {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Study session not found",
-                "code":  "SESSION_NOT_FOUND",
-            })
-            return
-        }
-        if err.Error() == "word not found" {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Word not found",
-                "code":  "WORD_NOT_FOUND",
-            })
-            return
-        }
-        log.Printf("Error creating word review: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "WORD_REVIEW_CREATE_ERROR",
-        })
+        c.Error(err)
         return
     }
 
     c.JSON(http.StatusCreated, review)
 }
+// CheckAnswer handles the POST /api/study_sessions/:id/words/:word_id/answer endpoint
+func (h *Handler) CheckAnswer(c *gin.Context) {
+    sessionID, ok := paramID(c, "id", "session")
+    if !ok {
+        return
+    }
+
+    wordID, ok := paramID(c, "word_id", "word")
+    if !ok {
+        return
+    }
+
+    var req service.CheckAnswerRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    result, err := h.svc.CheckAnswer(sessionID, wordID, &req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, result)
+}
+
 // ResetHistory handles the POST /api/reset_history endpoint
-func ResetHistory(c *gin.Context) {
-    if err := service.ResetHistory(); err != nil {
-        log.Printf("Error resetting history: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "RESET_HISTORY_ERROR",
-        })
+func (h *Handler) ResetHistory(c *gin.Context) {
+    if err := h.svc.ResetHistory(); err != nil {
+        c.Error(err)
         return
     }
 
@@ -325,13 +370,9 @@ func ResetHistory(c *gin.Context) {
     })
 }
 // FullReset handles the POST /api/full_reset endpoint
-func FullReset(c *gin.Context) {
-    if err := service.FullReset(); err != nil {
-        log.Printf("Error performing full reset: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "FULL_RESET_ERROR",
-        })
+func (h *Handler) FullReset(c *gin.Context) {
+    if err := h.svc.FullReset(); err != nil {
+        c.Error(err)
         return
     }
 
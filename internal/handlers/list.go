@@ -0,0 +1,128 @@
+package handlers
+
+import (
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// Items on a page of a list
+const (
+    defaultPerPage = 100
+    maxPerPage     = 500
+)
+
+// pageParams reads the page and per_page query parameters of a list read by
+// page number
+func pageParams(c *gin.Context) (page, perPage int, err error) {
+    if page, err = queryInt(c, "page", 1, 0); err != nil {
+        return 0, 0, err
+    }
+    if perPage, err = queryInt(c, "per_page", defaultPerPage, maxPerPage); err != nil {
+        return 0, 0, err
+    }
+    return page, perPage, nil
+}
+
+// cursorParams reads the cursor and per_page query parameters of a list read with
+// cursors. Such lists have no page numbers.
+func cursorParams(c *gin.Context) (cursor string, perPage int, err error) {
+    if c.Query("page") != "" {
+        return "", 0, &service.ValidationError{Entity: "list_query", Field: "page", Message: "is not supported by this list, which pages with cursor"}
+    }
+    if perPage, err = queryInt(c, "per_page", defaultPerPage, maxPerPage); err != nil {
+        return "", 0, err
+    }
+    return c.Query("cursor"), perPage, nil
+}
+
+// queryInt reads a positive integer parameter up to max, or without a limit when
+// max is 0. It returns def when the parameter is absent.
+func queryInt(c *gin.Context, name string, def, max int) (int, error) {
+    value := c.Query(name)
+    if value == "" {
+        return def, nil
+    }
+
+    n, err := strconv.Atoi(value)
+    switch {
+    case err != nil || n < 1:
+        return 0, &service.ValidationError{Entity: "list_query", Field: name, Message: "must be a positive integer"}
+    case max > 0 && n > max:
+        return 0, &service.ValidationError{Entity: "list_query", Field: name, Message: "must be at most " + strconv.Itoa(max)}
+    }
+    return n, nil
+}
+
+// listOptions reads the sorting and filters of a list request from the sort, order,
+// q, from, to, group_id, activity_id and min_accuracy query parameters. Which of
+// them a list accepts is up to the service.
+func listOptions(c *gin.Context) (service.ListOptions, error) {
+    opts := service.ListOptions{
+        Sort:   c.Query("sort"),
+        Order:  strings.ToLower(c.Query("order")),
+        Search: strings.TrimSpace(c.Query("q")),
+    }
+
+    var err error
+    if opts.From, err = queryTime(c, "from", false); err != nil {
+        return opts, err
+    }
+    if opts.To, err = queryTime(c, "to", true); err != nil {
+        return opts, err
+    }
+    if opts.GroupID, err = queryID(c, "group_id"); err != nil {
+        return opts, err
+    }
+    if opts.ActivityID, err = queryID(c, "activity_id"); err != nil {
+        return opts, err
+    }
+    if value := c.Query("min_accuracy"); value != "" {
+        accuracy, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return opts, &service.ValidationError{Entity: "list_query", Field: "min_accuracy", Message: "must be a number"}
+        }
+        opts.MinAccuracy = &accuracy
+    }
+    return opts, nil
+}
+
+// queryTime reads a date (2006-01-02) or RFC 3339 time parameter. Dates are days
+// in UTC; with endOfDay set a date stands for the end of the day, so that a range
+// ending on a date includes it.
+func queryTime(c *gin.Context, name string, endOfDay bool) (*time.Time, error) {
+    value := c.Query(name)
+    if value == "" {
+        return nil, nil
+    }
+
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return &t, nil
+    }
+    t, err := time.Parse("2006-01-02", value)
+    if err != nil {
+        return nil, &service.ValidationError{Entity: "list_query", Field: name, Message: "must be a date (YYYY-MM-DD) or an RFC 3339 time"}
+    }
+    if endOfDay {
+        t = t.AddDate(0, 0, 1)
+    }
+    return &t, nil
+}
+
+// queryID reads a positive ID parameter, returning 0 when it is absent
+func queryID(c *gin.Context, name string) (int64, error) {
+    value := c.Query(name)
+    if value == "" {
+        return 0, nil
+    }
+
+    id, err := strconv.ParseInt(value, 10, 64)
+    if err != nil || id <= 0 {
+        return 0, &service.ValidationError{Entity: "list_query", Field: name, Message: "must be a positive ID"}
+    }
+    return id, nil
+}
+
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/openapi"
+)
+
+// GetOpenAPI handles the GET /api/openapi.json endpoint
+func (h *Handler) GetOpenAPI(c *gin.Context) {
+    c.JSON(http.StatusOK, openapi.Spec())
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+)
+
+// Routes registers the API on r, guarding its endpoints with auth
+func (h *Handler) Routes(r *gin.Engine, auth *middleware.Auth) {
+    sessionAuth := auth.RequireSession("id")
+    adminAuth := auth.RequireAdmin()
+
+    // API routes group
+    api := r.Group("/api", auth.Identify())
+    {
+        // User routes
+        api.POST("/users", h.Register)
+        api.POST("/login", h.Login)
+        api.POST("/logout", h.Logout)
+        api.GET("/me", auth.RequireUser(), h.GetCurrentUser)
+        api.PUT("/users/:id/role", adminAuth, h.SetUserRole)
+
+        // Class and assignment routes
+        classes := api.Group("", auth.RequireUser())
+        {
+            classes.GET("/classes", h.GetClasses)
+            classes.POST("/classes", h.CreateClass)
+            classes.GET("/classes/:id", h.GetClass)
+            classes.POST("/classes/:id/members", h.AddClassMembers)
+            classes.DELETE("/classes/:id/members", h.RemoveClassMembers)
+            classes.GET("/classes/:id/assignments", h.GetClassAssignments)
+            classes.POST("/classes/:id/assignments", h.CreateAssignment)
+            classes.GET("/assignments/:id/report", h.GetAssignmentReport)
+            classes.GET("/me/assignments", h.GetOpenAssignments)
+        }
+
+        // Dashboard routes
+        api.GET("/dashboard/last_study_session", h.GetLastStudySession)
+        api.GET("/dashboard/study_progress", h.GetStudyProgress)
+        api.GET("/dashboard/quick-stats", h.GetQuickStats)
+
+        // Study activities routes
+        api.GET("/study_activities", h.GetStudyActivities)
+        api.GET("/study_activities/:id", h.GetStudyActivity)
+        api.GET("/study_activities/:id/study_sessions", h.GetStudyActivitySessions)
+        api.POST("/study_activities", h.CreateStudyActivity)
+        api.POST("/study_activities/:id/launch", h.LaunchStudyActivity)
+        api.POST("/launch/verify", h.VerifyLaunchToken)
+
+        // Words routes
+        api.GET("/words", h.GetWords)
+        api.GET("/words/:id", h.GetWord)
+        api.POST("/words", adminAuth, h.CreateWord)
+        api.POST("/words/import", adminAuth, h.ImportWords)
+        api.POST("/words/import/anki", adminAuth, h.ImportAnkiWords)
+        api.PUT("/words/:id", adminAuth, h.UpdateWord)
+        api.PATCH("/words/:id", adminAuth, h.PatchWord)
+        api.DELETE("/words/:id", adminAuth, h.DeleteWord)
+
+        // Groups routes
+        api.GET("/groups", h.GetGroups)
+        api.GET("/groups/:id", h.GetGroup)
+        api.GET("/groups/:id/words", h.GetGroupWords)
+        api.GET("/groups/:id/study_sessions", h.GetGroupStudySessions)
+        api.POST("/groups", h.CreateGroup)
+        api.PUT("/groups/:id", h.UpdateGroup)
+        api.DELETE("/groups/:id", h.DeleteGroup)
+        api.POST("/groups/:id/words", h.AddGroupWords)
+        api.DELETE("/groups/:id/words", h.RemoveGroupWords)
+        api.GET("/groups/:id/export/anki", h.ExportGroupAnki)
+
+        // Study sessions routes
+        api.GET("/study_sessions", h.GetStudySessions)
+        api.POST("/study_sessions", h.CreateStudySession)
+        api.GET("/study_sessions/:id", h.GetStudySession)
+        api.GET("/study_sessions/:id/words", h.GetStudySessionWords)
+        api.POST("/study_sessions/:id/complete", sessionAuth, h.CompleteStudySession)
+        api.GET("/study_sessions/:id/next", h.GetStudySessionNextWords)
+        api.POST("/study_sessions/:id/words/:word_id/review", sessionAuth, h.CreateWordReview)
+        api.POST("/study_sessions/:id/words/:word_id/answer", sessionAuth, h.CheckAnswer)
+
+        // Spaced repetition routes
+        api.GET("/review_queue", h.GetReviewQueue)
+
+        // Export and restore routes
+        api.GET("/export", adminAuth, h.ExportArchive)
+        api.POST("/import", adminAuth, h.RestoreArchive)
+
+        // Reset routes
+        api.POST("/reset_history", adminAuth, h.ResetHistory)
+        api.POST("/full_reset", adminAuth, h.FullReset)
+
+        // Error code catalog and API description
+        api.GET("/errors", h.GetErrorCodes)
+        api.GET("/openapi.json", h.GetOpenAPI)
+    }
+
+    // xAPI learning record store routes
+    r.GET("/xapi/about", h.GetXAPIAbout)
+    statements := r.Group("/xapi", middleware.XAPIVersion(), auth.RequireSession(""))
+    {
+        statements.GET("/statements", h.GetXAPIStatements)
+        statements.POST("/statements", h.PostXAPIStatements)
+        statements.PUT("/statements", h.PutXAPIStatement)
+    }
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+)
+
+// maxReviewQueueLimit caps how many due words a single request can ask for
+const maxReviewQueueLimit = 100
+
+// reviewQueueLimit reads the limit query parameter, defaulting to 20. Invalid
+// limits are list query errors, like invalid page sizes.
+func reviewQueueLimit(c *gin.Context) (int, bool) {
+    limit, err := queryInt(c, "limit", 20, maxReviewQueueLimit)
+    if err != nil {
+        c.Error(err)
+        return 0, false
+    }
+    return limit, true
+}
+
+// GetReviewQueue handles the GET /api/review_queue endpoint
+func (h *Handler) GetReviewQueue(c *gin.Context) {
+    groupID, err := strconv.ParseInt(c.Query("group_id"), 10, 64)
+    if err != nil {
+        c.Error(&middleware.Error{Code: "INVALID_GROUP_ID", Message: "Invalid group ID"})
+        return
+    }
+
+    limit, ok := reviewQueueLimit(c)
+    if !ok {
+        return
+    }
+
+    queue, err := h.svc.GetReviewQueue(groupID, currentUser(c), limit)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, queue)
+}
+
+// GetStudySessionNextWords handles the GET /api/study_sessions/:id/next endpoint
+func (h *Handler) GetStudySessionNextWords(c *gin.Context) {
+    id, ok := paramID(c, "id", "session")
+    if !ok {
+        return
+    }
+
+    limit, ok := reviewQueueLimit(c)
+    if !ok {
+        return
+    }
+
+    queue, err := h.svc.GetSessionReviewQueue(id, limit)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, queue)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+    "net/http"
+    "net/url"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// currentUser returns the logged-in user of a request, or 0 for the guest learner
+func currentUser(c *gin.Context) int64 {
+    return c.GetInt64(middleware.UserIDKey)
+}
+
+// Register handles the POST /api/users endpoint
+func (h *Handler) Register(c *gin.Context) {
+    var req service.RegisterRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    user, err := h.svc.CreateUser(&req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, user)
+}
+
+// Login handles the POST /api/login endpoint. The login token is returned in the
+// body for API clients and set as an HttpOnly cookie for browsers.
+func (h *Handler) Login(c *gin.Context) {
+    var req service.LoginRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    login, err := h.svc.Login(&req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    setLoginCookie(c, login.Token, int(time.Until(login.ExpiresAt).Seconds()))
+    c.JSON(http.StatusOK, login)
+}
+
+// Logout handles the POST /api/logout endpoint
+func (h *Handler) Logout(c *gin.Context) {
+    if token := middleware.LoginToken(c); token != "" {
+        if err := h.svc.Logout(token); err != nil {
+            c.Error(err)
+            return
+        }
+    }
+
+    setLoginCookie(c, "", -1)
+    c.Status(http.StatusNoContent)
+}
+
+// GetCurrentUser handles the GET /api/me endpoint
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+    user, err := h.svc.GetUser(currentUser(c))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, user)
+}
+
+// SetUserRole handles the PUT /api/users/:id/role endpoint
+func (h *Handler) SetUserRole(c *gin.Context) {
+    id, ok := paramID(c, "id", "user")
+    if !ok {
+        return
+    }
+
+    var req service.UserRoleRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    user, err := h.svc.SetUserRole(id, req.Role)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, user)
+}
+
+// setLoginCookie sets the login cookie. It is only sent with requests made from the
+// portal itself, so other sites cannot act as the logged-in user.
+func setLoginCookie(c *gin.Context, token string, maxAge int) {
+    http.SetCookie(c.Writer, &http.Cookie{
+        Name:     middleware.LoginCookie,
+        Value:    url.QueryEscape(token),
+        MaxAge:   maxAge,
+        Path:     "/",
+        Secure:   c.Request.TLS != nil,
+        HttpOnly: true,
+        SameSite: http.SameSiteStrictMode,
+    })
+}
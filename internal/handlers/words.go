@@ -1,27 +1,40 @@
 package handlers
 
 import (
-    "database/sql"
-    "log"
+    "fmt"
+    "io"
     "net/http"
     "strconv"
+    "strings"
 
     "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
 )
 
+// Largest uploads the word import endpoints accept
+const (
+    maxWordImportSize = 10 << 20
+    maxAnkiImportSize = 100 << 20
+)
+
 // GetWords handles the GET /api/words endpoint
-func GetWords(c *gin.Context) {
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "100"))
+func (h *Handler) GetWords(c *gin.Context) {
+    page, perPage, err := pageParams(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
 
-    words, pagination, err := service.GetWords(page, perPage)
+    opts, err := listOptions(c)
     if err != nil {
-        log.Printf("Error getting words: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "WORDS_FETCH_ERROR",
-        })
+        c.Error(err)
+        return
+    }
+
+    words, pagination, err := h.svc.GetWords(currentUser(c), opts, page, perPage)
+    if err != nil {
+        c.Error(err)
         return
     }
 
@@ -32,32 +45,225 @@ func GetWords(c *gin.Context) {
 }
 
 // GetWord handles the GET /api/words/:id endpoint
-func GetWord(c *gin.Context) {
-    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+func (h *Handler) GetWord(c *gin.Context) {
+    id, ok := paramID(c, "id", "word")
+    if !ok {
+        return
+    }
+
+    word, err := h.svc.GetWord(id, currentUser(c))
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid word ID",
-            "code":  "INVALID_WORD_ID",
-        })
+        c.Error(err)
         return
     }
 
-    word, err := service.GetWord(id)
+    c.JSON(http.StatusOK, word)
+}
+
+// CreateWord handles the POST /api/words endpoint
+func (h *Handler) CreateWord(c *gin.Context) {
+    var req service.CreateWordRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    word, err := h.svc.CreateWord(&req)
     if err != nil {
-        if err == sql.ErrNoRows {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "Word not found",
-                "code":  "WORD_NOT_FOUND",
-            })
-            return
-        }
-        log.Printf("Error getting word %d: %v", id, err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": err.Error(),
-            "code":  "WORD_FETCH_ERROR",
-        })
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, word)
+}
+
+// UpdateWord handles the PUT /api/words/:id endpoint
+func (h *Handler) UpdateWord(c *gin.Context) {
+    id, ok := paramID(c, "id", "word")
+    if !ok {
+        return
+    }
+
+    var req service.CreateWordRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    word, err := h.svc.UpdateWord(id, currentUser(c), &req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, word)
+}
+
+// PatchWord handles the PATCH /api/words/:id endpoint
+func (h *Handler) PatchWord(c *gin.Context) {
+    id, ok := paramID(c, "id", "word")
+    if !ok {
+        return
+    }
+
+    var req service.UpdateWordRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+
+    word, err := h.svc.PatchWord(id, currentUser(c), &req)
+    if err != nil {
+        c.Error(err)
         return
     }
 
     c.JSON(http.StatusOK, word)
 }
+
+// DeleteWord handles the DELETE /api/words/:id endpoint
+func (h *Handler) DeleteWord(c *gin.Context) {
+    id, ok := paramID(c, "id", "word")
+    if !ok {
+        return
+    }
+
+    force := c.Query("force") == "true"
+
+    result, err := h.svc.DeleteWord(id, force)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// ImportWords handles the POST /api/words/import endpoint
+func (h *Handler) ImportWords(c *gin.Context) {
+    opts := service.ImportOptions{
+        Format:    strings.ToLower(c.Query("format")),
+        GroupName: c.Query("group"),
+    }
+    if columns := c.Query("columns"); columns != "" {
+        for _, column := range strings.Split(columns, ",") {
+            opts.Columns = append(opts.Columns, strings.ToLower(strings.TrimSpace(column)))
+        }
+    }
+
+    body, filename, ok := importSource(c, maxWordImportSize)
+    if !ok {
+        return
+    }
+    defer body.Close()
+    if opts.Format == "" {
+        opts.Format = service.DetectImportFormat(filename, c.ContentType())
+    }
+
+    report, err := h.svc.ImportWords(body, opts)
+    if err != nil {
+        uploadError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
+
+// ImportAnkiWords handles the POST /api/words/import/anki endpoint
+func (h *Handler) ImportAnkiWords(c *gin.Context) {
+    reviews, _ := strconv.ParseBool(c.DefaultQuery("reviews", "false"))
+    opts := service.AnkiImportOptions{
+        ArabicField:  c.Query("arabic_field"),
+        RomanField:   c.Query("roman_field"),
+        EnglishField: c.Query("english_field"),
+        GroupName:    c.Query("group"),
+        Reviews:      reviews,
+        UserID:       currentUser(c),
+    }
+
+    body, _, ok := importSource(c, maxAnkiImportSize)
+    if !ok {
+        return
+    }
+    defer body.Close()
+
+    report, err := h.svc.ImportAnkiPackage(body, opts)
+    if err != nil {
+        uploadError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
+
+// importSource returns the "file" field of a multipart upload, or the raw request
+// body otherwise, along with the uploaded file name. Request bodies over limit
+// bytes are cut off. It records an error and returns false if the upload cannot
+// be read.
+func importSource(c *gin.Context, limit int64) (io.ReadCloser, string, bool) {
+    if c.Request.ContentLength > limit {
+        c.Error(uploadTooLarge(limit))
+        return nil, "", false
+    }
+    c.Request.Body = &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit), limit: limit}
+    if c.ContentType() != "multipart/form-data" {
+        return c.Request.Body, "", true
+    }
+
+    header, err := c.FormFile("file")
+    if err != nil {
+        if body, ok := c.Request.Body.(*limitedBody); ok && body.exceeded {
+            c.Error(uploadTooLarge(limit))
+            return nil, "", false
+        }
+        c.Error(&middleware.Error{
+            Code:    "INVALID_REQUEST",
+            Message: "Missing \"file\" field in multipart upload",
+            Details: err.Error(),
+        })
+        return nil, "", false
+    }
+    file, err := header.Open()
+    if err != nil {
+        c.Error(&middleware.Error{
+            Code:    "INVALID_REQUEST",
+            Message: "Could not read uploaded file",
+            Details: err.Error(),
+        })
+        return nil, "", false
+    }
+    return file, header.Filename, true
+}
+
+// limitedBody is a request body cut off by http.MaxBytesReader, which remembers
+// whether it was cut off
+type limitedBody struct {
+    io.ReadCloser
+    limit    int64
+    read     int64
+    exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+    n, err := b.ReadCloser.Read(p)
+    b.read += int64(n)
+    if err != nil && err != io.EOF && b.read >= b.limit {
+        b.exceeded = true
+    }
+    return n, err
+}
+
+// uploadError records err, the failure to import an upload, or an
+// UPLOAD_TOO_LARGE error if reading it failed because it was cut off
+func uploadError(c *gin.Context, err error) {
+    if body, ok := c.Request.Body.(*limitedBody); ok && body.exceeded {
+        err = uploadTooLarge(body.limit)
+    }
+    c.Error(err)
+}
+
+// uploadTooLarge returns the error for an upload over limit bytes
+func uploadTooLarge(limit int64) error {
+    return &middleware.Error{
+        Code:    "UPLOAD_TOO_LARGE",
+        Message: fmt.Sprintf("Upload is larger than the %d MB limit", limit>>20),
+        Details: gin.H{"max_bytes": limit},
+    }
+}
@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// fakeWords stores a single word, with ID 1
+type fakeWords struct {
+    service.WordRepository
+}
+
+func (fakeWords) Get(id, userID int64) (*service.WordDetailResponse, error) {
+    if id != 1 {
+        return nil, &service.NotFoundError{Entity: "word"}
+    }
+    return &service.WordDetailResponse{ID: 1, Arabic: "كتاب", Roman: "kitab", English: "book"}, nil
+}
+
+// TestGetWordFromRepository checks that words are read through the service's
+// repository and that its errors reach the client
+func TestGetWordFromRepository(t *testing.T) {
+    r := newRouter(service.NewWithRepositories(service.Repositories{Words: fakeWords{}}, nil, service.Options{}))
+
+    word := send(t, r, "GET", "/api/words/1", "", "", http.StatusOK)
+    if word["english"] != "book" {
+        t.Errorf("got word %v, want the book", word)
+    }
+
+    missing := send(t, r, "GET", "/api/words/2", "", "", http.StatusNotFound)
+    if missing["code"] != "WORD_NOT_FOUND" {
+        t.Errorf("got code %v, want WORD_NOT_FOUND", missing["code"])
+    }
+}
+
+// TestImportWordsTooLarge checks that word files over the size limit are refused,
+// whether or not the client sends their length
+func TestImportWordsTooLarge(t *testing.T) {
+    r := newTestServer(t)
+    row := "كتاب,kitab,book\n"
+    body := strings.Repeat(row, (10<<20)/len(row)+1)
+
+    for _, chunked := range []bool{false, true} {
+        req := httptest.NewRequest("POST", "/api/words/import?format=csv", strings.NewReader(body))
+        req.Header.Set("Content-Type", "text/csv")
+        req.Header.Set("Authorization", "Bearer "+testAdminToken)
+        if chunked {
+            req.ContentLength = -1
+        }
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        if w.Code != http.StatusRequestEntityTooLarge {
+            t.Errorf("chunked %v: got status %d, want 413: %.200s", chunked, w.Code, w.Body.String())
+        }
+    }
+}
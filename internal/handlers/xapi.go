@@ -0,0 +1,157 @@
+package handlers
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/xapi"
+)
+
+// GetXAPIAbout handles the GET /xapi/about endpoint
+func (h *Handler) GetXAPIAbout(c *gin.Context) {
+    c.Header("X-Experience-API-Version", xapi.Version)
+    c.JSON(http.StatusOK, gin.H{
+        "version": []string{xapi.Version},
+    })
+}
+
+// PostXAPIStatements handles the POST /xapi/statements endpoint. The body is a
+// single statement or an array of statements. Clients with a session token can
+// only record reviews in their own session.
+func (h *Handler) PostXAPIStatements(c *gin.Context) {
+    body, err := io.ReadAll(c.Request.Body)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    var statements []json.RawMessage
+    trimmed := strings.TrimSpace(string(body))
+    if strings.HasPrefix(trimmed, "[") {
+        if err := json.Unmarshal(body, &statements); err != nil {
+            c.Error(&service.ValidationError{Entity: "statement", Field: "statements", Message: err.Error()})
+            return
+        }
+    } else {
+        statements = []json.RawMessage{json.RawMessage(body)}
+    }
+
+    ids, err := h.svc.StoreXAPIStatements(statements, c.GetInt64(middleware.SessionIDKey))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.JSON(http.StatusOK, ids)
+}
+
+// PutXAPIStatement handles the PUT /xapi/statements?statementId= endpoint
+func (h *Handler) PutXAPIStatement(c *gin.Context) {
+    body, err := io.ReadAll(c.Request.Body)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    if err := h.svc.PutXAPIStatement(c.Query("statementId"), body, c.GetInt64(middleware.SessionIDKey)); err != nil {
+        c.Error(err)
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// GetXAPIStatements handles the GET /xapi/statements endpoint. A statementId or
+// voidedStatementId returns that statement; otherwise the filters agent, verb,
+// activity, registration, since, until, limit and ascending select a page of
+// statements, with a "more" link to the next page. Clients with a session token
+// only read the statements of their own session.
+func (h *Handler) GetXAPIStatements(c *gin.Context) {
+    c.Header("X-Experience-API-Consistent-Through", time.Now().UTC().Format(time.RFC3339Nano))
+
+    statementID := c.Query("statementId")
+    voidedID := c.Query("voidedStatementId")
+    if statementID != "" || voidedID != "" {
+        if statementID != "" && voidedID != "" {
+            c.Error(&service.ValidationError{
+                Entity:  "statement",
+                Field:   "statementId",
+                Message: "cannot be combined with voidedStatementId",
+            })
+            return
+        }
+
+        id := statementID
+        if voidedID != "" {
+            id = voidedID
+        }
+        statement, err := h.svc.GetXAPIStatement(id, voidedID != "", c.GetInt64(middleware.SessionIDKey))
+        if err != nil {
+            c.Error(err)
+            return
+        }
+        c.Data(http.StatusOK, "application/json; charset=utf-8", statement)
+        return
+    }
+
+    query := service.XAPIStatementQuery{
+        Agent:        c.Query("agent"),
+        Verb:         c.Query("verb"),
+        Activity:     c.Query("activity"),
+        Registration: c.Query("registration"),
+        Ascending:    c.Query("ascending") == "true",
+        SessionID:    c.GetInt64(middleware.SessionIDKey),
+    }
+
+    var err error
+    if query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultXAPIStatementLimit))); err != nil || query.Limit < 0 {
+        c.Error(&service.ValidationError{Entity: "statement", Field: "limit", Message: "must be a non-negative integer"})
+        return
+    }
+    if query.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil || query.Offset < 0 {
+        c.Error(&service.ValidationError{Entity: "statement", Field: "offset", Message: "must be a non-negative integer"})
+        return
+    }
+    for _, param := range []string{"since", "until"} {
+        value := c.Query(param)
+        if value == "" {
+            continue
+        }
+        t, err := time.Parse(time.RFC3339Nano, value)
+        if err != nil {
+            c.Error(&service.ValidationError{Entity: "statement", Field: param, Message: "must be an ISO 8601 timestamp"})
+            return
+        }
+        if param == "since" {
+            query.Since = &t
+        } else {
+            query.Until = &t
+        }
+    }
+
+    statements, more, err := h.svc.QueryXAPIStatements(query)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    next := ""
+    if more {
+        params := c.Request.URL.Query()
+        params.Set("offset", strconv.Itoa(query.Offset+len(statements)))
+        next = c.Request.URL.Path + "?" + params.Encode()
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "statements": statements,
+        "more":       next,
+    })
+}
+
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/handlers"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+// testAdminToken is the admin token of the test servers
+const testAdminToken = "test-admin-token"
+
+// newTestServer serves the API on a temporary database
+func newTestServer(t *testing.T) *gin.Engine {
+    t.Helper()
+    db, err := service.InitDB(filepath.Join(t.TempDir(), "words.db"), true)
+    if err != nil {
+        t.Fatalf("initializing database: %v", err)
+    }
+    t.Cleanup(func() { db.Close() })
+    return newRouter(service.New(db, service.Options{}))
+}
+
+// newRouter serves the API of svc
+func newRouter(svc *service.Service) *gin.Engine {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.Use(middleware.RequestID(), middleware.ErrorHandler(), middleware.Recovery())
+    handlers.New(svc).Routes(r, &middleware.Auth{
+        AdminToken:         testAdminToken,
+        VerifySessionToken: svc.VerifySessionToken,
+        Authenticate:       svc.AuthenticateUser,
+    })
+    return r
+}
+
+// send serves a request and fails the test unless it is answered with status. It
+// returns the decoded response body, if any.
+func send(t *testing.T, r *gin.Engine, method, path, token, body string, status int) map[string]interface{} {
+    t.Helper()
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-Experience-API-Version", "1.0.3")
+    if token != "" {
+        req.Header.Set("Authorization", "Bearer "+token)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != status {
+        t.Fatalf("%s %s: got status %d, want %d: %s", method, path, w.Code, status, w.Body.String())
+    }
+
+    var decoded map[string]interface{}
+    if w.Body.Len() > 0 {
+        if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+            t.Fatalf("%s %s: decoding response: %v", method, path, err)
+        }
+    }
+    return decoded
+}
+
+// TestXAPIStatementInSessionScope checks that a statement sent with a session token
+// can be read back with the same token even when it records no review
+func TestXAPIStatementInSessionScope(t *testing.T) {
+    r := newTestServer(t)
+
+    group := send(t, r, "POST", "/api/groups", "", `{"name": "Greetings"}`, http.StatusCreated)
+    activity := send(t, r, "POST", "/api/study_activities", "",
+        `{"name": "Quiz", "thumbnail_url": "/quiz.png", "description": "Quiz",
+            "launch_url": "http://localhost/quiz?session={session_id}&token={launch_token}"}`,
+        http.StatusCreated)
+    session := send(t, r, "POST", "/api/study_sessions", "",
+        `{"group_id": `+jsonNumber(group["id"])+`, "study_activity_id": `+jsonNumber(activity["id"])+`}`,
+        http.StatusCreated)
+    token, _ := session["session_token"].(string)
+    if token == "" {
+        t.Fatal("the new session has no session token")
+    }
+
+    const id = "5d3c1a7e-2b4f-4e6a-8c9d-0f1e2a3b4c5d"
+    path := "/xapi/statements?statementId=" + url.QueryEscape(id)
+    send(t, r, "PUT", path, token, `{
+        "actor": {"mbox": "mailto:learner@example.com"},
+        "verb": {"id": "http://adlnet.gov/expapi/verbs/experienced"},
+        "object": {"id": "http://localhost/quiz/intro"}
+    }`, http.StatusNoContent)
+
+    statement := send(t, r, "GET", path, token, "", http.StatusOK)
+    if statement["id"] != id {
+        t.Errorf("got statement %v, want %s", statement["id"], id)
+    }
+}
+
+// jsonNumber formats a number decoded from JSON for use in a request body
+func jsonNumber(value interface{}) string {
+    encoded, _ := json.Marshal(value)
+    return string(encoded)
+}
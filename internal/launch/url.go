@@ -0,0 +1,88 @@
+// Package launch resolves study activity launch URLs and signs the short-lived
+// tokens a launched activity uses to prove which study session it belongs to.
+package launch
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Placeholders understood in launch URL templates
+const (
+	PlaceholderSessionID  = "{session_id}"
+	PlaceholderGroupID    = "{group_id}"
+	PlaceholderActivityID = "{activity_id}"
+	PlaceholderAPIBase    = "{api_base}"
+	PlaceholderToken      = "{launch_token}"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// Params are the values substituted into a launch URL template
+type Params struct {
+	SessionID  int64
+	GroupID    int64
+	ActivityID int64
+	// APIBase is the absolute URL of the API, e.g. "http://localhost:8080/api"
+	APIBase string
+	// Token is the signed launch token, only needed when the template uses it
+	Token string
+}
+
+// Validate checks that a launch URL template parses and only uses known
+// placeholders
+func Validate(template string) error {
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		switch placeholder {
+		case PlaceholderSessionID, PlaceholderGroupID, PlaceholderActivityID,
+			PlaceholderAPIBase, PlaceholderToken:
+		default:
+			return fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+	}
+
+	// Placeholders are not valid URL syntax everywhere, so check a resolved sample
+	sample, err := Resolve(template, Params{SessionID: 1, GroupID: 1, ActivityID: 1, APIBase: "http://localhost/api", Token: "token"})
+	if err != nil {
+		return err
+	}
+	if _, err := url.Parse(sample); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UsesToken reports whether a template asks for a launch token
+func UsesToken(template string) bool {
+	return strings.Contains(template, PlaceholderToken)
+}
+
+// Resolve fills in the placeholders of a launch URL template. Values are escaped
+// for use in a query string, except {api_base}, which is inserted as is so it can
+// start the URL. A template without placeholders gets session_id and group_id
+// query parameters appended, as launch URLs always have.
+func Resolve(template string, p Params) (string, error) {
+	if !placeholderPattern.MatchString(template) {
+		u, err := url.Parse(template)
+		if err != nil {
+			return "", err
+		}
+		query := u.Query()
+		query.Set("session_id", strconv.FormatInt(p.SessionID, 10))
+		query.Set("group_id", strconv.FormatInt(p.GroupID, 10))
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	replacer := strings.NewReplacer(
+		PlaceholderSessionID, strconv.FormatInt(p.SessionID, 10),
+		PlaceholderGroupID, strconv.FormatInt(p.GroupID, 10),
+		PlaceholderActivityID, strconv.FormatInt(p.ActivityID, 10),
+		PlaceholderAPIBase, strings.TrimSuffix(p.APIBase, "/"),
+		PlaceholderToken, url.QueryEscape(p.Token),
+	)
+	return replacer.Replace(template), nil
+}
@@ -0,0 +1,76 @@
+// Package logging writes leveled messages to the standard logger, dropping those
+// below the configured level.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level is the severity of a log message
+type Level int
+
+// Levels in increasing severity
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = map[string]Level{"debug": Debug, "info": Info, "warn": Warn, "error": Error}
+
+// minLevel is the lowest level written
+var minLevel = Info
+
+// ParseLevel returns the level named debug, info, warn or error
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// SetLevel drops messages below the named level
+func SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	minLevel = level
+	return nil
+}
+
+// Enabled reports whether messages of a level are written
+func Enabled(level Level) bool {
+	return level >= minLevel
+}
+
+// Debugf writes a debug message
+func Debugf(format string, args ...interface{}) {
+	output(Debug, format, args...)
+}
+
+// Infof writes an informational message
+func Infof(format string, args ...interface{}) {
+	output(Info, format, args...)
+}
+
+// Warnf writes a warning
+func Warnf(format string, args ...interface{}) {
+	output(Warn, format, args...)
+}
+
+// Errorf writes an error message
+func Errorf(format string, args ...interface{}) {
+	output(Error, format, args...)
+}
+
+// output writes a message of a level to the standard logger if the level is enabled
+func output(level Level, format string, args ...interface{}) {
+	if Enabled(level) {
+		log.Output(3, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by Auth
+const (
+	// SessionIDKey holds the study session a request's session token was issued
+	// for. It is not set for requests made with the admin token.
+	SessionIDKey = "session_id"
+	// UserIDKey holds the logged-in user of a request. It is not set for guests.
+	UserIDKey = "user_id"
+)
+
+// LoginCookie is the cookie holding the login token of browser clients
+const LoginCookie = "lang_portal_session"
+
+// Auth checks the credentials sent in the Authorization header, either as a bearer
+// token or as the password of basic authentication, which xAPI clients use
+type Auth struct {
+	// AdminToken grants access to everything. Admin routes are refused when empty.
+	AdminToken string
+	// VerifySessionToken returns the study session a session token was issued for
+	VerifySessionToken func(token string) (int64, error)
+	// Authenticate returns the user logged in with a token, or 0 if there is none
+	Authenticate func(token string) (int64, error)
+}
+
+// Identify looks up the user logged in with the login cookie or the token in the
+// Authorization header and stores them under UserIDKey. Requests without a login
+// continue as the guest learner.
+func (a *Auth) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := LoginToken(c)
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := a.Authenticate(token)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if userID != 0 {
+			c.Set(UserIDKey, userID)
+		}
+		c.Next()
+	}
+}
+
+// LoginToken returns the login token of a request, taken from the login cookie or
+// else the Authorization header
+func LoginToken(c *gin.Context) string {
+	if token, err := c.Cookie(LoginCookie); err == nil && token != "" {
+		return token
+	}
+	return credential(c)
+}
+
+// RequireUser only lets through requests from a logged-in user. It must run
+// after Identify.
+func (a *Auth) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt64(UserIDKey) == 0 {
+			c.Error(&Error{Code: "LOGIN_REQUIRED", Message: "Login is required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin only lets requests with the admin token through
+func (a *Auth) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.AdminToken == "" {
+			c.Error(&Error{Code: "ADMIN_DISABLED", Message: "Admin endpoints are disabled until an admin token is configured"})
+			c.Abort()
+			return
+		}
+
+		token := credential(c)
+		if token == "" {
+			c.Error(&Error{Code: "ADMIN_TOKEN_REQUIRED", Message: "Admin token is required"})
+			c.Abort()
+			return
+		}
+		if !a.isAdmin(token) {
+			c.Error(&Error{Code: "INVALID_ADMIN_TOKEN", Message: "Invalid admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession lets through requests with the admin token or a session token.
+// When param names a route parameter, the session token must have been issued for
+// the study session it holds. The token's session is stored under SessionIDKey.
+func (a *Auth) RequireSession(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := credential(c)
+		if token == "" {
+			c.Error(&Error{Code: "SESSION_TOKEN_REQUIRED", Message: "Session token is required"})
+			c.Abort()
+			return
+		}
+		if a.isAdmin(token) {
+			c.Next()
+			return
+		}
+
+		sessionID, err := a.VerifySessionToken(token)
+		if err != nil {
+			c.Error(&Error{Code: "INVALID_SESSION_TOKEN", Message: "Invalid session token", Details: err.Error()})
+			c.Abort()
+			return
+		}
+		if param != "" && c.Param(param) != strconv.FormatInt(sessionID, 10) {
+			c.Error(&Error{Code: "SESSION_TOKEN_FORBIDDEN", Message: "Session token was issued for another study session"})
+			c.Abort()
+			return
+		}
+
+		c.Set(SessionIDKey, sessionID)
+		c.Next()
+	}
+}
+
+func (a *Auth) isAdmin(token string) bool {
+	return a.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.AdminToken)) == 1
+}
+
+// credential returns the token of a bearer or basic Authorization header
+func credential(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	scheme, value, ok := strings.Cut(header, " ")
+	if !ok {
+		return ""
+	}
+
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		return strings.TrimSpace(value)
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return ""
+		}
+		_, password, _ := strings.Cut(string(decoded), ":")
+		return password
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import "net/http"
+
+// ErrorCode is a code clients may find in error responses, with its status and
+// the message it is answered with
+type ErrorCode struct {
+	Code    string `json:"code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// ErrorCodes is the catalog of every error code, sorted by code. Conflicts and
+// errors about the request itself answer with a message of their own.
+var ErrorCodes = []ErrorCode{
+	{"ACTIVITY_NOT_FOUND", http.StatusNotFound, "Activity not found"},
+	{"ADMIN_DISABLED", http.StatusForbidden, "Admin endpoints are disabled until an admin token is configured"},
+	{"ADMIN_TOKEN_REQUIRED", http.StatusUnauthorized, "Admin token is required"},
+	{"ASSIGNMENT_NOT_FOUND", http.StatusNotFound, "Assignment not found"},
+	{"CLASS_NOT_FOUND", http.StatusNotFound, "Class not found"},
+	{"GROUP_ALREADY_EXISTS", http.StatusConflict, "Group already exists"},
+	{"GROUP_HAS_ASSIGNMENTS", http.StatusConflict, "Group is assigned to a class and cannot be deleted"},
+	{"GROUP_HAS_SESSIONS", http.StatusConflict, "Group has study sessions and cannot be deleted"},
+	{"GROUP_NOT_FOUND", http.StatusNotFound, "Group not found"},
+	{"INTERNAL_ERROR", http.StatusInternalServerError, "Internal server error"},
+	{"INVALID_ACTIVITY", http.StatusBadRequest, "Invalid study activity"},
+	{"INVALID_ACTIVITY_ID", http.StatusBadRequest, "Invalid activity ID"},
+	{"INVALID_ADMIN_TOKEN", http.StatusForbidden, "Invalid admin token"},
+	{"INVALID_ARCHIVE", http.StatusBadRequest, "Invalid archive"},
+	{"INVALID_ASSIGNMENT_ID", http.StatusBadRequest, "Invalid assignment ID"},
+	{"INVALID_CLASS_ID", http.StatusBadRequest, "Invalid class ID"},
+	{"INVALID_CREDENTIALS", http.StatusUnauthorized, "Invalid username or password"},
+	{"INVALID_EXPORT", http.StatusBadRequest, "Invalid export"},
+	{"INVALID_GROUP", http.StatusBadRequest, "Invalid group"},
+	{"INVALID_GROUP_ID", http.StatusBadRequest, "Invalid group ID"},
+	{"INVALID_IMPORT", http.StatusBadRequest, "Invalid import"},
+	{"INVALID_LAUNCH_TOKEN", http.StatusUnauthorized, "Invalid launch token"},
+	{"INVALID_LIST_QUERY", http.StatusBadRequest, "Invalid list query"},
+	{"INVALID_REQUEST", http.StatusBadRequest, "Invalid request"},
+	{"INVALID_REVIEW", http.StatusBadRequest, "Invalid review"},
+	{"INVALID_SESSION_ID", http.StatusBadRequest, "Invalid session ID"},
+	{"INVALID_SESSION_TOKEN", http.StatusUnauthorized, "Invalid session token"},
+	{"INVALID_STATEMENT", http.StatusBadRequest, "Invalid statement"},
+	{"INVALID_USER", http.StatusBadRequest, "Invalid user"},
+	{"INVALID_USER_ID", http.StatusBadRequest, "Invalid user ID"},
+	{"INVALID_WORD", http.StatusBadRequest, "Invalid word"},
+	{"INVALID_WORD_ID", http.StatusBadRequest, "Invalid word ID"},
+	{"LAUNCH_TOKEN_EXPIRED", http.StatusUnauthorized, "Launch token has expired"},
+	{"LOGIN_REQUIRED", http.StatusUnauthorized, "Login is required"},
+	{"NO_STUDY_SESSIONS", http.StatusNotFound, "No study sessions found"},
+	{"SESSION_NOT_ACTIVE", http.StatusConflict, "Study session is no longer active"},
+	{"SESSION_NOT_FOUND", http.StatusNotFound, "Study session not found"},
+	{"SESSION_TOKEN_FORBIDDEN", http.StatusForbidden, "Session token was issued for another study session"},
+	{"SESSION_TOKEN_REQUIRED", http.StatusUnauthorized, "Session token is required"},
+	{"STATEMENT_CONFLICT", http.StatusConflict, "Statement already exists with different content"},
+	{"STATEMENT_NOT_FOUND", http.StatusNotFound, "Statement not found"},
+	{"TEACHER_REQUIRED", http.StatusForbidden, "Only teachers can manage classes and assignments"},
+	{"UPLOAD_TOO_LARGE", http.StatusRequestEntityTooLarge, "Upload is too large"},
+	{"USERNAME_TAKEN", http.StatusConflict, "Username is already taken"},
+	{"USER_NOT_FOUND", http.StatusNotFound, "User not found"},
+	{"WORD_ALREADY_EXISTS", http.StatusConflict, "Word already exists"},
+	{"WORD_HAS_REVIEWS", http.StatusConflict, "Word has review history; pass force=true to delete it together with its reviews"},
+	{"WORD_NOT_FOUND", http.StatusNotFound, "Word not found"},
+	{"XAPI_VERSION_REQUIRED", http.StatusBadRequest, "X-Experience-API-Version header is required"},
+	{"XAPI_VERSION_UNSUPPORTED", http.StatusBadRequest, "Unsupported xAPI version, expected 1.0.x"},
+}
+
+// errorCodes indexes ErrorCodes by code
+var errorCodes = make(map[string]ErrorCode)
+
+func init() {
+	for _, code := range ErrorCodes {
+		errorCodes[code.Code] = code
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers CORS allows browsers to send and to read
+const (
+	corsAllowHeaders  = "Authorization, Content-Type, X-Experience-API-Version, X-Request-ID"
+	corsExposeHeaders = "X-Experience-API-Version, X-Request-ID"
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+)
+
+// CORS lets browsers on the given origins call the API. The origin "*" allows any
+// origin, without cookies; listed origins may send the login cookie. Preflight
+// requests from allowed origins are answered here.
+func CORS(origins []string) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	anyOrigin := false
+	for _, origin := range origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		switch {
+		case allowed[origin]:
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		case anyOrigin:
+			header.Set("Access-Control-Allow-Origin", "*")
+		default:
+			c.Next()
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
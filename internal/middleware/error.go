@@ -1,27 +1,135 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/launch"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
 )
 
-// ErrorResponse represents a standard error response
+// Error is an error about the HTTP request itself, such as a malformed body or a
+// missing credential, rather than one returned by the service. Its status is the
+// one of its code in ErrorCodes.
+type Error struct {
+	Code    string
+	Message string
+	Details interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorResponse is the body of every error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id"`
+}
+
+// codedError is implemented by errors that name their own code and the status to
+// answer it with when it is missing from ErrorCodes, such as the service's errors.
+// They may also implement Details, to add details to the response, and
+// ClientMessage, to be answered with their own message rather than their code's.
+type codedError interface {
+	error
+	Code() string
+	Status() int
 }
 
-// ErrorHandler middleware for consistent error handling
+// errorSentinels maps the launch token errors to their code
+var errorSentinels = []struct {
+	err  error
+	code string
+}{
+	{launch.ErrExpiredToken, "LAUNCH_TOKEN_EXPIRED"},
+	{launch.ErrInvalidToken, "INVALID_LAUNCH_TOKEN"},
+}
+
+// ErrorHandler answers requests whose handler recorded an error with c.Error and
+// wrote no response. The last error decides the response: coded errors and Error
+// map to their code in ErrorCodes, anything else is logged and answered with
+// INTERNAL_ERROR without revealing it.
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: err.Error(),
-			})
+		if len(c.Errors) == 0 || c.Writer.Written() {
 			return
 		}
+		err := c.Errors.Last().Err
+
+		status, response := errorResponse(err)
+		if status == http.StatusInternalServerError {
+			logging.Errorf("Error handling %s %s (request %s): %v", c.Request.Method, c.Request.URL.Path, c.GetString(RequestIDKey), err)
+		}
+		response.RequestID = c.GetString(RequestIDKey)
+		c.AbortWithStatusJSON(status, response)
+	}
+}
+
+// errorResponse returns the status and body of the response to err
+func errorResponse(err error) (int, ErrorResponse) {
+	var requestErr *Error
+	var coded codedError
+
+	// fallback is the status of a code missing from ErrorCodes, and ownMessage
+	// keeps the message of the error rather than the code's
+	var response ErrorResponse
+	var fallback int
+	var ownMessage bool
+	switch {
+	case errors.As(err, &requestErr):
+		response = ErrorResponse{Code: requestErr.Code, Message: requestErr.Message, Details: requestErr.Details}
+		fallback = http.StatusBadRequest
+		ownMessage = true
+	case errors.As(err, &coded):
+		response = ErrorResponse{Code: coded.Code(), Message: coded.Error()}
+		fallback = coded.Status()
+		if detailed, ok := coded.(interface{ Details() interface{} }); ok {
+			response.Details = detailed.Details()
+		}
+		if messenger, ok := coded.(interface{ ClientMessage() string }); ok {
+			response.Message = messenger.ClientMessage()
+			ownMessage = true
+		}
+	default:
+		for _, sentinel := range errorSentinels {
+			if errors.Is(err, sentinel.err) {
+				response = ErrorResponse{Code: sentinel.code, Message: err.Error()}
+				fallback = http.StatusBadRequest
+				break
+			}
+		}
+	}
+	if response.Code == "" {
+		response = ErrorResponse{Code: "INTERNAL_ERROR"}
+		fallback = http.StatusInternalServerError
+	}
+
+	code, ok := errorCodes[response.Code]
+	if !ok {
+		logging.Warnf("error code %s is missing from the catalog", response.Code)
+		if response.Message == "" {
+			response.Message = http.StatusText(fallback)
+		}
+		return fallback, response
 	}
+	if !ownMessage {
+		response.Message = code.Message
+	}
+	return code.Status, response
+}
+
+// Recovery turns panics into INTERNAL_ERROR responses written by ErrorHandler,
+// which must run before it
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		c.Error(fmt.Errorf("panic: %v", recovered))
+		c.Abort()
+	})
 }
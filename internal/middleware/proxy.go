@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardedKey is set for requests that came through a trusted proxy, whose
+// X-Forwarded-Proto and X-Forwarded-Host headers describe the request the client
+// made. Those headers are not believed on other requests, as any client can send
+// them.
+const ForwardedKey = "forwarded"
+
+// TrustProxies sets ForwardedKey on requests from the given proxy addresses or
+// CIDRs. No requests are marked when proxies is empty.
+func TrustProxies(proxies []string) (gin.HandlerFunc, error) {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *gin.Context) {
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					c.Set(ForwardedKey, true)
+					break
+				}
+			}
+		}
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the context key holding the ID of a request
+const RequestIDKey = "request_id"
+
+// RequestIDHeader carries the ID of a request in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs accepted from clients and proxies
+const maxRequestIDLength = 64
+
+// RequestID gives every request an ID, stored under RequestIDKey and returned in
+// the X-Request-ID header, so that an error response can be matched with the
+// server log. An ID set by the client or a proxy in X-Request-ID is kept when it
+// is a plausible one.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to maxRequestIDLength letters, digits, dashes,
+// underscores and dots
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	// crypto/rand does not fail on supported platforms
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
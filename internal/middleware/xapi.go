@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/xapi"
+)
+
+// XAPIVersion requires the X-Experience-API-Version header on xAPI requests and
+// reports the version the LRS speaks on every response
+func XAPIVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Experience-API-Version", xapi.Version)
+
+		version := c.GetHeader("X-Experience-API-Version")
+		if version == "" {
+			c.Error(&Error{Code: "XAPI_VERSION_REQUIRED", Message: "X-Experience-API-Version header is required"})
+			c.Abort()
+			return
+		}
+		if !strings.HasPrefix(version, "1.0") {
+			c.Error(&Error{Code: "XAPI_VERSION_UNSUPPORTED", Message: "Unsupported xAPI version " + version + ", expected 1.0.x"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
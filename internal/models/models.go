@@ -46,3 +46,11 @@ type Pagination struct {
 	TotalItems   int `json:"total_items"`
 	ItemsPerPage int `json:"items_per_page"`
 }
+
+// CursorPagination links a page of a list read with cursors to the pages around it.
+// The cursors are null at the ends of the list.
+type CursorPagination struct {
+	NextCursor   *string `json:"next_cursor"`
+	PrevCursor   *string `json:"prev_cursor"`
+	ItemsPerPage int     `json:"items_per_page"`
+}
@@ -0,0 +1,292 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// step is a request of a contract run and the status it must be answered with
+type step struct {
+	method string
+	// path may hold {{name}} placeholders for values kept by earlier steps, as
+	// may body and the headers
+	path string
+	// token names the kept value sent as a bearer token; "admin" is the admin token
+	token  string
+	header map[string]string
+	body   string
+	// contentType of body, application/json by default
+	contentType string
+	// bodyOf names a response body kept by an earlier step to send instead of body
+	bodyOf string
+	status int
+	// keep names values of the JSON response by their dotted path, as in user.id
+	keep map[string]string
+	// keepBody names the response body, for bodyOf
+	keepBody string
+}
+
+// Report is the outcome of a contract run
+type Report struct {
+	Requests int
+	Failures []string
+}
+
+// OK reports whether the run found no failures
+func (r *Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Report) fail(format string, args ...interface{}) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+// Run checks the API served by handler against the document. It sends a scenario
+// exercising every endpoint, which expects an empty database and the admin token
+// adminToken, and checks each response against the operation serving it. Routes
+// missing from the document, operations without a route and operations that no
+// request got a successful response from are reported too.
+func Run(handler http.Handler, routes gin.RoutesInfo, adminToken string) *Report {
+	doc := Spec()
+	report := &Report{}
+	checkRoutes(doc, routes, report)
+
+	run := &contractRun{
+		doc:       doc,
+		handler:   handler,
+		report:    report,
+		values:    map[string]string{"admin": adminToken},
+		bodies:    map[string][]byte{},
+		exercised: map[*Operation]bool{},
+	}
+	for _, s := range scenario() {
+		run.send(s)
+	}
+
+	for _, op := range doc.Operations() {
+		if !run.exercised[op] {
+			report.fail("%s %s: no request got a successful response", op.Method, op.Route)
+		}
+	}
+	return report
+}
+
+// checkRoutes compares the routes served with the operations of the document
+func checkRoutes(doc *Document, routes gin.RoutesInfo, report *Report) {
+	served := map[string]bool{}
+	for _, route := range routes {
+		served[route.Method+" "+route.Path] = true
+		if doc.Operation(route.Method, route.Path) == nil {
+			report.fail("%s %s: route is missing from the document", route.Method, route.Path)
+		}
+	}
+	for _, op := range doc.Operations() {
+		if !served[op.Method+" "+op.Route] {
+			report.fail("%s %s: documented operation has no route", op.Method, op.Route)
+		}
+	}
+}
+
+type contractRun struct {
+	doc     *Document
+	handler http.Handler
+	report  *Report
+	// values and bodies are kept from responses for later steps
+	values    map[string]string
+	bodies    map[string][]byte
+	exercised map[*Operation]bool
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{([a-z_]+)\}\}`)
+
+// expand fills in the placeholders of s, returning false when a value is missing
+func (run *contractRun) expand(s string) (string, bool) {
+	ok := true
+	expanded := placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		value, found := run.values[placeholder[2:len(placeholder)-2]]
+		if !found {
+			ok = false
+		}
+		return value
+	})
+	return expanded, ok
+}
+
+func (run *contractRun) send(s step) {
+	path, pathOK := run.expand(s.path)
+	body, bodyOK := run.expand(s.body)
+	name := s.method + " " + path
+	if !pathOK || !bodyOK {
+		run.report.fail("%s: skipped, a value it needs was not kept by an earlier request", name)
+		return
+	}
+
+	payload := []byte(body)
+	if s.bodyOf != "" {
+		payload = run.bodies[s.bodyOf]
+	}
+	req := httptest.NewRequest(s.method, path, bytes.NewReader(payload))
+	if len(payload) > 0 {
+		contentType := s.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+run.values[s.token])
+	}
+	for key, value := range s.header {
+		value, _ = run.expand(value)
+		req.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	run.handler.ServeHTTP(recorder, req)
+	run.report.Requests++
+	response := recorder.Result()
+	data := recorder.Body.Bytes()
+
+	if response.StatusCode != s.status {
+		run.report.fail("%s: answered %d instead of %d: %s", name, response.StatusCode, s.status, excerpt(data))
+		return
+	}
+
+	op := run.operation(s.method, req.URL.Path)
+	if op == nil {
+		run.report.fail("%s: no operation of the document serves this path", name)
+		return
+	}
+	if run.check(name, op, response, data) && response.StatusCode < 400 {
+		run.exercised[op] = true
+	}
+
+	if s.keepBody != "" {
+		run.bodies[s.keepBody] = data
+	}
+	run.keep(name, s.keep, data)
+}
+
+// operation finds the operation serving a request path, preferring routes with
+// more static segments as Gin does
+func (run *contractRun) operation(method, path string) *Operation {
+	segments := strings.Split(path, "/")
+	var best *Operation
+	bestScore := -1
+	for _, op := range run.doc.Operations() {
+		if op.Method != method {
+			continue
+		}
+		routeSegments := strings.Split(op.Route, "/")
+		if len(routeSegments) != len(segments) {
+			continue
+		}
+		score := 0
+		for i, segment := range routeSegments {
+			switch {
+			case strings.HasPrefix(segment, ":"):
+			case segment == segments[i]:
+				score++
+			default:
+				score = -1
+			}
+			if score < 0 {
+				break
+			}
+		}
+		if score > bestScore {
+			best, bestScore = op, score
+		}
+	}
+	return best
+}
+
+// check validates a response against the operation, returning false on failure
+func (run *contractRun) check(name string, op *Operation, response *http.Response, data []byte) bool {
+	documented, ok := op.Responses[strconv.Itoa(response.StatusCode)]
+	if !ok && response.StatusCode >= 400 {
+		documented, ok = op.Responses["default"]
+	}
+	if !ok {
+		run.report.fail("%s: status %d is not documented", name, response.StatusCode)
+		return false
+	}
+	if len(documented.Content) == 0 {
+		return true
+	}
+
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	media, ok := documented.Content[contentType]
+	if !ok {
+		run.report.fail("%s: content type %q is not documented", name, contentType)
+		return false
+	}
+	if contentType != "application/json" || media.Schema == nil {
+		return true
+	}
+
+	errors := run.doc.Validate(media.Schema, data)
+	for _, err := range errors {
+		run.report.fail("%s: %s", name, err)
+	}
+	return len(errors) == 0
+}
+
+// keep stores the values of a JSON response named by a step
+func (run *contractRun) keep(name string, paths map[string]string, data []byte) {
+	if len(paths) == 0 {
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		run.report.fail("%s: cannot keep values of a response that is not JSON", name)
+		return
+	}
+
+	for key, path := range paths {
+		found := value
+		for _, part := range strings.Split(path, ".") {
+			switch v := found.(type) {
+			case map[string]interface{}:
+				found = v[part]
+			case []interface{}:
+				i, err := strconv.Atoi(part)
+				if err != nil || i >= len(v) {
+					found = nil
+				} else {
+					found = v[i]
+				}
+			default:
+				found = nil
+			}
+		}
+		switch v := found.(type) {
+		case string:
+			run.values[key] = v
+		case json.Number:
+			run.values[key] = v.String()
+		default:
+			run.report.fail("%s: response has no value at %s", name, path)
+		}
+	}
+}
+
+// excerpt shortens a response body for a failure message
+func excerpt(data []byte) string {
+	const max = 300
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,42 @@
+package openapi_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/handlers"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/openapi"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+)
+
+const adminToken = "contract-admin-token"
+
+// TestContract serves the API on a temporary database and checks the response to
+// every request of the contract scenario against the document
+func TestContract(t *testing.T) {
+	db, err := service.InitDB(filepath.Join(t.TempDir(), "words.db"), true)
+	if err != nil {
+		t.Fatalf("initializing database: %v", err)
+	}
+	defer db.Close()
+	svc := service.New(db, service.Options{})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.ErrorHandler(), middleware.Recovery())
+	handlers.New(svc).Routes(r, &middleware.Auth{
+		AdminToken:         adminToken,
+		VerifySessionToken: svc.VerifySessionToken,
+		Authenticate:       svc.AuthenticateUser,
+	})
+
+	report := openapi.Run(r, r.Routes(), adminToken)
+	for _, failure := range report.Failures {
+		t.Error(failure)
+	}
+	if report.Requests == 0 {
+		t.Fatal("the scenario sent no requests")
+	}
+}
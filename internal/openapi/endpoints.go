@@ -0,0 +1,412 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/answer"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/models"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/service"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/xapi"
+)
+
+// overrideTypes sets the schemas of the types that encode or decode JSON
+// themselves, or accept fewer values than their Go type allows
+func overrideTypes(g *generator) {
+	zero, five := 0.0, 5.0
+	g.override(service.ReviewGrade(0), &Schema{
+		Description: "A grade from 0 to 5, or an again, hard, good or easy rating",
+		OneOf: []*Schema{
+			{Type: "integer", Minimum: &zero, Maximum: &five},
+			{Type: "string", Enum: []string{"again", "hard", "good", "easy"}},
+		},
+	})
+	g.override(answer.Verdict(""), &Schema{
+		Type: "string",
+		Enum: []string{string(answer.Correct), string(answer.AlmostCorrect), string(answer.Incorrect)},
+	})
+}
+
+// statementSchema is a stored xAPI statement. Statements are returned as they were
+// submitted, so only the properties every statement has are described.
+var statementSchema = statement("id", "actor", "verb", "object")
+
+// newStatementSchema is a statement to store, which gets an ID if it has none
+var newStatementSchema = statement("actor", "verb", "object")
+
+func statement(required ...string) *Schema {
+	return &Schema{
+		Type:        "object",
+		Description: "An xAPI " + xapi.Version + " statement",
+		Properties: map[string]*Schema{
+			"id":     {Type: "string"},
+			"actor":  {Type: "object", AdditionalProperties: &Schema{}},
+			"verb":   {Type: "object", AdditionalProperties: &Schema{}},
+			"object": {Type: "object", AdditionalProperties: &Schema{}},
+		},
+		Required:             required,
+		AdditionalProperties: &Schema{},
+	}
+}
+
+// page is a page of a list read by page number
+func (g *generator) page(item interface{}) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"items":      {Type: "array", Items: g.schemaOf(item)},
+			"pagination": g.schemaOf(models.Pagination{}),
+		},
+		Required: []string{"items", "pagination"},
+	}
+}
+
+// cursorPage is a page of a list read with cursors
+func (g *generator) cursorPage(item interface{}) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"items":      {Type: "array", Items: g.schemaOf(item)},
+			"pagination": g.schemaOf(models.CursorPagination{}),
+		},
+		Required: []string{"items", "pagination"},
+	}
+}
+
+// items is a whole list
+func (g *generator) items(item interface{}) *Schema {
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"items": {Type: "array", Items: g.schemaOf(item)}},
+		Required:   []string{"items"},
+	}
+}
+
+// message is the confirmation of an action without a result
+var message = &Schema{
+	Type:       "object",
+	Properties: map[string]*Schema{"message": {Type: "string"}},
+	Required:   []string{"message"},
+}
+
+func query(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func requiredQuery(name, description string, schema *Schema) *Parameter {
+	p := query(name, description, schema)
+	p.Required = true
+	return p
+}
+
+var (
+	stringType  = &Schema{Type: "string"}
+	integerType = &Schema{Type: "integer"}
+	idType      = &Schema{Type: "integer", Format: "int64"}
+	booleanType = &Schema{Type: "boolean"}
+	timeSchema  = &Schema{Type: "string", Format: "date-time"}
+)
+
+// filterParams describes the filters of ListOptions
+var filterParams = map[string]*Parameter{
+	"q":            query("q", "Words matching these terms, in Arabic, transliteration or English", stringType),
+	"from":         query("from", "Items started at or after this time or date", stringType),
+	"to":           query("to", "Items started before this time, or up to this date", stringType),
+	"group_id":     query("group_id", "Items of this group", idType),
+	"activity_id":  query("activity_id", "Items of this study activity", idType),
+	"min_accuracy": query("min_accuracy", "Items answered correctly at least this percentage of the time", &Schema{Type: "number"}),
+}
+
+// listParams returns the query parameters of a list of the service, read by page
+// number or with cursors
+func listParams(list string, cursor bool) []*Parameter {
+	params := pageParams()
+	if cursor {
+		params = []*Parameter{
+			query("cursor", "The next_cursor or prev_cursor of a page", stringType),
+			params[1],
+		}
+	}
+
+	spec, ok := service.ListQueryOf(list)
+	if !ok {
+		panic("openapi: unknown list " + list)
+	}
+	params = append(params,
+		query("sort", "Field to sort by, "+spec.DefaultSort+" by default", &Schema{Type: "string", Enum: spec.Sorts}),
+		query("order", "Sort direction, "+spec.DefaultOrder+" by default", &Schema{Type: "string", Enum: []string{"asc", "desc"}}),
+	)
+	for _, filter := range spec.Filters {
+		params = append(params, filterParams[filter])
+	}
+	return params
+}
+
+// pageParams are the parameters of a list read by page number
+func pageParams() []*Parameter {
+	return []*Parameter{
+		query("page", "Page number, from 1", integerType),
+		query("per_page", "Items per page, 100 by default and at most 500", integerType),
+	}
+}
+
+var limitParam = query("limit", "Number of words, 20 by default and at most 100", integerType)
+
+var xapiVersionParam = &Parameter{
+	Name:     "X-Experience-API-Version",
+	In:       "header",
+	Required: true,
+	Schema:   &Schema{Type: "string", Enum: []string{xapi.Version}},
+}
+
+var locationHeader = map[string]*Header{
+	"Location": {Description: "The launch URL of the new session", Schema: stringType},
+}
+
+func ok(body interface{}) []reply {
+	return []reply{{status: http.StatusOK, body: body}}
+}
+
+func created(body interface{}) []reply {
+	return []reply{{status: http.StatusCreated, body: body}}
+}
+
+func noContent() []reply {
+	return []reply{{status: http.StatusNoContent}}
+}
+
+// endpoints lists the operations of the API, in the order of the routes
+func endpoints(g *generator) []*endpoint {
+	return []*endpoint{
+		// Users
+		{method: "POST", route: "/api/users", handler: "Register", tag: "Users",
+			summary: "Register a user", body: service.RegisterRequest{}, responses: created(service.User{})},
+		{method: "POST", route: "/api/login", handler: "Login", tag: "Users",
+			summary:     "Log in",
+			description: "Returns a login token and sets it as the " + middleware.LoginCookie + " cookie.",
+			body:        service.LoginRequest{}, responses: ok(service.LoginResponse{})},
+		{method: "POST", route: "/api/logout", handler: "Logout", tag: "Users",
+			summary: "Log out", description: "Revokes the login token of the request, if any, and clears the cookie.",
+			responses: noContent()},
+		{method: "GET", route: "/api/me", handler: "GetCurrentUser", tag: "Users", auth: authLogin,
+			summary: "Get the logged-in user", responses: ok(service.User{})},
+		{method: "PUT", route: "/api/users/:id/role", handler: "SetUserRole", tag: "Users", auth: authAdmin,
+			summary: "Change the role of a user", body: service.UserRoleRequest{}, responses: ok(service.User{})},
+
+		// Classes
+		{method: "GET", route: "/api/classes", handler: "GetClasses", tag: "Classes", auth: authLogin,
+			summary: "List the classes of a teacher", params: pageParams(), responses: ok(g.page(service.ClassResponse{}))},
+		{method: "POST", route: "/api/classes", handler: "CreateClass", tag: "Classes", auth: authLogin,
+			summary: "Create a class", body: service.ClassRequest{}, responses: created(service.ClassDetailResponse{})},
+		{method: "GET", route: "/api/classes/:id", handler: "GetClass", tag: "Classes", auth: authLogin,
+			summary: "Get a class with its members", responses: ok(service.ClassDetailResponse{})},
+		{method: "POST", route: "/api/classes/:id/members", handler: "AddClassMembers", tag: "Classes", auth: authLogin,
+			summary: "Add learners to a class", body: service.ClassMembersRequest{}, responses: ok(service.ClassMembersResponse{})},
+		{method: "DELETE", route: "/api/classes/:id/members", handler: "RemoveClassMembers", tag: "Classes", auth: authLogin,
+			summary: "Remove learners from a class", body: service.ClassMembersRequest{}, responses: ok(service.ClassMembersResponse{})},
+		{method: "GET", route: "/api/classes/:id/assignments", handler: "GetClassAssignments", tag: "Classes", auth: authLogin,
+			summary: "List the assignments of a class", responses: ok(g.items(service.AssignmentResponse{}))},
+		{method: "POST", route: "/api/classes/:id/assignments", handler: "CreateAssignment", tag: "Classes", auth: authLogin,
+			summary: "Assign a group and study activity to a class", body: service.AssignmentRequest{},
+			responses: created(service.AssignmentResponse{})},
+		{method: "GET", route: "/api/assignments/:id/report", handler: "GetAssignmentReport", tag: "Classes", auth: authLogin,
+			summary: "Report the progress of a class on an assignment", responses: ok(service.AssignmentReportResponse{})},
+		{method: "GET", route: "/api/me/assignments", handler: "GetOpenAssignments", tag: "Classes", auth: authLogin,
+			summary: "List the open assignments of a learner", responses: ok(g.items(service.OpenAssignmentResponse{}))},
+
+		// Dashboard
+		{method: "GET", route: "/api/dashboard/last_study_session", handler: "GetLastStudySession", tag: "Dashboard",
+			summary: "Get the most recent study session", responses: ok(service.LastStudySessionResponse{})},
+		{method: "GET", route: "/api/dashboard/study_progress", handler: "GetStudyProgress", tag: "Dashboard",
+			summary: "Get the study progress", responses: ok(service.StudyProgressResponse{})},
+		{method: "GET", route: "/api/dashboard/quick-stats", handler: "GetQuickStats", tag: "Dashboard",
+			summary: "Get quick statistics", responses: ok(service.QuickStatsResponse{})},
+
+		// Study activities
+		{method: "GET", route: "/api/study_activities", handler: "GetStudyActivities", tag: "Study activities",
+			summary: "List study activities", params: listParams("activities", false),
+			responses: ok(g.page(service.ActivityResponse{}))},
+		{method: "GET", route: "/api/study_activities/:id", handler: "GetStudyActivity", tag: "Study activities",
+			summary: "Get a study activity", responses: ok(service.ActivityDetailResponse{})},
+		{method: "GET", route: "/api/study_activities/:id/study_sessions", handler: "GetStudyActivitySessions", tag: "Study activities",
+			summary: "List the study sessions of an activity", params: listParams("activity_sessions", false),
+			responses: ok(g.page(service.ActivitySessionResponse{}))},
+		{method: "POST", route: "/api/study_activities", handler: "CreateStudyActivity", tag: "Study activities",
+			summary: "Create a study activity", body: service.CreateActivityRequest{},
+			responses: created(service.CreateActivityResponse{})},
+		{method: "POST", route: "/api/study_activities/:id/launch", handler: "LaunchStudyActivity", tag: "Study activities",
+			summary: "Start a study session of an activity",
+			params:  []*Parameter{query("redirect", "Redirect to the launch URL instead of returning the session", booleanType)},
+			body:    service.LaunchActivityRequest{},
+			responses: []reply{
+				{status: http.StatusCreated, body: service.CreateStudySessionResponse{}},
+				{status: http.StatusSeeOther, description: "Redirect to the launch URL of the new session, with redirect=true",
+					headers: locationHeader},
+			}},
+		{method: "POST", route: "/api/launch/verify", handler: "VerifyLaunchToken", tag: "Study activities",
+			summary: "Exchange a launch token for its study session", body: service.LaunchTokenRequest{},
+			responses: ok(service.LaunchTokenResponse{})},
+
+		// Words
+		{method: "GET", route: "/api/words", handler: "GetWords", tag: "Words",
+			summary: "List words", params: listParams("words", false), responses: ok(g.page(service.WordWithStats{}))},
+		{method: "GET", route: "/api/words/:id", handler: "GetWord", tag: "Words",
+			summary: "Get a word", responses: ok(service.WordDetailResponse{})},
+		{method: "POST", route: "/api/words", handler: "CreateWord", tag: "Words", auth: authAdmin,
+			summary: "Create a word", body: service.CreateWordRequest{}, responses: created(service.WordDetailResponse{})},
+		{method: "POST", route: "/api/words/import", handler: "ImportWords", tag: "Words", auth: authAdmin,
+			summary: "Import words from a CSV, TSV or JSON file",
+			params: []*Parameter{
+				query("format", "csv, tsv or json, told from the upload otherwise", &Schema{Type: "string", Enum: []string{"csv", "tsv", "json"}}),
+				query("group", "Name of a group to add the words to, created if needed", stringType),
+				query("columns", "Comma separated columns of a file without a header row", stringType),
+			},
+			uploads:   []string{"text/csv", "text/tab-separated-values", "application/json", "multipart/form-data"},
+			responses: ok(service.ImportReport{})},
+		{method: "POST", route: "/api/words/import/anki", handler: "ImportAnkiWords", tag: "Words", auth: authAdmin,
+			summary: "Import words from an Anki package",
+			params: []*Parameter{
+				query("arabic_field", "Note field holding the Arabic", stringType),
+				query("roman_field", "Note field holding the transliteration", stringType),
+				query("english_field", "Note field holding the English", stringType),
+				query("group", "Name of the group to add the words to, named after their decks otherwise", stringType),
+				query("reviews", "Import the review history too", booleanType),
+			},
+			uploads:   []string{"application/octet-stream", "multipart/form-data"},
+			responses: ok(service.AnkiImportReport{})},
+		{method: "PUT", route: "/api/words/:id", handler: "UpdateWord", tag: "Words", auth: authAdmin,
+			summary: "Replace a word", body: service.CreateWordRequest{}, responses: ok(service.WordDetailResponse{})},
+		{method: "PATCH", route: "/api/words/:id", handler: "PatchWord", tag: "Words", auth: authAdmin,
+			summary: "Update some fields of a word", body: service.UpdateWordRequest{}, responses: ok(service.WordDetailResponse{})},
+		{method: "DELETE", route: "/api/words/:id", handler: "DeleteWord", tag: "Words", auth: authAdmin,
+			summary:   "Delete a word",
+			params:    []*Parameter{query("force", "Delete the word along with its review history", booleanType)},
+			responses: ok(service.DeleteWordResponse{})},
+
+		// Groups
+		{method: "GET", route: "/api/groups", handler: "GetGroups", tag: "Groups",
+			summary: "List groups", params: listParams("groups", false), responses: ok(g.page(service.GroupResponse{}))},
+		{method: "GET", route: "/api/groups/:id", handler: "GetGroup", tag: "Groups",
+			summary: "Get a group", responses: ok(service.GroupDetailResponse{})},
+		{method: "GET", route: "/api/groups/:id/words", handler: "GetGroupWords", tag: "Groups",
+			summary: "List the words of a group", params: listParams("group_words", false),
+			responses: ok(g.page(service.WordWithStats{}))},
+		{method: "GET", route: "/api/groups/:id/study_sessions", handler: "GetGroupStudySessions", tag: "Groups",
+			summary: "List the study sessions of a group", params: listParams("group_sessions", false),
+			responses: ok(g.page(service.GroupStudySession{}))},
+		{method: "POST", route: "/api/groups", handler: "CreateGroup", tag: "Groups",
+			summary: "Create a group", body: service.GroupRequest{}, responses: created(service.GroupDetailResponse{})},
+		{method: "PUT", route: "/api/groups/:id", handler: "UpdateGroup", tag: "Groups",
+			summary: "Rename a group", body: service.GroupRequest{}, responses: ok(service.GroupDetailResponse{})},
+		{method: "DELETE", route: "/api/groups/:id", handler: "DeleteGroup", tag: "Groups",
+			summary: "Delete a group", responses: ok(service.DeleteGroupResponse{})},
+		{method: "POST", route: "/api/groups/:id/words", handler: "AddGroupWords", tag: "Groups",
+			summary: "Add words to a group", body: service.GroupWordsRequest{}, responses: ok(service.GroupWordsResponse{})},
+		{method: "DELETE", route: "/api/groups/:id/words", handler: "RemoveGroupWords", tag: "Groups",
+			summary: "Remove words from a group", body: service.GroupWordsRequest{}, responses: ok(service.GroupWordsResponse{})},
+		{method: "GET", route: "/api/groups/:id/export/anki", handler: "ExportGroupAnki", tag: "Groups",
+			summary:   "Export the words of a group as an Anki package",
+			responses: []reply{{status: http.StatusOK, files: []string{"application/apkg"}}}},
+
+		// Study sessions
+		{method: "GET", route: "/api/study_sessions", handler: "GetStudySessions", tag: "Study sessions",
+			summary: "List study sessions", params: listParams("sessions", true),
+			responses: ok(g.cursorPage(service.StudySessionResponse{}))},
+		{method: "POST", route: "/api/study_sessions", handler: "CreateStudySession", tag: "Study sessions",
+			summary: "Start a study session", body: service.CreateStudySessionRequest{},
+			responses: created(service.CreateStudySessionResponse{})},
+		{method: "GET", route: "/api/study_sessions/:id", handler: "GetStudySession", tag: "Study sessions",
+			summary: "Get a study session",
+			params: []*Parameter{query("include", "statements adds the xAPI statements recorded for the session",
+				&Schema{Type: "string", Enum: []string{"statements"}})},
+			responses: ok(service.StudySessionDetailResponse{})},
+		{method: "GET", route: "/api/study_sessions/:id/words", handler: "GetStudySessionWords", tag: "Study sessions",
+			summary: "List the words reviewed in a study session", params: listParams("session_words", true),
+			responses: ok(g.cursorPage(service.SessionWordResponse{}))},
+		{method: "POST", route: "/api/study_sessions/:id/complete", handler: "CompleteStudySession", tag: "Study sessions",
+			auth: authSession, summary: "Complete a study session", responses: ok(service.StudySessionDetailResponse{})},
+		{method: "GET", route: "/api/study_sessions/:id/next", handler: "GetStudySessionNextWords", tag: "Study sessions",
+			summary: "Get the words to review next in a study session", params: []*Parameter{limitParam},
+			responses: ok(service.ReviewQueueResponse{})},
+		{method: "POST", route: "/api/study_sessions/:id/words/:word_id/review", handler: "CreateWordReview", tag: "Study sessions",
+			auth: authSession, summary: "Record a review of a word", body: service.CreateWordReviewRequest{},
+			responses: created(service.CreateWordReviewResponse{})},
+		{method: "POST", route: "/api/study_sessions/:id/words/:word_id/answer", handler: "CheckAnswer", tag: "Study sessions",
+			auth: authSession, summary: "Check a typed answer and record it as a review", body: service.CheckAnswerRequest{},
+			responses: created(service.CheckAnswerResponse{})},
+
+		// Spaced repetition
+		{method: "GET", route: "/api/review_queue", handler: "GetReviewQueue", tag: "Study sessions",
+			summary:   "Get the words of a group that are due for review",
+			params:    []*Parameter{requiredQuery("group_id", "The group to review", idType), limitParam},
+			responses: ok(service.ReviewQueueResponse{})},
+
+		// Export and restore
+		{method: "GET", route: "/api/export", handler: "ExportArchive", tag: "Export and restore", auth: authAdmin,
+			summary: "Export the whole learning record",
+			params: []*Parameter{query("format", "json, the default, or zip",
+				&Schema{Type: "string", Enum: []string{service.ArchiveFormatJSON, service.ArchiveFormatZip}})},
+			responses: []reply{{status: http.StatusOK, body: service.Archive{}, files: []string{"application/zip"}}}},
+		{method: "POST", route: "/api/import", handler: "RestoreArchive", tag: "Export and restore", auth: authAdmin,
+			summary: "Restore an exported archive", uploads: []string{"application/json", "application/zip", "multipart/form-data"},
+			responses: ok(service.RestoreReport{})},
+
+		// Reset
+		{method: "POST", route: "/api/reset_history", handler: "ResetHistory", tag: "Reset", auth: authAdmin,
+			summary: "Delete all study sessions and reviews", responses: ok(message)},
+		{method: "POST", route: "/api/full_reset", handler: "FullReset", tag: "Reset", auth: authAdmin,
+			summary: "Delete all data", responses: ok(message)},
+
+		// Error code catalog and API description
+		{method: "GET", route: "/api/errors", handler: "GetErrorCodes", tag: "API",
+			summary: "List the error codes", responses: ok(g.items(middleware.ErrorCode{}))},
+		{method: "GET", route: "/api/openapi.json", handler: "GetOpenAPI", tag: "API",
+			summary: "Get this OpenAPI document", responses: ok(&Schema{Type: "object", AdditionalProperties: &Schema{}})},
+
+		// xAPI
+		{method: "GET", route: "/xapi/about", handler: "GetXAPIAbout", tag: "xAPI",
+			summary: "Get the xAPI versions of the learning record store",
+			responses: ok(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"version": {Type: "array", Items: stringType}},
+				Required:   []string{"version"},
+			})},
+		{method: "GET", route: "/xapi/statements", handler: "GetXAPIStatements", tag: "xAPI", auth: authSession,
+			summary: "Get a statement or query statements",
+			description: "With statementId or voidedStatementId returns that statement, and a page of statements otherwise. " +
+				"A session token only reads the statements of its own study session.",
+			params: []*Parameter{
+				xapiVersionParam,
+				query("statementId", "ID of the statement to return", stringType),
+				query("voidedStatementId", "ID of the voided statement to return", stringType),
+				query("agent", "Statements about this agent, as JSON", stringType),
+				query("verb", "Statements with this verb IRI", stringType),
+				query("activity", "Statements about this activity IRI", stringType),
+				query("registration", "Statements with this registration", stringType),
+				query("since", "Statements stored after this time", timeSchema),
+				query("until", "Statements stored at or before this time", timeSchema),
+				query("limit", "Statements per page, 100 by default and at most 500", integerType),
+				query("offset", "Statements to skip", integerType),
+				query("ascending", "Oldest statements first", booleanType),
+			},
+			responses: ok(&Schema{OneOf: []*Schema{
+				{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"statements": {Type: "array", Items: statementSchema},
+						"more":       {Type: "string", Description: "Path of the next page, empty on the last one"},
+					},
+					Required: []string{"statements", "more"},
+				},
+				statementSchema,
+			}})},
+		{method: "POST", route: "/xapi/statements", handler: "PostXAPIStatements", tag: "xAPI", auth: authSession,
+			summary: "Store statements", params: []*Parameter{xapiVersionParam},
+			body:      &Schema{OneOf: []*Schema{newStatementSchema, {Type: "array", Items: newStatementSchema}}},
+			responses: ok(&Schema{Type: "array", Items: stringType, Description: "IDs of the stored statements"})},
+		{method: "PUT", route: "/xapi/statements", handler: "PutXAPIStatement", tag: "xAPI", auth: authSession,
+			summary: "Store a statement under an ID",
+			params:  []*Parameter{xapiVersionParam, requiredQuery("statementId", "ID of the statement", stringType)},
+			body:    newStatementSchema, responses: noContent()},
+	}
+}
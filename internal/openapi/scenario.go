@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Values of the contract scenario
+const (
+	contractPassword = "contract-password"
+	// contractStatementID is the ID the scenario stores a statement under with PUT
+	contractStatementID = "0b5e7a52-4c1d-4f3e-9a6b-7d2c8e1f0a93"
+)
+
+// xapiHeader is sent with the xAPI statement requests
+var xapiHeader = map[string]string{"X-Experience-API-Version": "1.0.3"}
+
+// answeredStatement is an answered statement about the first word in the first
+// session of the scenario
+const answeredStatement = `{
+	"actor": {"mbox": "mailto:learner@example.com", "name": "Learner"},
+	"verb": {"id": "http://adlnet.gov/expapi/verbs/answered"},
+	"object": {"id": "http://localhost/api/words/{{hello_id}}"},
+	"result": {"success": true, "response": "hello", "duration": "PT2.5S"},
+	"context": {
+		"contextActivities": {
+			"parent": [{"id": "http://localhost/api/study_sessions/{{session_id}}"}]
+		}
+	}
+}`
+
+// scenario exercises every endpoint on an empty database, keeping the IDs and
+// tokens it is given for the requests that need them. Besides the successful
+// responses it checks a few error responses of each kind.
+func scenario() []step {
+	credentials := func(username string) string {
+		return `{"username": "` + username + `", "password": "` + contractPassword + `"}`
+	}
+	csv := "arabic,roman,english\nكتاب,kitaab,book\nقلم,qalam,pen\n"
+	statementPath := "/xapi/statements?statementId=" + url.QueryEscape(contractStatementID)
+
+	return []step{
+		// The API description
+		{method: "GET", path: "/api/openapi.json", status: http.StatusOK},
+		{method: "GET", path: "/api/errors", status: http.StatusOK},
+
+		// Empty lists
+		{method: "GET", path: "/api/words", status: http.StatusOK},
+		{method: "GET", path: "/api/groups", status: http.StatusOK},
+		{method: "GET", path: "/api/study_activities", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/study_progress", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/quick-stats", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/last_study_session", status: http.StatusNotFound},
+		{method: "GET", path: "/api/export", token: "admin", status: http.StatusOK},
+
+		// Users
+		{method: "POST", path: "/api/users", status: http.StatusCreated,
+			body: `{"username": "teacher", "password": "` + contractPassword + `", "display_name": "Teacher"}`,
+			keep: map[string]string{"teacher_id": "id"}},
+		{method: "POST", path: "/api/users", body: credentials("learner"), status: http.StatusCreated},
+		{method: "POST", path: "/api/users", body: credentials("learner"), status: http.StatusConflict},
+		{method: "POST", path: "/api/users", body: `{"username": "nobody"}`, status: http.StatusBadRequest},
+		{method: "PUT", path: "/api/users/{{teacher_id}}/role", token: "admin", body: `{"role": "teacher"}`, status: http.StatusOK},
+		{method: "PUT", path: "/api/users/{{teacher_id}}/role", body: `{"role": "teacher"}`, status: http.StatusUnauthorized},
+		{method: "POST", path: "/api/login", body: credentials("teacher"), status: http.StatusOK,
+			keep: map[string]string{"teacher": "token"}},
+		{method: "POST", path: "/api/login", body: credentials("learner"), status: http.StatusOK,
+			keep: map[string]string{"learner": "token"}},
+		{method: "POST", path: "/api/login", body: `{"username": "learner", "password": "wrong"}`, status: http.StatusUnauthorized},
+		{method: "GET", path: "/api/me", token: "learner", status: http.StatusOK},
+		{method: "GET", path: "/api/me", status: http.StatusUnauthorized},
+		{method: "GET", path: "/api/classes", token: "teacher", status: http.StatusOK},
+		{method: "GET", path: "/api/me/assignments", token: "learner", status: http.StatusOK},
+
+		// Words
+		{method: "POST", path: "/api/words", token: "admin", status: http.StatusCreated,
+			body: `{"arabic": "مرحبا", "roman": "marhaban", "english": "hello", "parts": {"type": "greeting"}}`,
+			keep: map[string]string{"hello_id": "id"}},
+		{method: "POST", path: "/api/words", token: "admin", status: http.StatusCreated,
+			body: `{"arabic": "شكرا", "roman": "shukran", "english": "thanks"}`,
+			keep: map[string]string{"thanks_id": "id"}},
+		{method: "POST", path: "/api/words", token: "admin", status: http.StatusConflict,
+			body: `{"arabic": "مرحبا", "roman": "marhaban", "english": "hello"}`},
+		{method: "GET", path: "/api/words", status: http.StatusOK},
+		{method: "GET", path: "/api/words?q=marhaban&sort=english&order=desc&per_page=10", status: http.StatusOK},
+		{method: "GET", path: "/api/words?sort=nonsense", status: http.StatusBadRequest},
+		{method: "GET", path: "/api/words/{{hello_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/words/999999", status: http.StatusNotFound},
+		{method: "GET", path: "/api/words/hello", status: http.StatusBadRequest},
+		{method: "PUT", path: "/api/words/{{thanks_id}}", token: "admin", status: http.StatusOK,
+			body: `{"arabic": "شكرا", "roman": "shukran", "english": "thank you"}`},
+		{method: "PATCH", path: "/api/words/{{thanks_id}}", token: "admin", body: `{"english": "thanks"}`, status: http.StatusOK},
+		{method: "PATCH", path: "/api/words/{{thanks_id}}", body: `{"english": "thanks"}`, status: http.StatusUnauthorized},
+
+		// Groups
+		{method: "POST", path: "/api/groups", body: `{"name": "Greetings"}`, status: http.StatusCreated,
+			keep: map[string]string{"group_id": "id"}},
+		{method: "POST", path: "/api/groups", body: `{"name": "Spare"}`, status: http.StatusCreated,
+			keep: map[string]string{"spare_id": "id"}},
+		{method: "POST", path: "/api/groups/{{group_id}}/words", status: http.StatusOK,
+			body: `{"word_ids": [{{hello_id}}, {{thanks_id}}]}`},
+		{method: "PUT", path: "/api/groups/{{group_id}}", body: `{"name": "Basic Greetings"}`, status: http.StatusOK},
+		{method: "GET", path: "/api/groups", status: http.StatusOK},
+		{method: "GET", path: "/api/groups/{{group_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/groups/{{group_id}}/words", status: http.StatusOK},
+		{method: "GET", path: "/api/groups/{{spare_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/groups/{{spare_id}}/words", status: http.StatusOK},
+		{method: "GET", path: "/api/groups/{{spare_id}}/study_sessions", status: http.StatusOK},
+
+		// Study activities
+		{method: "POST", path: "/api/study_activities", status: http.StatusCreated,
+			body: `{"name": "Vocabulary Quiz", "thumbnail_url": "/thumbnails/quiz.png", "description": "Practice vocabulary",
+				"launch_url": "http://localhost/quiz?session={session_id}&token={launch_token}"}`,
+			keep: map[string]string{"activity_id": "id"}},
+		{method: "POST", path: "/api/study_activities", status: http.StatusBadRequest,
+			body: `{"name": "Broken", "thumbnail_url": "/x.png", "description": "x", "launch_url": "http://localhost/{nonsense}"}`},
+		{method: "GET", path: "/api/study_activities", status: http.StatusOK},
+		{method: "GET", path: "/api/study_activities/{{activity_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/study_activities/{{activity_id}}/study_sessions", status: http.StatusOK},
+
+		// Study sessions
+		{method: "POST", path: "/api/study_sessions", status: http.StatusCreated,
+			body: `{"group_id": {{group_id}}, "study_activity_id": {{activity_id}}}`,
+			keep: map[string]string{"session_id": "id", "session": "session_token", "launch": "launch_token"}},
+		{method: "POST", path: "/api/study_sessions", status: http.StatusNotFound,
+			body: `{"group_id": 999999, "study_activity_id": {{activity_id}}}`},
+		{method: "POST", path: "/api/launch/verify", body: `{"token": "{{launch}}"}`, status: http.StatusOK},
+		{method: "POST", path: "/api/launch/verify", body: `{"token": "not-a-token"}`, status: http.StatusUnauthorized},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}/next", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}/next?limit=0", status: http.StatusBadRequest},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}/words", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}?include=statements", status: http.StatusOK},
+		{method: "POST", path: "/api/study_sessions/{{session_id}}/words/{{thanks_id}}/review", token: "session",
+			body: `{"grade": "good", "response_time_ms": 1800}`, status: http.StatusCreated},
+		{method: "POST", path: "/api/study_sessions/{{session_id}}/words/{{thanks_id}}/review",
+			body: `{"grade": "good"}`, status: http.StatusUnauthorized},
+		{method: "POST", path: "/api/study_sessions/{{session_id}}/words/{{thanks_id}}/answer", token: "session",
+			body: `{"answer": "thanks"}`, status: http.StatusCreated},
+
+		// xAPI
+		{method: "GET", path: "/xapi/about", status: http.StatusOK},
+		{method: "POST", path: "/xapi/statements", token: "session", header: xapiHeader,
+			body: answeredStatement, status: http.StatusOK},
+		{method: "PUT", path: statementPath, token: "session", header: xapiHeader,
+			body: answeredStatement, status: http.StatusNoContent},
+		{method: "GET", path: statementPath, token: "session", header: xapiHeader, status: http.StatusOK},
+		{method: "GET", path: "/xapi/statements?limit=1", token: "session", header: xapiHeader, status: http.StatusOK},
+		{method: "GET", path: "/xapi/statements", token: "session", status: http.StatusBadRequest},
+
+		// The session and its progress
+		{method: "GET", path: "/api/study_sessions", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions?per_page=1&sort=accuracy", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}?include=statements", status: http.StatusOK},
+		{method: "GET", path: "/api/study_sessions/{{session_id}}/words", status: http.StatusOK},
+		{method: "GET", path: "/api/review_queue?group_id={{group_id}}", status: http.StatusOK},
+		{method: "GET", path: "/api/review_queue", status: http.StatusBadRequest},
+		{method: "POST", path: "/api/study_sessions/{{session_id}}/complete", token: "session", status: http.StatusOK},
+		{method: "POST", path: "/api/study_sessions/{{session_id}}/words/{{hello_id}}/review", token: "session",
+			body: `{"is_correct": true}`, status: http.StatusConflict},
+		{method: "GET", path: "/api/groups/{{group_id}}/study_sessions", status: http.StatusOK},
+		{method: "GET", path: "/api/study_activities/{{activity_id}}/study_sessions", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/last_study_session", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/study_progress", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/quick-stats", status: http.StatusOK},
+		{method: "GET", path: "/api/dashboard/last_study_session", token: "learner", status: http.StatusNotFound},
+
+		// Launching activities
+		{method: "POST", path: "/api/study_activities/{{activity_id}}/launch", token: "learner",
+			body: `{"group_id": {{group_id}}}`, status: http.StatusCreated},
+		{method: "POST", path: "/api/study_activities/{{activity_id}}/launch?redirect=true",
+			body: `{"group_id": {{group_id}}}`, status: http.StatusSeeOther},
+
+		// Classes
+		{method: "POST", path: "/api/classes", token: "teacher", body: `{"name": "Class A"}`, status: http.StatusCreated,
+			keep: map[string]string{"class_id": "id"}},
+		{method: "POST", path: "/api/classes", token: "learner", body: `{"name": "Class B"}`, status: http.StatusForbidden},
+		{method: "GET", path: "/api/classes", token: "teacher", status: http.StatusOK},
+		{method: "POST", path: "/api/classes/{{class_id}}/members", token: "teacher",
+			body: `{"usernames": ["learner", "nobody"]}`, status: http.StatusOK},
+		{method: "GET", path: "/api/classes/{{class_id}}", token: "teacher", status: http.StatusOK},
+		{method: "POST", path: "/api/classes/{{class_id}}/assignments", token: "teacher", status: http.StatusCreated,
+			body: `{"group_id": {{group_id}}, "study_activity_id": {{activity_id}}, "due_at": "2100-01-01T00:00:00Z"}`,
+			keep: map[string]string{"assignment_id": "id"}},
+		{method: "GET", path: "/api/classes/{{class_id}}/assignments", token: "teacher", status: http.StatusOK},
+		{method: "GET", path: "/api/assignments/{{assignment_id}}/report", token: "teacher", status: http.StatusOK},
+		{method: "GET", path: "/api/me/assignments", token: "learner", status: http.StatusOK},
+		{method: "DELETE", path: "/api/classes/{{class_id}}/members", token: "teacher",
+			body: `{"usernames": ["learner"]}`, status: http.StatusOK},
+		{method: "GET", path: "/api/classes/999999", token: "teacher", status: http.StatusNotFound},
+
+		// Imports and exports
+		{method: "POST", path: "/api/words/import?format=csv&group=Imported", token: "admin", body: csv, contentType: "text/csv",
+			status: http.StatusOK},
+		{method: "POST", path: "/api/words/import", token: "admin", body: "not a vocabulary file", contentType: "text/plain",
+			status: http.StatusBadRequest},
+		{method: "GET", path: "/api/groups/{{group_id}}/export/anki", status: http.StatusOK, keepBody: "anki"},
+		{method: "POST", path: "/api/words/import/anki?reviews=true", token: "admin", bodyOf: "anki",
+			contentType: "application/octet-stream", status: http.StatusOK},
+		{method: "GET", path: "/api/export", token: "admin", status: http.StatusOK, keepBody: "archive"},
+		{method: "GET", path: "/api/export?format=zip", token: "admin", status: http.StatusOK},
+		{method: "GET", path: "/api/export", status: http.StatusUnauthorized},
+		{method: "POST", path: "/api/import", token: "admin", bodyOf: "archive", status: http.StatusOK},
+
+		// Removing words and groups
+		{method: "DELETE", path: "/api/groups/{{group_id}}/words", status: http.StatusOK,
+			body: `{"word_ids": [{{thanks_id}}]}`},
+		{method: "DELETE", path: "/api/words/{{thanks_id}}", token: "admin", status: http.StatusConflict},
+		{method: "DELETE", path: "/api/words/{{thanks_id}}?force=true", token: "admin", status: http.StatusOK},
+		{method: "DELETE", path: "/api/groups/{{spare_id}}", status: http.StatusOK},
+
+		// Logging out and resetting
+		{method: "POST", path: "/api/logout", token: "learner", status: http.StatusNoContent},
+		{method: "GET", path: "/api/me", token: "learner", status: http.StatusUnauthorized},
+		{method: "POST", path: "/api/reset_history", token: "admin", status: http.StatusOK},
+		{method: "POST", path: "/api/full_reset", token: "admin", status: http.StatusOK},
+	}
+}
@@ -0,0 +1,201 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document and checks
+// responses against it. Schemas are generated from the request and response
+// types of the service, so the document follows the code it describes.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is an OpenAPI 3.0 schema object, limited to what the API needs
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// refPrefix starts the references to component schemas
+const refPrefix = "#/components/schemas/"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator builds schemas from Go types by reflection, collecting the named
+// struct types it meets as component schemas
+type generator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	// overrides replace the generated schema of types with custom JSON encodings
+	overrides map[reflect.Type]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+		overrides:  map[reflect.Type]*Schema{},
+	}
+}
+
+// override sets the schema of the type of v
+func (g *generator) override(v interface{}, s *Schema) {
+	g.overrides[reflect.TypeOf(v)] = s
+}
+
+// schemaOf returns the schema of the JSON encoding of v. A *Schema is returned
+// as it is.
+func (g *generator) schemaOf(v interface{}) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return g.schema(reflect.TypeOf(v), false)
+}
+
+// requestOf returns the schema of a request body decoded into v, whose required
+// fields are those Gin validates with binding:"required"
+func (g *generator) requestOf(v interface{}) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return g.schema(reflect.TypeOf(v), true)
+}
+
+func (g *generator) schema(t reflect.Type, request bool) *Schema {
+	if s, ok := g.overrides[t]; ok {
+		return s
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(g.schema(t.Elem(), request))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), request)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), request)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, request)
+		}
+		return &Schema{Ref: refPrefix + g.component(t, request)}
+	}
+	// Interfaces hold any value
+	return &Schema{}
+}
+
+// component adds a named struct type to the component schemas and returns the
+// name it is registered under
+func (g *generator) component(t reflect.Type, request bool) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.components[name]; taken {
+		// Types of different packages may share a name
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = string(unicode.ToUpper(rune(pkg[0]))) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// Register the name first, so that recursive types refer to themselves
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.object(t, request)
+	return name
+}
+
+// object returns the schema of a struct, with the fields of embedded structs
+// promoted as encoding/json does
+func (g *generator) object(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(s, t, request)
+	return s
+}
+
+func (g *generator) fields(s *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(s, embedded, request)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = g.schema(field.Type, request)
+		var required bool
+		if request {
+			required = strings.Contains(field.Tag.Get("binding"), "required")
+		} else {
+			required = !strings.Contains(options, "omitempty")
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullable allows null in place of a value of s. References cannot have siblings
+// in OpenAPI 3.0, so they are wrapped in allOf.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Nullable: true}
+	}
+	if s.Type == "" && s.AllOf == nil && s.OneOf == nil {
+		// Any value includes null already
+		return s
+	}
+	copied := *s
+	copied.Nullable = true
+	return &copied
+}
@@ -0,0 +1,361 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/middleware"
+)
+
+// Version is the OpenAPI version of the document
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	operations []*Operation
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by lower case method
+type PathItem map[string]*Operation
+
+// Operation is an endpoint of the API. Method and Route, the Gin route it is
+// served by, are not part of the document.
+type Operation struct {
+	Method string `json:"-"`
+	Route  string `json:"-"`
+
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody lists the content types an operation accepts
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header is a response header
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body, if it has one
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the named schemas and the security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is a way of passing a token
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operations returns the operations of the document in the order they are
+// declared
+func (d *Document) Operations() []*Operation {
+	return d.operations
+}
+
+// Operation returns the operation served by a Gin route, or nil
+func (d *Document) Operation(method, route string) *Operation {
+	for _, op := range d.operations {
+		if op.Method == method && op.Route == route {
+			return op
+		}
+	}
+	return nil
+}
+
+// Resolve returns the component schema a reference points to, or s itself when
+// it is not a reference
+func (d *Document) Resolve(s *Schema) *Schema {
+	if s.Ref == "" {
+		return s
+	}
+	if resolved, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]; ok {
+		return resolved
+	}
+	return s
+}
+
+var (
+	specOnce sync.Once
+	spec     *Document
+)
+
+// Spec returns the OpenAPI document of the API. It is built once.
+func Spec() *Document {
+	specOnce.Do(func() {
+		spec = build()
+	})
+	return spec
+}
+
+// build assembles the document from the operations table
+func build() *Document {
+	g := newGenerator()
+	overrideTypes(g)
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title: "Language Learning Portal API",
+			Description: "Vocabulary, study sessions and progress of the language learning portal. " +
+				"Every /api endpoint accepts an optional login token, which scopes progress to the " +
+				"logged-in user. Errors are answered with an ErrorResponse; GET /api/errors lists " +
+				"their codes.",
+			Version: "1.0",
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: g.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "A login, session or admin token, depending on the endpoint",
+				},
+				"basicAuth": {
+					Type:        "http",
+					Scheme:      "basic",
+					Description: "A session or admin token as the password, as sent by xAPI clients",
+				},
+				"loginCookie": {
+					Type:        "apiKey",
+					In:          "cookie",
+					Name:        middleware.LoginCookie,
+					Description: "The login token set by POST /api/login",
+				},
+			},
+		},
+	}
+
+	errorResponse := &Response{
+		Description: "Error",
+		Content:     jsonContent(g.schemaOf(middleware.ErrorResponse{})),
+	}
+	tags := map[string]bool{}
+	for _, e := range endpoints(g) {
+		op := e.operation(g)
+		op.Responses["default"] = errorResponse
+		doc.operations = append(doc.operations, op)
+
+		path := openAPIPath(op.Route)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = op
+
+		if !tags[e.tag] {
+			tags[e.tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: e.tag})
+		}
+	}
+	return doc
+}
+
+// openAPIPath turns the parameters of a Gin route, as in /words/:id, into
+// OpenAPI templates, as in /words/{id}
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// routeParams returns the names of the parameters of a Gin route
+func routeParams(route string) []string {
+	var names []string
+	for _, segment := range strings.Split(route, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// authKind is the token an endpoint requires
+type authKind int
+
+const (
+	authNone authKind = iota
+	// authLogin requires a login token
+	authLogin
+	// authSession requires a token of the session, or the admin token
+	authSession
+	// authAdmin requires the admin token
+	authAdmin
+)
+
+// security returns the security requirements and a description of an auth kind
+func (a authKind) security() ([]map[string][]string, string) {
+	switch a {
+	case authLogin:
+		return []map[string][]string{{"bearerAuth": {}}, {"loginCookie": {}}},
+			"Requires a login token."
+	case authSession:
+		return []map[string][]string{{"bearerAuth": {}}, {"basicAuth": {}}},
+			"Requires the session token of the study session, or the admin token."
+	case authAdmin:
+		return []map[string][]string{{"bearerAuth": {}}, {"basicAuth": {}}},
+			"Requires the admin token."
+	}
+	return nil, ""
+}
+
+// endpoint is an entry of the operations table
+type endpoint struct {
+	method, route string
+	// handler names the handler serving the route, used as the operation ID
+	handler      string
+	tag, summary string
+	description  string
+	auth         authKind
+	params       []*Parameter
+	// body is a value of the JSON request body type, or nil
+	body interface{}
+	// uploads are the content types of a body that is not decoded as JSON
+	uploads   []string
+	responses []reply
+}
+
+// reply is a response of an endpoint
+type reply struct {
+	status      int
+	description string
+	// body is a value of the JSON response type or a *Schema, nil for no body
+	body interface{}
+	// files are the content types of a body that is not JSON
+	files   []string
+	headers map[string]*Header
+}
+
+func (e *endpoint) operation(g *generator) *Operation {
+	op := &Operation{
+		Method:      e.method,
+		Route:       e.route,
+		OperationID: e.handler,
+		Tags:        []string{e.tag},
+		Summary:     e.summary,
+		Description: e.description,
+		Responses:   map[string]*Response{},
+	}
+
+	security, note := e.auth.security()
+	op.Security = security
+	if note != "" {
+		op.Description = strings.TrimSpace(op.Description + " " + note)
+	}
+
+	for _, name := range routeParams(e.route) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer", Format: "int64"},
+		})
+	}
+	op.Parameters = append(op.Parameters, e.params...)
+
+	switch {
+	case e.body != nil:
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.requestOf(e.body))}
+	case len(e.uploads) > 0:
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{}}
+		for _, contentType := range e.uploads {
+			op.RequestBody.Content[contentType] = uploadType(contentType)
+		}
+	}
+
+	for _, r := range e.responses {
+		response := &Response{Description: r.description, Headers: r.headers}
+		if response.Description == "" {
+			response.Description = http.StatusText(r.status)
+		}
+		if r.body != nil {
+			response.Content = jsonContent(g.schemaOf(r.body))
+		}
+		for _, contentType := range r.files {
+			if response.Content == nil {
+				response.Content = map[string]*MediaType{}
+			}
+			if _, ok := response.Content[contentType]; !ok {
+				response.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+			}
+		}
+		op.Responses[strconv.Itoa(r.status)] = response
+	}
+	return op
+}
+
+// uploadType describes an upload body: a file in the "file" field of a form, or
+// the file itself
+func uploadType(contentType string) *MediaType {
+	if contentType == "multipart/form-data" {
+		return &MediaType{Schema: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}},
+			Required:   []string{"file"},
+		}}
+	}
+	return &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+}
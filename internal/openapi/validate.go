@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Validate checks a JSON document against a schema of the document and returns
+// every mismatch, each prefixed with the JSON path it was found at
+func (d *Document) Validate(s *Schema, data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"$: invalid JSON: " + err.Error()}
+	}
+
+	v := &validator{doc: d}
+	v.check("$", s, value)
+	return v.errors
+}
+
+type validator struct {
+	doc    *Document
+	errors []string
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches reports whether value fits s, without recording the mismatches
+func (v *validator) matches(path string, s *Schema, value interface{}) bool {
+	sub := &validator{doc: v.doc}
+	sub.check(path, s, value)
+	return len(sub.errors) == 0
+}
+
+func (v *validator) check(path string, s *Schema, value interface{}) {
+	if s.Ref != "" {
+		resolved := v.doc.Resolve(s)
+		if resolved == s {
+			v.fail(path, "unknown schema %s", s.Ref)
+			return
+		}
+		s = resolved
+	}
+
+	if value == nil {
+		if !s.Nullable && (s.Type != "" || s.AllOf != nil || s.OneOf != nil) {
+			v.fail(path, "is null")
+		}
+		return
+	}
+
+	for _, sub := range s.AllOf {
+		v.check(path, sub, value)
+	}
+	if s.OneOf != nil {
+		var matched int
+		for _, sub := range s.OneOf {
+			if v.matches(path, sub, value) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "matches %d of the %d allowed schemas instead of one", matched, len(s.OneOf))
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "is %s, not an object", kind(value))
+			return
+		}
+		v.checkObject(path, s, object)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, "is %s, not an array", kind(value))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				v.check(fmt.Sprintf("%s[%d]", path, i), s.Items, item)
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			v.fail(path, "is %s, not a string", kind(value))
+			return
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, text); err != nil {
+				v.fail(path, "%q is not a date-time", text)
+			}
+		}
+		if s.Enum != nil && !contains(s.Enum, text) {
+			v.fail(path, "%q is not one of %v", text, s.Enum)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			v.fail(path, "is %s, not a number", kind(value))
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				v.fail(path, "%s is not an integer", number)
+				return
+			}
+		}
+		f, _ := number.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			v.fail(path, "%s is below the minimum of %v", number, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			v.fail(path, "%s is above the maximum of %v", number, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "is %s, not a boolean", kind(value))
+		}
+	}
+}
+
+// checkObject checks the properties of an object. Properties the schema does not
+// declare are reported unless it allows additional properties, since they are
+// missing from the document.
+func (v *validator) checkObject(path string, s *Schema, object map[string]interface{}) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(path, "misses required property %q", name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		switch {
+		case ok:
+			v.check(path+"."+name, property, object[name])
+		case s.AdditionalProperties != nil:
+			v.check(path+"."+name, s.AdditionalProperties, object[name])
+		default:
+			v.fail(path, "has undocumented property %q", name)
+		}
+	}
+}
+
+// kind names the JSON type of a decoded value
+func kind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return "null"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
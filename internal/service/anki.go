@@ -0,0 +1,431 @@
+package service
+
+import (
+    "database/sql"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/anki"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// ankiActivityName is the study activity that imported Anki review history is filed under
+const ankiActivityName = "Anki"
+
+// ankiRatings maps Anki's answer buttons (ease 1-4) onto ratings
+var ankiRatings = []string{"again", "hard", "good", "easy"}
+
+// ankiFieldNames lists the note field names recognized for each word field when no
+// explicit mapping is given
+var ankiFieldNames = map[string][]string{
+    "arabic":  {"arabic", "ar", "word", "expression", "front"},
+    "roman":   {"roman", "romanization", "romanisation", "transliteration", "pronunciation", "reading"},
+    "english": {"english", "en", "meaning", "translation", "definition", "back"},
+}
+
+// ankiNoteType is the note type of exported decks
+var ankiNoteType = anki.NoteType{
+    Name:   "Lang Portal Arabic",
+    Fields: []string{"Arabic", "Roman", "English"},
+    Templates: []anki.Template{
+        {
+            Name:  "Arabic to English",
+            Front: `<div class="arabic">{{Arabic}}</div>`,
+            Back:  `{{FrontSide}}<hr id=answer><div>{{English}}</div><div class="roman">{{Roman}}</div>`,
+        },
+        {
+            Name:  "English to Arabic",
+            Front: `<div>{{English}}</div>`,
+            Back:  `{{FrontSide}}<hr id=answer><div class="arabic">{{Arabic}}</div><div class="roman">{{Roman}}</div>`,
+        },
+    },
+    CSS: ".card { font-family: arial; font-size: 24px; text-align: center; }\n" +
+        ".arabic { direction: rtl; font-size: 40px; }\n" +
+        ".roman { color: #666; font-style: italic; }\n",
+}
+
+// AnkiImportOptions controls how an Anki package is imported
+type AnkiImportOptions struct {
+    // ArabicField, RomanField and EnglishField name the note fields holding each
+    // word field. Fields are matched by common names such as "Arabic", "Front" or
+    // "Transliteration" when left empty, and Arabic and English fall back to the
+    // first field that is or is not written in Arabic script.
+    ArabicField  string
+    RomanField   string
+    EnglishField string
+    // GroupName adds every word to this group. Otherwise each word is added to a
+    // group named after the deck holding its cards.
+    GroupName string
+    // Reviews imports the review history of newly inserted words
+    Reviews bool
+    // UserID is the learner the review history is imported for, 0 for the guest learner
+    UserID int64
+}
+
+// AnkiImportGroup reports a group words were added to
+type AnkiImportGroup struct {
+    ID        int64  `json:"id"`
+    Name      string `json:"name"`
+    WordCount int    `json:"word_count"`
+    SessionID *int64 `json:"study_session_id,omitempty"`
+}
+
+// AnkiImportReport summarizes an Anki import. Rows are numbered by note.
+type AnkiImportReport struct {
+    ImportReport
+    Groups          []AnkiImportGroup `json:"groups"`
+    ReviewsImported int               `json:"reviews_imported"`
+}
+
+// ankiGroupImport tracks a group and the review history imported into it
+type ankiGroupImport struct {
+    AnkiImportGroup
+    reviews []ankiReview
+}
+
+type ankiReview struct {
+    wordID int64
+    anki.Review
+}
+
+// ImportAnkiPackage imports the notes of an .apkg package as words in a single
+// transaction. Notes that fail validation or already exist as words are reported
+// and skipped. Review history is only imported for words the import inserted, so
+// importing the same deck twice does not duplicate it.
+func (s *Service) ImportAnkiPackage(r io.Reader, opts AnkiImportOptions) (*AnkiImportReport, error) {
+    db := s.db
+
+    collection, err := anki.ReadPackage(r)
+    if err != nil {
+        return nil, &ValidationError{Entity: "import", Field: "file", Message: err.Error()}
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    report := &AnkiImportReport{
+        ImportReport: ImportReport{Rows: []ImportRowResult{}},
+        Groups:       []AnkiImportGroup{},
+    }
+    groups := make(map[string]*ankiGroupImport)
+    var groupOrder []string
+
+    for i, note := range collection.Notes {
+        result := ImportRowResult{Row: i + 1}
+
+        arabicText, roman, english, err := mapAnkiNote(collection, note, opts)
+        var word *CreateWordRequest
+        if err == nil {
+            word, err = normalizeWord(arabicText, roman, english, nil)
+        }
+        if err != nil {
+            result.Status = ImportStatusInvalid
+            result.Arabic = arabicText
+            result.English = english
+            result.Message = err.Error()
+            report.Invalid++
+            report.Rows = append(report.Rows, result)
+            continue
+        }
+        result.Arabic = word.Arabic
+        result.English = word.English
+
+        existingID, err := findDuplicateWord(tx, word.Arabic, word.English, 0)
+        if err != nil {
+            return nil, err
+        }
+
+        if existingID != 0 {
+            result.Status = ImportStatusDuplicate
+            result.WordID = existingID
+            report.Skipped++
+        } else {
+            inserted, err := tx.Exec(`
+                INSERT INTO words (arabic, roman, english, parts, arabic_normalized, roman_normalized)
+                VALUES (?, ?, ?, ?, ?, ?)`,
+                word.Arabic, word.Roman, word.English, storedParts(word),
+                arabic.Normalize(word.Arabic), arabic.NormalizeRoman(word.Roman))
+            if err != nil {
+                logging.Errorf("Error importing Anki note %d: %v", note.ID, err)
+                return nil, err
+            }
+            if result.WordID, err = inserted.LastInsertId(); err != nil {
+                return nil, err
+            }
+            result.Status = ImportStatusInserted
+            report.Inserted++
+        }
+
+        groupName := strings.TrimSpace(opts.GroupName)
+        if groupName == "" && len(note.DeckIDs) > 0 {
+            groupName = collection.Decks[note.DeckIDs[0]]
+        }
+        if groupName != "" {
+            group, ok := groups[groupName]
+            if !ok {
+                groupID, err := ensureGroup(tx, groupName)
+                if err != nil {
+                    return nil, err
+                }
+                group = &ankiGroupImport{AnkiImportGroup: AnkiImportGroup{ID: groupID, Name: groupName}}
+                groups[groupName] = group
+                groupOrder = append(groupOrder, groupName)
+            }
+
+            _, err = tx.Exec(
+                "INSERT OR IGNORE INTO words_groups (word_id, group_id) VALUES (?, ?)",
+                result.WordID, group.ID)
+            if err != nil {
+                logging.Errorf("Error adding imported word %d to group %d: %v", result.WordID, group.ID, err)
+                return nil, err
+            }
+            group.WordCount++
+            result.Group = groupName
+
+            if opts.Reviews && result.Status == ImportStatusInserted {
+                for _, review := range note.Reviews {
+                    group.reviews = append(group.reviews, ankiReview{wordID: result.WordID, Review: review})
+                }
+            }
+        }
+
+        report.Rows = append(report.Rows, result)
+    }
+
+    for _, name := range groupOrder {
+        group := groups[name]
+        if len(group.reviews) > 0 {
+            sessionID, err := importAnkiReviews(tx, s.opts.Scheduler, group.ID, opts.UserID, group.reviews)
+            if err != nil {
+                return nil, err
+            }
+            group.SessionID = &sessionID
+            report.ReviewsImported += len(group.reviews)
+        }
+        report.Groups = append(report.Groups, group.AnkiImportGroup)
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return report, nil
+}
+
+// mapAnkiNote extracts the arabic, roman and english text of a note
+func mapAnkiNote(collection *anki.Collection, note anki.Note, opts AnkiImportOptions) (string, string, string, error) {
+    noteType, ok := collection.NoteTypes[note.NoteTypeID]
+    if !ok {
+        return "", "", "", &ValidationError{Field: "note_type", Message: fmt.Sprintf("note type %d not found", note.NoteTypeID)}
+    }
+
+    used := make(map[int]bool)
+    find := func(field, explicit string) (int, error) {
+        if explicit != "" {
+            for i, name := range noteType.Fields {
+                if strings.EqualFold(name, explicit) {
+                    used[i] = true
+                    return i, nil
+                }
+            }
+            return -1, &ValidationError{
+                Field:   field,
+                Message: fmt.Sprintf("note type %q has no field %q", noteType.Name, explicit),
+            }
+        }
+        for _, candidate := range ankiFieldNames[field] {
+            for i, name := range noteType.Fields {
+                if !used[i] && strings.EqualFold(strings.TrimSpace(name), candidate) {
+                    used[i] = true
+                    return i, nil
+                }
+            }
+        }
+        return -1, nil
+    }
+
+    arabicIndex, err := find("arabic", opts.ArabicField)
+    if err != nil {
+        return "", "", "", err
+    }
+    romanIndex, err := find("roman", opts.RomanField)
+    if err != nil {
+        return "", "", "", err
+    }
+    englishIndex, err := find("english", opts.EnglishField)
+    if err != nil {
+        return "", "", "", err
+    }
+
+    text := func(i int) string {
+        if i < 0 || i >= len(note.Fields) {
+            return ""
+        }
+        return anki.Text(note.Fields[i])
+    }
+
+    // Fall back to telling the Arabic and English fields apart by their script
+    for i := range note.Fields {
+        if used[i] || text(i) == "" {
+            continue
+        }
+        if arabicIndex < 0 && isArabicText(text(i)) {
+            arabicIndex = i
+            used[i] = true
+        } else if englishIndex < 0 && !isArabicText(text(i)) {
+            englishIndex = i
+            used[i] = true
+        }
+    }
+
+    return text(arabicIndex), text(romanIndex), text(englishIndex), nil
+}
+
+// importAnkiReviews files imported review history under a completed session of the
+// Anki activity and replays it through scheduler, returning the session ID
+func importAnkiReviews(tx *sql.Tx, scheduler srs.Scheduler, groupID, userID int64, reviews []ankiReview) (int64, error) {
+    activityID, err := ensureAnkiActivity(tx)
+    if err != nil {
+        return 0, err
+    }
+
+    // The session spans the imported history
+    first, last := reviews[0].Time, reviews[0].Time
+    for _, review := range reviews {
+        if review.Time.Before(first) {
+            first = review.Time
+        }
+        if review.Time.After(last) {
+            last = review.Time
+        }
+    }
+
+    result, err := tx.Exec(`
+        INSERT INTO study_sessions (group_id, study_activity_id, user_id, status, created_at, ended_at, nonce)
+        VALUES (?, ?, ?, ?, ?, ?, lower(hex(randomblob(16))))`,
+        groupID, activityID, userScope(userID), SessionStatusCompleted,
+        first.Format(sqliteTimeFormat), last.Format(sqliteTimeFormat))
+    if err != nil {
+        logging.Errorf("Error creating Anki import session: %v", err)
+        return 0, err
+    }
+    sessionID, err := result.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+
+    for _, review := range reviews {
+        grade, _ := srs.GradeFromRating(ankiRatings[review.Ease-1])
+        _, err := tx.Exec(`
+            INSERT INTO word_review_items (
+                word_id, study_session_id, correct, grade, response_time_ms, created_at
+            )
+            VALUES (?, ?, ?, ?, ?, ?)`,
+            review.wordID, sessionID, grade >= srs.PassingGrade, grade,
+            review.Duration.Milliseconds(), review.Time.Format(sqliteTimeFormat))
+        if err != nil {
+            logging.Errorf("Error importing Anki review of word %d: %v", review.wordID, err)
+            return 0, err
+        }
+
+        if err := scheduleReview(tx, scheduler, userID, review.wordID, grade, review.Time); err != nil {
+            return 0, err
+        }
+    }
+
+    return sessionID, nil
+}
+
+// ensureAnkiActivity returns the ID of the Anki study activity, creating it if needed
+func ensureAnkiActivity(q queryer) (int64, error) {
+    var id int64
+    err := q.QueryRow("SELECT id FROM study_activities WHERE name = ? ORDER BY id LIMIT 1", ankiActivityName).Scan(&id)
+    if err == nil {
+        return id, nil
+    }
+    if err != sql.ErrNoRows {
+        logging.Errorf("Error finding Anki activity: %v", err)
+        return 0, err
+    }
+
+    result, err := q.Exec(`
+        INSERT INTO study_activities (name, thumbnail_url, description, launch_url)
+        VALUES (?, '', ?, '')`,
+        ankiActivityName, "Review history imported from Anki")
+    if err != nil {
+        logging.Errorf("Error creating Anki activity: %v", err)
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// ExportGroupAnki writes the words of a group as an Anki package with one deck
+// named after the group and an Arabic to English and English to Arabic card per
+// word. It returns a file name for the package.
+func (s *Service) ExportGroupAnki(groupID int64, w io.Writer) (string, error) {
+    db := s.db
+
+    var groupName string
+    err := db.QueryRow("SELECT name FROM groups WHERE id = ?", groupID).Scan(&groupName)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            logging.Errorf("Error getting group: %v", err)
+        }
+        return "", notFound(err, "group")
+    }
+
+    rows, err := db.Query(`
+        SELECT w.id, w.arabic, w.roman, w.english
+        FROM words w
+        JOIN words_groups wg ON wg.word_id = w.id
+        WHERE wg.group_id = ?
+        ORDER BY w.id`,
+        groupID)
+    if err != nil {
+        logging.Errorf("Error querying group words: %v", err)
+        return "", err
+    }
+    defer rows.Close()
+
+    deck := &anki.Deck{Name: groupName, NoteType: ankiNoteType}
+    for rows.Next() {
+        var id int64
+        var arabicText, roman, english string
+        if err := rows.Scan(&id, &arabicText, &roman, &english); err != nil {
+            logging.Errorf("Error scanning group word: %v", err)
+            return "", err
+        }
+        deck.Notes = append(deck.Notes, anki.ExportNote{
+            GUID:   anki.GUID(fmt.Sprintf("lang-portal/words/%d", id)),
+            Fields: []string{arabicText, roman, english},
+        })
+    }
+    if err := rows.Err(); err != nil {
+        return "", err
+    }
+
+    if err := anki.WritePackage(w, deck); err != nil {
+        logging.Errorf("Error writing Anki package for group %d: %v", groupID, err)
+        return "", err
+    }
+    return ankiExportFilename(groupName), nil
+}
+
+// ankiExportFilename is the download name of a group's Anki package
+func ankiExportFilename(groupName string) string {
+    name := strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`/\:*?"<>|`, r) {
+            return '_'
+        }
+        return r
+    }, groupName)
+    return strings.TrimSpace(name) + ".apkg"
+}
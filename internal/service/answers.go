@@ -0,0 +1,96 @@
+package service
+
+import (
+    "fmt"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/answer"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// CheckAnswerRequest represents a learner's raw answer to a word prompt
+type CheckAnswerRequest struct {
+    Answer         string `json:"answer" binding:"required"`
+    Direction      string `json:"direction"`
+    ResponseTimeMs *int64 `json:"response_time_ms"`
+}
+
+// CheckAnswerResponse represents the verdict on an answer and the review it recorded
+type CheckAnswerResponse struct {
+    Verdict   answer.Verdict            `json:"verdict"`
+    IsCorrect bool                      `json:"is_correct"`
+    Expected  string                    `json:"expected"`
+    Distance  int                       `json:"distance"`
+    Feedback  string                    `json:"feedback"`
+    Review    *CreateWordReviewResponse `json:"review"`
+}
+
+// CheckAnswer grades a learner's answer against the word and records it as a review.
+// The direction decides what is expected: English for arabic_to_english (the default),
+// otherwise Arabic, which may be typed in Arabic script or as a transliteration.
+func (s *Service) CheckAnswer(sessionID, wordID int64, req *CheckAnswerRequest) (*CheckAnswerResponse, error) {
+    direction := req.Direction
+    if direction == "" {
+        direction = DirectionArabicToEnglish
+    }
+    if !reviewDirections[direction] {
+        return nil, &ValidationError{
+            Entity:  "review",
+            Field:   "direction",
+            Message: "must be arabic_to_english, english_to_arabic or roman_to_arabic",
+        }
+    }
+
+    word, err := s.Words.GetFields(wordID)
+    if err != nil {
+        return nil, err
+    }
+
+    var result answer.Result
+    switch {
+    case direction == DirectionArabicToEnglish:
+        result = answer.Check(req.Answer, answer.Alternatives(word.English), answer.English)
+    case arabic.IsArabicScript(req.Answer):
+        result = answer.Check(req.Answer, answer.Alternatives(word.Arabic), arabic.Normalize)
+    default:
+        result = answer.Check(req.Answer, answer.Alternatives(word.Roman), arabic.NormalizeRoman)
+    }
+
+    response := &CheckAnswerResponse{
+        Verdict:  result.Verdict,
+        Expected: result.Expected,
+        Distance: result.Distance,
+    }
+
+    // Exact answers are perfect, answers that only differ in spelling conventions are good,
+    // and near misses are failed but graded above a complete miss
+    var grade ReviewGrade
+    switch {
+    case result.Verdict == answer.Correct && result.Exact:
+        grade = srs.GradePerfect
+        response.Feedback = "Correct!"
+    case result.Verdict == answer.Correct:
+        grade = srs.GradeGood
+        response.Feedback = fmt.Sprintf("Correct! The exact spelling is %q.", result.Expected)
+    case result.Verdict == answer.AlmostCorrect:
+        grade = srs.GradeFamiliar
+        response.Feedback = fmt.Sprintf("Almost, check your spelling: the answer is %q.", result.Expected)
+    default:
+        grade = srs.GradeIncorrect
+        response.Feedback = fmt.Sprintf("Not quite: the answer is %q.", result.Expected)
+    }
+
+    review, err := s.CreateWordReview(sessionID, wordID, &CreateWordReviewRequest{
+        Grade:          &grade,
+        Answer:         req.Answer,
+        Direction:      direction,
+        ResponseTimeMs: req.ResponseTimeMs,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    response.IsCorrect = review.IsCorrect
+    response.Review = review
+    return response, nil
+}
@@ -0,0 +1,1077 @@
+package service
+
+import (
+    "archive/zip"
+    "bufio"
+    "bytes"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// Formats accepted by ExportArchive
+const (
+    ArchiveFormatJSON = "json"
+    ArchiveFormatZip  = "zip"
+)
+
+// archiveName identifies learning record store archives and ArchiveVersion is the
+// version written by this release. RestoreArchive reads any version up to it.
+// Version 1 archives have no user roles, classes or assignments; their users are
+// restored as learners.
+const (
+    archiveName    = "lang-portal-archive"
+    ArchiveVersion = 2
+)
+
+// Archive tables in the order they are written and restored
+var archiveTables = []string{
+    "words",
+    "groups",
+    "words_groups",
+    "study_activities",
+    "users",
+    "classes",
+    "class_members",
+    "assignments",
+    "study_sessions",
+    "word_review_items",
+}
+
+// ArchiveTables returns the tables of an archive in the order they are restored
+func ArchiveTables() []string {
+    return append([]string(nil), archiveTables...)
+}
+
+// Archive is the complete learning record of the database. Word schedules are not
+// included because they are rebuilt from the review history on restore, and login
+// sessions are not included so that restoring never logs anyone in.
+type Archive struct {
+    Format          string                 `json:"format"`
+    Version         int                    `json:"version"`
+    ExportedAt      time.Time              `json:"exported_at"`
+    Words           []ArchiveWord          `json:"words"`
+    Groups          []ArchiveGroup         `json:"groups"`
+    WordsGroups     []ArchiveWordGroup     `json:"words_groups"`
+    StudyActivities []ArchiveStudyActivity `json:"study_activities"`
+    Users           []ArchiveUser          `json:"users,omitempty"`
+    Classes         []ArchiveClass         `json:"classes,omitempty"`
+    ClassMembers    []ArchiveClassMember   `json:"class_members,omitempty"`
+    Assignments     []ArchiveAssignment    `json:"assignments,omitempty"`
+    StudySessions   []ArchiveStudySession  `json:"study_sessions"`
+    WordReviewItems []ArchiveReview        `json:"word_review_items"`
+}
+
+// ArchiveManifest describes the NDJSON files of a zip archive
+type ArchiveManifest struct {
+    Format     string         `json:"format"`
+    Version    int            `json:"version"`
+    ExportedAt time.Time      `json:"exported_at"`
+    Counts     map[string]int `json:"counts"`
+}
+
+// ArchiveWord is a row of the words table
+type ArchiveWord struct {
+    ID      int64           `json:"id"`
+    Arabic  string          `json:"arabic"`
+    Roman   string          `json:"roman"`
+    English string          `json:"english"`
+    Parts   json.RawMessage `json:"parts,omitempty"`
+}
+
+// ArchiveGroup is a row of the groups table
+type ArchiveGroup struct {
+    ID   int64  `json:"id"`
+    Name string `json:"name"`
+}
+
+// ArchiveWordGroup is a row of the words_groups table
+type ArchiveWordGroup struct {
+    WordID  int64 `json:"word_id"`
+    GroupID int64 `json:"group_id"`
+}
+
+// ArchiveStudyActivity is a row of the study_activities table
+type ArchiveStudyActivity struct {
+    ID           int64   `json:"id"`
+    Name         string  `json:"name"`
+    ThumbnailURL *string `json:"thumbnail_url"`
+    Description  *string `json:"description"`
+    LaunchURL    *string `json:"launch_url"`
+}
+
+// ArchiveUser is a row of the users table
+type ArchiveUser struct {
+    ID           int64     `json:"id"`
+    Username     string    `json:"username"`
+    DisplayName  string    `json:"display_name"`
+    PasswordHash string    `json:"password_hash"`
+    Role         string    `json:"role"`
+    CreatedAt    time.Time `json:"created_at"`
+}
+
+// ArchiveClass is a row of the classes table
+type ArchiveClass struct {
+    ID        int64     `json:"id"`
+    Name      string    `json:"name"`
+    TeacherID int64     `json:"teacher_id"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchiveClassMember is a row of the class_members table
+type ArchiveClassMember struct {
+    ClassID  int64     `json:"class_id"`
+    UserID   int64     `json:"user_id"`
+    JoinedAt time.Time `json:"joined_at"`
+}
+
+// ArchiveAssignment is a row of the assignments table
+type ArchiveAssignment struct {
+    ID              int64     `json:"id"`
+    ClassID         int64     `json:"class_id"`
+    GroupID         int64     `json:"group_id"`
+    StudyActivityID int64     `json:"study_activity_id"`
+    DueAt           time.Time `json:"due_at"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// ArchiveStudySession is a row of the study_sessions table. UserID is absent for
+// sessions of the guest learner.
+type ArchiveStudySession struct {
+    ID              int64      `json:"id"`
+    UserID          *int64     `json:"user_id,omitempty"`
+    GroupID         int64      `json:"group_id"`
+    StudyActivityID int64      `json:"study_activity_id"`
+    Status          string     `json:"status"`
+    CreatedAt       time.Time  `json:"created_at"`
+    EndedAt         *time.Time `json:"ended_at"`
+}
+
+// ArchiveReview is a row of the word_review_items table
+type ArchiveReview struct {
+    ID             int64     `json:"id"`
+    WordID         int64     `json:"word_id"`
+    StudySessionID int64     `json:"study_session_id"`
+    Correct        bool      `json:"correct"`
+    Grade          *int      `json:"grade"`
+    Answer         *string   `json:"answer"`
+    Direction      *string   `json:"direction"`
+    ResponseTimeMs *int64    `json:"response_time_ms"`
+    CreatedAt      time.Time `json:"created_at"`
+}
+
+// Outcomes of restoring a single archive record
+const (
+    RestoreStatusInserted = "inserted"
+    RestoreStatusMerged   = "merged"
+    RestoreStatusSkipped  = "skipped"
+)
+
+// RestoreTableReport counts what happened to the records of one table
+type RestoreTableReport struct {
+    Inserted int `json:"inserted"`
+    Merged   int `json:"merged"`
+    Skipped  int `json:"skipped"`
+}
+
+// RestoreConflict reports an archive record that was merged into an existing row
+// or could not be restored
+type RestoreConflict struct {
+    Table      string `json:"table"`
+    ArchiveID  int64  `json:"archive_id,omitempty"`
+    Status     string `json:"status"`
+    ExistingID int64  `json:"existing_id,omitempty"`
+    Reason     string `json:"reason"`
+}
+
+// RestoreReport summarizes a restore. IDMap maps archive IDs to database IDs for
+// every table with an ID of its own.
+type RestoreReport struct {
+    Version   int                            `json:"version"`
+    Tables    map[string]*RestoreTableReport `json:"tables"`
+    Conflicts []RestoreConflict              `json:"conflicts"`
+    IDMap     map[string]map[int64]int64     `json:"id_map"`
+}
+
+// ExportArchive writes the whole learning record as a single JSON document or as a
+// zip of one NDJSON file per table plus a manifest.json
+func (s *Service) ExportArchive(w io.Writer, format string) error {
+    db := s.db
+
+    if format != ArchiveFormatJSON && format != ArchiveFormatZip {
+        return &ValidationError{Entity: "export", Field: "format", Message: "must be json or zip"}
+    }
+
+    archive, err := readArchive(db)
+    if err != nil {
+        return err
+    }
+
+    if format == ArchiveFormatJSON {
+        return json.NewEncoder(w).Encode(archive)
+    }
+    return writeArchiveZip(w, archive)
+}
+
+// readArchive reads every archived table in a single read transaction so the
+// archive is consistent
+func readArchive(db *sql.DB) (*Archive, error) {
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    archive := &Archive{
+        Format:          archiveName,
+        Version:         ArchiveVersion,
+        ExportedAt:      time.Now().UTC().Truncate(time.Second),
+        Words:           []ArchiveWord{},
+        Groups:          []ArchiveGroup{},
+        WordsGroups:     []ArchiveWordGroup{},
+        StudyActivities: []ArchiveStudyActivity{},
+        Users:           []ArchiveUser{},
+        Classes:         []ArchiveClass{},
+        ClassMembers:    []ArchiveClassMember{},
+        Assignments:     []ArchiveAssignment{},
+        StudySessions:   []ArchiveStudySession{},
+        WordReviewItems: []ArchiveReview{},
+    }
+
+    err = queryArchiveRows(tx, "SELECT id, arabic, roman, english, parts FROM words ORDER BY id", func(rows *sql.Rows) error {
+        var word ArchiveWord
+        var parts sql.NullString
+        if err := rows.Scan(&word.ID, &word.Arabic, &word.Roman, &word.English, &parts); err != nil {
+            return err
+        }
+        if parts.Valid {
+            word.Parts = json.RawMessage(parts.String)
+        }
+        archive.Words = append(archive.Words, word)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, "SELECT id, name FROM groups ORDER BY id", func(rows *sql.Rows) error {
+        var group ArchiveGroup
+        if err := rows.Scan(&group.ID, &group.Name); err != nil {
+            return err
+        }
+        archive.Groups = append(archive.Groups, group)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, "SELECT word_id, group_id FROM words_groups ORDER BY id", func(rows *sql.Rows) error {
+        var wordGroup ArchiveWordGroup
+        if err := rows.Scan(&wordGroup.WordID, &wordGroup.GroupID); err != nil {
+            return err
+        }
+        archive.WordsGroups = append(archive.WordsGroups, wordGroup)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT id, name, thumbnail_url, description, launch_url
+        FROM study_activities
+        ORDER BY id`, func(rows *sql.Rows) error {
+        var activity ArchiveStudyActivity
+        if err := rows.Scan(
+            &activity.ID,
+            &activity.Name,
+            &activity.ThumbnailURL,
+            &activity.Description,
+            &activity.LaunchURL,
+        ); err != nil {
+            return err
+        }
+        archive.StudyActivities = append(archive.StudyActivities, activity)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT id, username, display_name, password_hash, role, created_at
+        FROM users
+        ORDER BY id`, func(rows *sql.Rows) error {
+        var user ArchiveUser
+        if err := rows.Scan(
+            &user.ID,
+            &user.Username,
+            &user.DisplayName,
+            &user.PasswordHash,
+            &user.Role,
+            &user.CreatedAt,
+        ); err != nil {
+            return err
+        }
+        archive.Users = append(archive.Users, user)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, "SELECT id, name, teacher_id, created_at FROM classes ORDER BY id", func(rows *sql.Rows) error {
+        var class ArchiveClass
+        if err := rows.Scan(&class.ID, &class.Name, &class.TeacherID, &class.CreatedAt); err != nil {
+            return err
+        }
+        archive.Classes = append(archive.Classes, class)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT class_id, user_id, joined_at
+        FROM class_members
+        ORDER BY class_id, user_id`, func(rows *sql.Rows) error {
+        var member ArchiveClassMember
+        if err := rows.Scan(&member.ClassID, &member.UserID, &member.JoinedAt); err != nil {
+            return err
+        }
+        archive.ClassMembers = append(archive.ClassMembers, member)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT id, class_id, group_id, study_activity_id, due_at, created_at
+        FROM assignments
+        ORDER BY id`, func(rows *sql.Rows) error {
+        var assignment ArchiveAssignment
+        if err := rows.Scan(
+            &assignment.ID,
+            &assignment.ClassID,
+            &assignment.GroupID,
+            &assignment.StudyActivityID,
+            &assignment.DueAt,
+            &assignment.CreatedAt,
+        ); err != nil {
+            return err
+        }
+        archive.Assignments = append(archive.Assignments, assignment)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT id, user_id, group_id, study_activity_id, status, created_at, ended_at
+        FROM study_sessions
+        ORDER BY id`, func(rows *sql.Rows) error {
+        var session ArchiveStudySession
+        if err := rows.Scan(
+            &session.ID,
+            &session.UserID,
+            &session.GroupID,
+            &session.StudyActivityID,
+            &session.Status,
+            &session.CreatedAt,
+            &session.EndedAt,
+        ); err != nil {
+            return err
+        }
+        archive.StudySessions = append(archive.StudySessions, session)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = queryArchiveRows(tx, `
+        SELECT id, word_id, study_session_id, correct, grade, answer, direction, response_time_ms, created_at
+        FROM word_review_items
+        ORDER BY id`, func(rows *sql.Rows) error {
+        var review ArchiveReview
+        if err := rows.Scan(
+            &review.ID,
+            &review.WordID,
+            &review.StudySessionID,
+            &review.Correct,
+            &review.Grade,
+            &review.Answer,
+            &review.Direction,
+            &review.ResponseTimeMs,
+            &review.CreatedAt,
+        ); err != nil {
+            return err
+        }
+        archive.WordReviewItems = append(archive.WordReviewItems, review)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return archive, nil
+}
+
+func queryArchiveRows(q queryer, query string, scan func(rows *sql.Rows) error) error {
+    rows, err := q.Query(query)
+    if err != nil {
+        logging.Errorf("Error exporting records: %v", err)
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        if err := scan(rows); err != nil {
+            logging.Errorf("Error scanning exported record: %v", err)
+            return err
+        }
+    }
+    return rows.Err()
+}
+
+// archiveRecords returns the records of each table for the NDJSON files
+func (a *Archive) archiveRecords() map[string]interface{} {
+    return map[string]interface{}{
+        "words":             a.Words,
+        "groups":            a.Groups,
+        "words_groups":      a.WordsGroups,
+        "study_activities":  a.StudyActivities,
+        "users":             a.Users,
+        "classes":           a.Classes,
+        "class_members":     a.ClassMembers,
+        "assignments":       a.Assignments,
+        "study_sessions":    a.StudySessions,
+        "word_review_items": a.WordReviewItems,
+    }
+}
+
+func writeArchiveZip(w io.Writer, archive *Archive) error {
+    zw := zip.NewWriter(w)
+
+    manifest := ArchiveManifest{
+        Format:     archive.Format,
+        Version:    archive.Version,
+        ExportedAt: archive.ExportedAt,
+        Counts: map[string]int{
+            "words":             len(archive.Words),
+            "groups":            len(archive.Groups),
+            "words_groups":      len(archive.WordsGroups),
+            "study_activities":  len(archive.StudyActivities),
+            "users":             len(archive.Users),
+            "classes":           len(archive.Classes),
+            "class_members":     len(archive.ClassMembers),
+            "assignments":       len(archive.Assignments),
+            "study_sessions":    len(archive.StudySessions),
+            "word_review_items": len(archive.WordReviewItems),
+        },
+    }
+    file, err := zw.Create("manifest.json")
+    if err != nil {
+        return err
+    }
+    if err := json.NewEncoder(file).Encode(manifest); err != nil {
+        return err
+    }
+
+    records := archive.archiveRecords()
+    for _, table := range archiveTables {
+        file, err := zw.Create(table + ".ndjson")
+        if err != nil {
+            return err
+        }
+
+        // Encode the slice and write one element per line
+        var elements []json.RawMessage
+        encoded, err := json.Marshal(records[table])
+        if err != nil {
+            return err
+        }
+        if err := json.Unmarshal(encoded, &elements); err != nil {
+            return err
+        }
+        for _, element := range elements {
+            if _, err := file.Write(append(element, '\n')); err != nil {
+                return err
+            }
+        }
+    }
+
+    return zw.Close()
+}
+
+// parseArchive reads a JSON or zip archive, telling them apart by the zip signature
+func parseArchive(r io.Reader) (*Archive, error) {
+    br := bufio.NewReader(r)
+    signature, _ := br.Peek(4)
+    if !bytes.Equal(signature, []byte("PK\x03\x04")) {
+        var archive Archive
+        if err := json.NewDecoder(br).Decode(&archive); err != nil {
+            return nil, &ValidationError{Field: "file", Message: "not a JSON or zip archive: " + err.Error()}
+        }
+        if err := checkArchiveVersion(archive.Format, archive.Version); err != nil {
+            return nil, err
+        }
+        upgradeArchive(&archive)
+        return &archive, nil
+    }
+
+    data, err := io.ReadAll(br)
+    if err != nil {
+        return nil, err
+    }
+    zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+    if err != nil {
+        return nil, &ValidationError{Field: "file", Message: err.Error()}
+    }
+
+    files := make(map[string]*zip.File)
+    for _, file := range zr.File {
+        files[file.Name] = file
+    }
+    if files["manifest.json"] == nil {
+        return nil, &ValidationError{Field: "file", Message: "archive has no manifest.json"}
+    }
+
+    var manifest ArchiveManifest
+    if err := decodeZipFile(files["manifest.json"], func(dec *json.Decoder) error {
+        return dec.Decode(&manifest)
+    }); err != nil {
+        return nil, err
+    }
+    if err := checkArchiveVersion(manifest.Format, manifest.Version); err != nil {
+        return nil, err
+    }
+
+    archive := &Archive{Format: manifest.Format, Version: manifest.Version, ExportedAt: manifest.ExportedAt}
+    targets := map[string]interface{}{
+        "words":             &archive.Words,
+        "groups":            &archive.Groups,
+        "words_groups":      &archive.WordsGroups,
+        "study_activities":  &archive.StudyActivities,
+        "users":             &archive.Users,
+        "classes":           &archive.Classes,
+        "class_members":     &archive.ClassMembers,
+        "assignments":       &archive.Assignments,
+        "study_sessions":    &archive.StudySessions,
+        "word_review_items": &archive.WordReviewItems,
+    }
+    for _, table := range archiveTables {
+        file := files[table+".ndjson"]
+        if file == nil {
+            continue
+        }
+        // Collect the lines into a JSON array and decode it into the target slice
+        var lines []json.RawMessage
+        err := decodeZipFile(file, func(dec *json.Decoder) error {
+            for dec.More() {
+                var line json.RawMessage
+                if err := dec.Decode(&line); err != nil {
+                    return err
+                }
+                lines = append(lines, line)
+            }
+            return nil
+        })
+        if err != nil {
+            return nil, err
+        }
+        encoded, err := json.Marshal(lines)
+        if err != nil {
+            return nil, err
+        }
+        if err := json.Unmarshal(encoded, targets[table]); err != nil {
+            return nil, &ValidationError{Field: table, Message: err.Error()}
+        }
+    }
+
+    upgradeArchive(archive)
+    return archive, nil
+}
+
+// upgradeArchive brings an archive of an earlier version up to ArchiveVersion
+func upgradeArchive(archive *Archive) {
+    if archive.Version < 2 {
+        // Roles, classes and assignments were not archived, so every user is
+        // restored as a learner
+        for i := range archive.Users {
+            archive.Users[i].Role = RoleLearner
+        }
+        archive.Classes = nil
+        archive.ClassMembers = nil
+        archive.Assignments = nil
+    }
+}
+
+func decodeZipFile(file *zip.File, decode func(dec *json.Decoder) error) error {
+    rc, err := file.Open()
+    if err != nil {
+        return &ValidationError{Field: file.Name, Message: err.Error()}
+    }
+    defer rc.Close()
+
+    if err := decode(json.NewDecoder(rc)); err != nil {
+        return &ValidationError{Field: file.Name, Message: err.Error()}
+    }
+    return nil
+}
+
+func checkArchiveVersion(format string, version int) error {
+    if format != archiveName {
+        return &ValidationError{Field: "format", Message: fmt.Sprintf("must be %q", archiveName)}
+    }
+    if version < 1 || version > ArchiveVersion {
+        return &ValidationError{
+            Field:   "version",
+            Message: fmt.Sprintf("archive version %d is not supported, expected 1 to %d", version, ArchiveVersion),
+        }
+    }
+    return nil
+}
+
+// RestoreArchive restores a JSON or zip archive into the database in a single
+// transaction, assigning new IDs to every restored record. Records matching an
+// existing row are merged into it instead: words with the same Arabic and English,
+// groups and study activities with the same name, users with the same username,
+// classes of the same teacher with the same name, assignments of the same group
+// and activity to the same class with the same due date, sessions of the same
+// learner with the same group, activity and start time, and reviews of the same
+// word in the same session at the same time. Records referring to something that
+// was not restored are skipped. Schedules of words that gained reviews are rebuilt
+// from their full history.
+func (s *Service) RestoreArchive(r io.Reader) (*RestoreReport, error) {
+    db := s.db
+
+    archive, err := parseArchive(r)
+    if err != nil {
+        return nil, invalid(err, "archive")
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    report := &RestoreReport{
+        Version:   archive.Version,
+        Tables:    make(map[string]*RestoreTableReport),
+        Conflicts: []RestoreConflict{},
+        IDMap:     make(map[string]map[int64]int64),
+    }
+    for _, table := range archiveTables {
+        report.Tables[table] = &RestoreTableReport{}
+    }
+    for _, table := range []string{"words", "groups", "study_activities", "users", "classes", "assignments", "study_sessions", "word_review_items"} {
+        report.IDMap[table] = make(map[int64]int64)
+    }
+
+    // inserted records a new row and maps its archive ID
+    inserted := func(table string, archiveID int64, result sql.Result) error {
+        id, err := result.LastInsertId()
+        if err != nil {
+            return err
+        }
+        report.IDMap[table][archiveID] = id
+        report.Tables[table].Inserted++
+        return nil
+    }
+    merged := func(table string, archiveID, existingID int64, reason string) {
+        report.IDMap[table][archiveID] = existingID
+        report.Tables[table].Merged++
+        if reason != "" {
+            report.Conflicts = append(report.Conflicts, RestoreConflict{
+                Table:      table,
+                ArchiveID:  archiveID,
+                Status:     RestoreStatusMerged,
+                ExistingID: existingID,
+                Reason:     reason,
+            })
+        }
+    }
+    skipped := func(table string, archiveID int64, reason string) {
+        report.Tables[table].Skipped++
+        report.Conflicts = append(report.Conflicts, RestoreConflict{
+            Table:     table,
+            ArchiveID: archiveID,
+            Status:    RestoreStatusSkipped,
+            Reason:    reason,
+        })
+    }
+
+    for _, word := range archive.Words {
+        if word.Arabic == "" || word.English == "" {
+            skipped("words", word.ID, "arabic and english are required")
+            continue
+        }
+        var parts sql.NullString
+        if len(word.Parts) > 0 && string(word.Parts) != "null" {
+            if !json.Valid(word.Parts) {
+                skipped("words", word.ID, "parts is not valid JSON")
+                continue
+            }
+            parts = sql.NullString{String: string(word.Parts), Valid: true}
+        }
+
+        existingID, err := findDuplicateWord(tx, word.Arabic, word.English, 0)
+        if err != nil {
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("words", word.ID, existingID, "a word with the same arabic and english already exists")
+            continue
+        }
+
+        result, err := tx.Exec(`
+            INSERT INTO words (arabic, roman, english, parts, arabic_normalized, roman_normalized)
+            VALUES (?, ?, ?, ?, ?, ?)`,
+            word.Arabic, word.Roman, word.English, parts,
+            arabic.Normalize(word.Arabic), arabic.NormalizeRoman(word.Roman))
+        if err != nil {
+            logging.Errorf("Error restoring word %d: %v", word.ID, err)
+            return nil, err
+        }
+        if err := inserted("words", word.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, group := range archive.Groups {
+        existingID, err := findGroupByName(tx, group.Name, 0)
+        if err != nil {
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("groups", group.ID, existingID, "a group with the same name already exists")
+            continue
+        }
+
+        result, err := tx.Exec("INSERT INTO groups (name) VALUES (?)", group.Name)
+        if err != nil {
+            logging.Errorf("Error restoring group %d: %v", group.ID, err)
+            return nil, err
+        }
+        if err := inserted("groups", group.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, wordGroup := range archive.WordsGroups {
+        wordID, wordOK := report.IDMap["words"][wordGroup.WordID]
+        groupID, groupOK := report.IDMap["groups"][wordGroup.GroupID]
+        if !wordOK || !groupOK {
+            skipped("words_groups", 0, fmt.Sprintf(
+                "word %d or group %d was not restored", wordGroup.WordID, wordGroup.GroupID))
+            continue
+        }
+
+        result, err := tx.Exec(
+            "INSERT OR IGNORE INTO words_groups (word_id, group_id) VALUES (?, ?)",
+            wordID, groupID)
+        if err != nil {
+            logging.Errorf("Error restoring word %d in group %d: %v", wordID, groupID, err)
+            return nil, err
+        }
+        if affected, err := result.RowsAffected(); err != nil {
+            return nil, err
+        } else if affected == 0 {
+            report.Tables["words_groups"].Merged++
+        } else {
+            report.Tables["words_groups"].Inserted++
+        }
+    }
+
+    for _, activity := range archive.StudyActivities {
+        var existingID int64
+        err := tx.QueryRow("SELECT id FROM study_activities WHERE name = ? ORDER BY id LIMIT 1", activity.Name).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up study activity: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("study_activities", activity.ID, existingID, "a study activity with the same name already exists")
+            continue
+        }
+
+        result, err := tx.Exec(`
+            INSERT INTO study_activities (name, thumbnail_url, description, launch_url)
+            VALUES (?, ?, ?, ?)`,
+            activity.Name, activity.ThumbnailURL, activity.Description, activity.LaunchURL)
+        if err != nil {
+            logging.Errorf("Error restoring study activity %d: %v", activity.ID, err)
+            return nil, err
+        }
+        if err := inserted("study_activities", activity.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, user := range archive.Users {
+        if !usernamePattern.MatchString(user.Username) || user.PasswordHash == "" {
+            skipped("users", user.ID, "a valid username and a password hash are required")
+            continue
+        }
+        if user.Role != RoleLearner && user.Role != RoleTeacher {
+            skipped("users", user.ID, "role must be learner or teacher")
+            continue
+        }
+
+        var existingID int64
+        err := tx.QueryRow("SELECT id FROM users WHERE username = ?", user.Username).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up user: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("users", user.ID, existingID, "a user with the same username already exists")
+            continue
+        }
+
+        displayName := user.DisplayName
+        if displayName == "" {
+            displayName = user.Username
+        }
+        result, err := tx.Exec(`
+            INSERT INTO users (username, display_name, password_hash, role, created_at)
+            VALUES (?, ?, ?, ?, ?)`,
+            user.Username, displayName, user.PasswordHash, user.Role, user.CreatedAt.UTC().Format(sqliteTimeFormat))
+        if err != nil {
+            logging.Errorf("Error restoring user %d: %v", user.ID, err)
+            return nil, err
+        }
+        if err := inserted("users", user.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, class := range archive.Classes {
+        teacherID, ok := report.IDMap["users"][class.TeacherID]
+        if !ok {
+            skipped("classes", class.ID, fmt.Sprintf("teacher %d was not restored", class.TeacherID))
+            continue
+        }
+        if class.Name == "" {
+            skipped("classes", class.ID, "name is required")
+            continue
+        }
+
+        var existingID int64
+        err := tx.QueryRow(
+            "SELECT id FROM classes WHERE teacher_id = ? AND name = ? ORDER BY id LIMIT 1",
+            teacherID, class.Name).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up class: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("classes", class.ID, existingID, "")
+            continue
+        }
+
+        result, err := tx.Exec(
+            "INSERT INTO classes (name, teacher_id, created_at) VALUES (?, ?, ?)",
+            class.Name, teacherID, class.CreatedAt.UTC().Format(sqliteTimeFormat))
+        if err != nil {
+            logging.Errorf("Error restoring class %d: %v", class.ID, err)
+            return nil, err
+        }
+        if err := inserted("classes", class.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, member := range archive.ClassMembers {
+        classID, classOK := report.IDMap["classes"][member.ClassID]
+        userID, userOK := report.IDMap["users"][member.UserID]
+        if !classOK || !userOK {
+            skipped("class_members", 0, fmt.Sprintf(
+                "class %d or user %d was not restored", member.ClassID, member.UserID))
+            continue
+        }
+
+        result, err := tx.Exec(
+            "INSERT OR IGNORE INTO class_members (class_id, user_id, joined_at) VALUES (?, ?, ?)",
+            classID, userID, member.JoinedAt.UTC().Format(sqliteTimeFormat))
+        if err != nil {
+            logging.Errorf("Error restoring user %d in class %d: %v", userID, classID, err)
+            return nil, err
+        }
+        if affected, err := result.RowsAffected(); err != nil {
+            return nil, err
+        } else if affected == 0 {
+            report.Tables["class_members"].Merged++
+        } else {
+            report.Tables["class_members"].Inserted++
+        }
+    }
+
+    for _, assignment := range archive.Assignments {
+        classID, classOK := report.IDMap["classes"][assignment.ClassID]
+        groupID, groupOK := report.IDMap["groups"][assignment.GroupID]
+        activityID, activityOK := report.IDMap["study_activities"][assignment.StudyActivityID]
+        if !classOK || !groupOK || !activityOK {
+            skipped("assignments", assignment.ID, fmt.Sprintf(
+                "class %d, group %d or study activity %d was not restored",
+                assignment.ClassID, assignment.GroupID, assignment.StudyActivityID))
+            continue
+        }
+
+        dueAt := assignment.DueAt.UTC().Format(sqliteTimeFormat)
+        var existingID int64
+        err := tx.QueryRow(`
+            SELECT id
+            FROM assignments
+            WHERE class_id = ? AND group_id = ? AND study_activity_id = ? AND due_at = ?
+            LIMIT 1`,
+            classID, groupID, activityID, dueAt).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up assignment: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("assignments", assignment.ID, existingID, "")
+            continue
+        }
+
+        result, err := tx.Exec(`
+            INSERT INTO assignments (class_id, group_id, study_activity_id, due_at, created_at)
+            VALUES (?, ?, ?, ?, ?)`,
+            classID, groupID, activityID, dueAt, assignment.CreatedAt.UTC().Format(sqliteTimeFormat))
+        if err != nil {
+            logging.Errorf("Error restoring assignment %d: %v", assignment.ID, err)
+            return nil, err
+        }
+        if err := inserted("assignments", assignment.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, session := range archive.StudySessions {
+        groupID, groupOK := report.IDMap["groups"][session.GroupID]
+        activityID, activityOK := report.IDMap["study_activities"][session.StudyActivityID]
+        if !groupOK || !activityOK {
+            skipped("study_sessions", session.ID, fmt.Sprintf(
+                "group %d or study activity %d was not restored", session.GroupID, session.StudyActivityID))
+            continue
+        }
+        var userID interface{}
+        if session.UserID != nil {
+            id, ok := report.IDMap["users"][*session.UserID]
+            if !ok {
+                skipped("study_sessions", session.ID, fmt.Sprintf("user %d was not restored", *session.UserID))
+                continue
+            }
+            userID = id
+        }
+
+        createdAt := session.CreatedAt.UTC().Format(sqliteTimeFormat)
+        var existingID int64
+        err := tx.QueryRow(`
+            SELECT id
+            FROM study_sessions
+            WHERE group_id = ? AND study_activity_id = ? AND created_at = ? AND user_id IS ?
+            LIMIT 1`,
+            groupID, activityID, createdAt, userID).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up study session: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("study_sessions", session.ID, existingID, "")
+            continue
+        }
+
+        status := session.Status
+        if status == "" {
+            status = SessionStatusCompleted
+        }
+        var endedAt sql.NullString
+        if session.EndedAt != nil {
+            endedAt = sql.NullString{String: session.EndedAt.UTC().Format(sqliteTimeFormat), Valid: true}
+        }
+
+        result, err := tx.Exec(`
+            INSERT INTO study_sessions (user_id, group_id, study_activity_id, status, created_at, ended_at, nonce)
+            VALUES (?, ?, ?, ?, ?, ?, lower(hex(randomblob(16))))`,
+            userID, groupID, activityID, status, createdAt, endedAt)
+        if err != nil {
+            logging.Errorf("Error restoring study session %d: %v", session.ID, err)
+            return nil, err
+        }
+        if err := inserted("study_sessions", session.ID, result); err != nil {
+            return nil, err
+        }
+    }
+
+    reviewedWords := make(map[int64]bool)
+    for _, review := range archive.WordReviewItems {
+        wordID, wordOK := report.IDMap["words"][review.WordID]
+        sessionID, sessionOK := report.IDMap["study_sessions"][review.StudySessionID]
+        if !wordOK || !sessionOK {
+            skipped("word_review_items", review.ID, fmt.Sprintf(
+                "word %d or study session %d was not restored", review.WordID, review.StudySessionID))
+            continue
+        }
+
+        createdAt := review.CreatedAt.UTC().Format(sqliteTimeFormat)
+        var existingID int64
+        err := tx.QueryRow(`
+            SELECT id
+            FROM word_review_items
+            WHERE word_id = ? AND study_session_id = ? AND created_at = ?
+            LIMIT 1`,
+            wordID, sessionID, createdAt).Scan(&existingID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error looking up word review: %v", err)
+            return nil, err
+        }
+        if existingID != 0 {
+            merged("word_review_items", review.ID, existingID, "")
+            continue
+        }
+
+        result, err := tx.Exec(`
+            INSERT INTO word_review_items (
+                word_id, study_session_id, correct, grade, answer, direction, response_time_ms, created_at
+            )
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+            wordID, sessionID, review.Correct, review.Grade, review.Answer,
+            review.Direction, review.ResponseTimeMs, createdAt)
+        if err != nil {
+            logging.Errorf("Error restoring word review %d: %v", review.ID, err)
+            return nil, err
+        }
+        if err := inserted("word_review_items", review.ID, result); err != nil {
+            return nil, err
+        }
+        reviewedWords[wordID] = true
+    }
+
+    for wordID := range reviewedWords {
+        if err := rebuildSchedule(tx, s.opts.Scheduler, wordID); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return report, nil
+}
@@ -0,0 +1,647 @@
+package service
+
+import (
+    "database/sql"
+    "errors"
+    "math"
+    "strings"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
+)
+
+// ClassRequest represents the request body for creating a class
+type ClassRequest struct {
+    Name string `json:"name" binding:"required"`
+}
+
+// ClassResponse represents a class with its member and assignment counts
+type ClassResponse struct {
+    ID              int64     `json:"id"`
+    Name            string    `json:"name"`
+    TeacherID       int64     `json:"teacher_id"`
+    MemberCount     int       `json:"member_count"`
+    AssignmentCount int       `json:"assignment_count"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// ClassDetailResponse represents a class with its members
+type ClassDetailResponse struct {
+    ClassResponse
+    Members []User `json:"members"`
+}
+
+// ClassMembersRequest represents a batch of learners to add to or remove from a class
+type ClassMembersRequest struct {
+    Usernames []string `json:"usernames" binding:"required,min=1"`
+}
+
+// ClassMembersResponse reports what happened to each learner in a membership batch
+type ClassMembersResponse struct {
+    ClassID     int64    `json:"class_id"`
+    Added       []string `json:"added,omitempty"`
+    Removed     []string `json:"removed,omitempty"`
+    Unchanged   []string `json:"unchanged"`
+    NotFound    []string `json:"not_found"`
+    MemberCount int      `json:"member_count"`
+}
+
+// AssignmentRequest represents the request body for assigning a group to a class
+type AssignmentRequest struct {
+    GroupID         int64     `json:"group_id" binding:"required"`
+    StudyActivityID int64     `json:"study_activity_id" binding:"required"`
+    DueAt           time.Time `json:"due_at" binding:"required"`
+}
+
+// AssignmentResponse represents an assignment of a group and study activity to a class
+type AssignmentResponse struct {
+    ID              int64     `json:"id"`
+    ClassID         int64     `json:"class_id"`
+    ClassName       string    `json:"class_name"`
+    GroupID         int64     `json:"group_id"`
+    GroupName       string    `json:"group_name"`
+    StudyActivityID int64     `json:"study_activity_id"`
+    ActivityName    string    `json:"activity_name"`
+    DueAt           time.Time `json:"due_at"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// OpenAssignmentResponse represents an assignment a learner has not completed yet
+type OpenAssignmentResponse struct {
+    AssignmentResponse
+    Overdue bool `json:"overdue"`
+    // SessionCount counts the sessions the learner has started for the assignment
+    SessionCount int `json:"session_count"`
+}
+
+// AssignmentProgress reports a learner's completion and accuracy on an assignment
+type AssignmentProgress struct {
+    UserID       int64             `json:"user_id"`
+    Username     string            `json:"username"`
+    DisplayName  string            `json:"display_name"`
+    Completed    bool              `json:"completed"`
+    CompletedAt  *time.Time        `json:"completed_at,omitempty"`
+    Late         bool              `json:"late"`
+    SessionCount int               `json:"session_count"`
+    Stats        StudySessionStats `json:"stats"`
+    AccuracyRate float64           `json:"accuracy_rate"`
+}
+
+// AssignmentReportResponse reports the progress of every member of the assigned class
+type AssignmentReportResponse struct {
+    AssignmentResponse
+    CompletedCount int                  `json:"completed_count"`
+    CompletionRate float64              `json:"completion_rate"`
+    Learners       []AssignmentProgress `json:"learners"`
+}
+
+// assignmentSelectSQL selects the columns of an AssignmentResponse from assignments a
+const assignmentSelectSQL = `
+        SELECT
+            a.id,
+            a.class_id,
+            c.name as class_name,
+            a.group_id,
+            g.name as group_name,
+            a.study_activity_id,
+            sa.name as activity_name,
+            a.due_at,
+            a.created_at
+        FROM assignments a
+        JOIN classes c ON a.class_id = c.id
+        JOIN groups g ON a.group_id = g.id
+        JOIN study_activities sa ON a.study_activity_id = sa.id`
+
+// assignmentSessionJoinSQL joins the sessions ss of learner u that count towards
+// assignment a: sessions of the assigned group and activity started after it was assigned
+const assignmentSessionJoinSQL = `
+        LEFT JOIN study_sessions ss ON ss.user_id = u.id
+            AND ss.group_id = a.group_id
+            AND ss.study_activity_id = a.study_activity_id
+            AND julianday(ss.created_at) >= julianday(a.created_at)`
+
+func scanAssignment(row interface{ Scan(...interface{}) error }, a *AssignmentResponse) error {
+    return row.Scan(
+        &a.ID,
+        &a.ClassID,
+        &a.ClassName,
+        &a.GroupID,
+        &a.GroupName,
+        &a.StudyActivityID,
+        &a.ActivityName,
+        &a.DueAt,
+        &a.CreatedAt,
+    )
+}
+
+// checkClassTeacher makes sure a class exists and is taught by the user. It returns
+// ErrTeacherRequired for learners and a NotFoundError for classes of other
+// teachers, so they cannot tell which classes exist.
+func checkClassTeacher(q queryer, classID, teacherID int64) error {
+    teacher, err := isTeacher(q, teacherID)
+    if err != nil {
+        return err
+    }
+    if !teacher {
+        return ErrTeacherRequired
+    }
+
+    var owned bool
+    err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM classes WHERE id = ? AND teacher_id = ?)", classID, teacherID).Scan(&owned)
+    if err != nil {
+        logging.Errorf("Error checking class %d: %v", classID, err)
+        return err
+    }
+    if !owned {
+        return &NotFoundError{Entity: "class"}
+    }
+    return nil
+}
+
+// CreateClass creates an empty class taught by a teacher
+func (s *Service) CreateClass(teacherID int64, req *ClassRequest) (*ClassDetailResponse, error) {
+    db := s.db
+
+    teacher, err := isTeacher(db, teacherID)
+    if err != nil {
+        return nil, err
+    }
+    if !teacher {
+        return nil, ErrTeacherRequired
+    }
+
+    name := strings.TrimSpace(req.Name)
+    if name == "" {
+        return nil, &ValidationError{Field: "name", Message: "must not be empty"}
+    }
+
+    result, err := db.Exec(`
+        INSERT INTO classes (name, teacher_id, created_at)
+        VALUES (?, ?, CURRENT_TIMESTAMP)`,
+        name, teacherID)
+    if err != nil {
+        logging.Errorf("Error creating class: %v", err)
+        return nil, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return nil, err
+    }
+
+    return s.GetClass(id, teacherID)
+}
+
+// GetClasses returns a paginated list of the classes a teacher teaches
+func (s *Service) GetClasses(teacherID int64, page, perPage int) ([]ClassResponse, *models.Pagination, error) {
+    db := s.db
+
+    teacher, err := isTeacher(db, teacherID)
+    if err != nil {
+        return nil, nil, err
+    }
+    if !teacher {
+        return nil, nil, ErrTeacherRequired
+    }
+
+    offset := (page - 1) * perPage
+
+    var total int
+    err = db.QueryRow("SELECT COUNT(*) FROM classes WHERE teacher_id = ?", teacherID).Scan(&total)
+    if err != nil {
+        logging.Errorf("Error counting classes: %v", err)
+        return nil, nil, err
+    }
+
+    rows, err := db.Query(`
+        SELECT
+            c.id,
+            c.name,
+            c.teacher_id,
+            (SELECT COUNT(*) FROM class_members cm WHERE cm.class_id = c.id) as member_count,
+            (SELECT COUNT(*) FROM assignments a WHERE a.class_id = c.id) as assignment_count,
+            c.created_at
+        FROM classes c
+        WHERE c.teacher_id = ?
+        ORDER BY c.name
+        LIMIT ? OFFSET ?`,
+        teacherID, perPage, offset)
+    if err != nil {
+        logging.Errorf("Error querying classes: %v", err)
+        return nil, nil, err
+    }
+    defer rows.Close()
+
+    classes := []ClassResponse{}
+    for rows.Next() {
+        var class ClassResponse
+        if err := rows.Scan(
+            &class.ID,
+            &class.Name,
+            &class.TeacherID,
+            &class.MemberCount,
+            &class.AssignmentCount,
+            &class.CreatedAt,
+        ); err != nil {
+            logging.Errorf("Error scanning class: %v", err)
+            return nil, nil, err
+        }
+        classes = append(classes, class)
+    }
+
+    pagination := &models.Pagination{
+        CurrentPage:  page,
+        ItemsPerPage: perPage,
+        TotalItems:   total,
+        TotalPages:   (total + perPage - 1) / perPage,
+    }
+
+    return classes, pagination, nil
+}
+
+// GetClass returns a class taught by a teacher with its members
+func (s *Service) GetClass(id, teacherID int64) (*ClassDetailResponse, error) {
+    db := s.db
+
+    if err := checkClassTeacher(db, id, teacherID); err != nil {
+        return nil, err
+    }
+
+    var class ClassDetailResponse
+    err := db.QueryRow(`
+        SELECT
+            c.id,
+            c.name,
+            c.teacher_id,
+            (SELECT COUNT(*) FROM class_members cm WHERE cm.class_id = c.id) as member_count,
+            (SELECT COUNT(*) FROM assignments a WHERE a.class_id = c.id) as assignment_count,
+            c.created_at
+        FROM classes c
+        WHERE c.id = ?`,
+        id).Scan(
+        &class.ID,
+        &class.Name,
+        &class.TeacherID,
+        &class.MemberCount,
+        &class.AssignmentCount,
+        &class.CreatedAt,
+    )
+    if err != nil {
+        logging.Errorf("Error getting class %d: %v", id, err)
+        return nil, err
+    }
+
+    rows, err := db.Query(`
+        SELECT u.id, u.username, u.display_name, u.role, u.created_at
+        FROM class_members cm
+        JOIN users u ON cm.user_id = u.id
+        WHERE cm.class_id = ?
+        ORDER BY u.username`,
+        id)
+    if err != nil {
+        logging.Errorf("Error querying members of class %d: %v", id, err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    class.Members = []User{}
+    for rows.Next() {
+        var user User
+        if err := rows.Scan(&user.ID, &user.Username, &user.DisplayName, &user.Role, &user.CreatedAt); err != nil {
+            logging.Errorf("Error scanning class member: %v", err)
+            return nil, err
+        }
+        class.Members = append(class.Members, user)
+    }
+    if err := rows.Err(); err != nil {
+        logging.Errorf("Error reading class members: %v", err)
+        return nil, err
+    }
+
+    return &class, nil
+}
+
+// AddClassMembers adds a batch of learners to a class by username in a single
+// transaction. Learners who are already members or do not exist are reported rather
+// than treated as errors.
+func (s *Service) AddClassMembers(classID, teacherID int64, usernames []string) (*ClassMembersResponse, error) {
+    return s.changeClassMembers(classID, teacherID, usernames, true)
+}
+
+// RemoveClassMembers removes a batch of learners from a class in a single transaction
+func (s *Service) RemoveClassMembers(classID, teacherID int64, usernames []string) (*ClassMembersResponse, error) {
+    return s.changeClassMembers(classID, teacherID, usernames, false)
+}
+
+func (s *Service) changeClassMembers(classID, teacherID int64, usernames []string, add bool) (*ClassMembersResponse, error) {
+    db := s.db
+
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    if err := checkClassTeacher(tx, classID, teacherID); err != nil {
+        return nil, err
+    }
+
+    response := &ClassMembersResponse{
+        ClassID:   classID,
+        Unchanged: []string{},
+        NotFound:  []string{},
+    }
+    seen := make(map[string]bool)
+
+    for _, username := range usernames {
+        username = strings.TrimSpace(username)
+        if seen[strings.ToLower(username)] {
+            continue
+        }
+        seen[strings.ToLower(username)] = true
+
+        var userID int64
+        err := tx.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&userID)
+        if err == sql.ErrNoRows {
+            response.NotFound = append(response.NotFound, username)
+            continue
+        }
+        if err != nil {
+            logging.Errorf("Error looking up user: %v", err)
+            return nil, err
+        }
+
+        var result sql.Result
+        if add {
+            result, err = tx.Exec(`
+                INSERT OR IGNORE INTO class_members (class_id, user_id, joined_at)
+                VALUES (?, ?, CURRENT_TIMESTAMP)`,
+                classID, userID)
+        } else {
+            result, err = tx.Exec(
+                "DELETE FROM class_members WHERE class_id = ? AND user_id = ?",
+                classID, userID)
+        }
+        if err != nil {
+            logging.Errorf("Error changing membership of user %d in class %d: %v", userID, classID, err)
+            return nil, err
+        }
+
+        changed, err := result.RowsAffected()
+        if err != nil {
+            return nil, err
+        }
+        switch {
+        case changed == 0:
+            response.Unchanged = append(response.Unchanged, username)
+        case add:
+            response.Added = append(response.Added, username)
+        default:
+            response.Removed = append(response.Removed, username)
+        }
+    }
+
+    err = tx.QueryRow("SELECT COUNT(*) FROM class_members WHERE class_id = ?", classID).Scan(&response.MemberCount)
+    if err != nil {
+        logging.Errorf("Error counting class members: %v", err)
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return response, nil
+}
+
+// CreateAssignment assigns a group to be studied with a study activity by every
+// member of a class before a due date
+func (s *Service) CreateAssignment(classID, teacherID int64, req *AssignmentRequest) (*AssignmentResponse, error) {
+    db := s.db
+
+    if err := checkClassTeacher(db, classID, teacherID); err != nil {
+        return nil, err
+    }
+    if !req.DueAt.After(time.Now()) {
+        return nil, &ValidationError{Field: "due_at", Message: "must be in the future"}
+    }
+
+    groupExists, err := s.Groups.Exists(req.GroupID)
+    if err != nil {
+        return nil, err
+    }
+    if !groupExists {
+        return nil, &NotFoundError{Entity: "group"}
+    }
+
+    activityExists, err := s.Activities.Exists(req.StudyActivityID)
+    if err != nil {
+        return nil, err
+    }
+    if !activityExists {
+        return nil, &NotFoundError{Entity: "activity"}
+    }
+
+    result, err := db.Exec(`
+        INSERT INTO assignments (class_id, group_id, study_activity_id, due_at, created_at)
+        VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+        classID, req.GroupID, req.StudyActivityID, req.DueAt.UTC().Format(sqliteTimeFormat))
+    if err != nil {
+        logging.Errorf("Error creating assignment: %v", err)
+        return nil, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return nil, err
+    }
+
+    var assignment AssignmentResponse
+    if err := scanAssignment(db.QueryRow(assignmentSelectSQL+" WHERE a.id = ?", id), &assignment); err != nil {
+        logging.Errorf("Error getting created assignment: %v", err)
+        return nil, err
+    }
+
+    return &assignment, nil
+}
+
+// GetClassAssignments returns the assignments of a class taught by a teacher, latest
+// due date first
+func (s *Service) GetClassAssignments(classID, teacherID int64) ([]AssignmentResponse, error) {
+    db := s.db
+
+    if err := checkClassTeacher(db, classID, teacherID); err != nil {
+        return nil, err
+    }
+
+    rows, err := db.Query(assignmentSelectSQL+`
+        WHERE a.class_id = ?
+        ORDER BY a.due_at DESC, a.id DESC`,
+        classID)
+    if err != nil {
+        logging.Errorf("Error querying assignments of class %d: %v", classID, err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    assignments := []AssignmentResponse{}
+    for rows.Next() {
+        var assignment AssignmentResponse
+        if err := scanAssignment(rows, &assignment); err != nil {
+            logging.Errorf("Error scanning assignment: %v", err)
+            return nil, err
+        }
+        assignments = append(assignments, assignment)
+    }
+
+    return assignments, nil
+}
+
+// GetOpenAssignments returns the assignments of a learner's classes that the learner
+// has not completed yet, soonest due first. An assignment is completed by completing a
+// study session of its group and activity started after it was assigned.
+func (s *Service) GetOpenAssignments(userID int64) ([]OpenAssignmentResponse, error) {
+    db := s.db
+
+    rows, err := db.Query(assignmentSelectSQL+`
+        JOIN class_members cm ON cm.class_id = a.class_id
+        JOIN users u ON u.id = cm.user_id`+assignmentSessionJoinSQL+`
+        WHERE u.id = ?
+        GROUP BY a.id
+        HAVING SUM(CASE WHEN ss.status = ? THEN 1 ELSE 0 END) = 0
+        ORDER BY a.due_at, a.id`,
+        userID, SessionStatusCompleted)
+    if err != nil {
+        logging.Errorf("Error querying open assignments: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    now := time.Now().UTC()
+    assignments := []OpenAssignmentResponse{}
+    for rows.Next() {
+        var assignment OpenAssignmentResponse
+        if err := scanAssignment(rows, &assignment.AssignmentResponse); err != nil {
+            logging.Errorf("Error scanning assignment: %v", err)
+            return nil, err
+        }
+        assignment.Overdue = assignment.DueAt.Before(now)
+        assignments = append(assignments, assignment)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    // Count the sessions the learner has started for each open assignment
+    for i := range assignments {
+        a := &assignments[i]
+        err := db.QueryRow(`
+            SELECT COUNT(*)
+            FROM study_sessions
+            WHERE user_id = ? AND group_id = ? AND study_activity_id = ?
+            AND julianday(created_at) >= julianday(?)`,
+            userID, a.GroupID, a.StudyActivityID, a.CreatedAt.UTC().Format(sqliteTimeFormat)).Scan(&a.SessionCount)
+        if err != nil {
+            logging.Errorf("Error counting sessions of assignment %d: %v", a.ID, err)
+            return nil, err
+        }
+    }
+
+    return assignments, nil
+}
+
+// GetAssignmentReport reports the completion and accuracy of every member of the
+// class an assignment was given to. Accuracy covers all reviews in the learner's
+// sessions for the assignment.
+func (s *Service) GetAssignmentReport(id, teacherID int64) (*AssignmentReportResponse, error) {
+    db := s.db
+
+    var report AssignmentReportResponse
+    err := scanAssignment(db.QueryRow(assignmentSelectSQL+" WHERE a.id = ?", id), &report.AssignmentResponse)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "assignment"}
+        }
+        logging.Errorf("Error getting assignment %d: %v", id, err)
+        return nil, err
+    }
+    if err := checkClassTeacher(db, report.ClassID, teacherID); err != nil {
+        if errors.Is(err, ErrNotFound) {
+            return nil, &NotFoundError{Entity: "assignment"}
+        }
+        return nil, err
+    }
+
+    rows, err := db.Query(`
+        SELECT
+            u.id,
+            u.username,
+            u.display_name,
+            COUNT(DISTINCT ss.id) as session_count,
+            MIN(CASE WHEN ss.status = ? THEN ss.ended_at END) as completed_at,
+            `+sessionStatsSQL+`
+        FROM assignments a
+        JOIN class_members cm ON cm.class_id = a.class_id
+        JOIN users u ON u.id = cm.user_id`+assignmentSessionJoinSQL+`
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+        WHERE a.id = ?
+        GROUP BY u.id
+        ORDER BY u.username`,
+        SessionStatusCompleted, id)
+    if err != nil {
+        logging.Errorf("Error querying progress of assignment %d: %v", id, err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    report.Learners = []AssignmentProgress{}
+    for rows.Next() {
+        var progress AssignmentProgress
+        var completedAt sql.NullString
+        if err := rows.Scan(
+            &progress.UserID,
+            &progress.Username,
+            &progress.DisplayName,
+            &progress.SessionCount,
+            &completedAt,
+            &progress.Stats.TotalWords,
+            &progress.Stats.CorrectCount,
+            &progress.Stats.WrongCount,
+        ); err != nil {
+            logging.Errorf("Error scanning assignment progress: %v", err)
+            return nil, err
+        }
+
+        // MIN loses the column type, so the timestamp comes back as text
+        if completedAt.Valid {
+            t, err := time.Parse(sqliteTimeFormat, completedAt.String)
+            if err != nil {
+                return nil, err
+            }
+            progress.Completed = true
+            progress.CompletedAt = &t
+            progress.Late = t.After(report.DueAt)
+            report.CompletedCount++
+        }
+        if reviews := progress.Stats.CorrectCount + progress.Stats.WrongCount; reviews > 0 {
+            progress.AccuracyRate = percentage(progress.Stats.CorrectCount, reviews)
+        }
+        report.Learners = append(report.Learners, progress)
+    }
+
+    if len(report.Learners) > 0 {
+        report.CompletionRate = percentage(report.CompletedCount, len(report.Learners))
+    }
+
+    return &report, nil
+}
+
+// percentage returns part of total as a percentage rounded to one decimal
+func percentage(part, total int) float64 {
+    return math.Round(float64(part)*1000/float64(total)) / 10
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+    "bytes"
+    "encoding/base64"
+    "encoding/json"
+    "strconv"
+    "strings"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
+)
+
+// listCursor is a position in a sorted list, next to one of its items. Clients get
+// it as an opaque token, which stays valid while items are added and removed.
+type listCursor struct {
+    // Sort and Order are those of the list the cursor was issued for
+    Sort  string `json:"s"`
+    Order string `json:"o"`
+    // Key and ID are the sort key and tiebreak of the item
+    Key interface{} `json:"k"`
+    ID  int64       `json:"i"`
+    // Before points to the items before the item rather than after it
+    Before bool `json:"b,omitempty"`
+}
+
+// listKey is the sort key and tiebreak of an item, as read by a list query
+type listKey struct {
+    Key interface{}
+    ID  int64
+}
+
+// encode returns the token of c
+func (c *listCursor) encode() *string {
+    // Keys are plain SQLite values, which always marshal
+    data, _ := json.Marshal(c)
+    token := base64.RawURLEncoding.EncodeToString(data)
+    return &token
+}
+
+// decodeCursor reads the token of a listCursor
+func decodeCursor(token string) (*listCursor, error) {
+    invalid := &ValidationError{Entity: "list_query", Field: "cursor", Message: "is not a cursor of this list"}
+
+    data, err := base64.RawURLEncoding.DecodeString(token)
+    if err != nil {
+        return nil, invalid
+    }
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    var c listCursor
+    if err := decoder.Decode(&c); err != nil {
+        return nil, invalid
+    }
+
+    // Compare integer keys as integers, like SQLite returned them
+    if number, ok := c.Key.(json.Number); ok {
+        if c.Key, err = number.Int64(); err != nil {
+            if c.Key, err = number.Float64(); err != nil {
+                return nil, invalid
+            }
+        }
+    }
+    switch c.Key.(type) {
+    case nil, string, int64, float64:
+    default:
+        return nil, invalid
+    }
+    return &c, nil
+}
+
+// listPage reads one page of a list, after or before a cursor. Unlike pages read
+// with an offset, it neither skips nor repeats items that were added or removed
+// in front of it, and its cost does not grow with the length of the list.
+type listPage struct {
+    spec   *listSpec
+    field  string
+    order  string
+    cursor *listCursor
+    limit  int
+}
+
+// page prepares reading limit items from the position of a cursor token, or from
+// the start of the list when it is empty. opts must be accepted by validate.
+func (l *listSpec) page(opts ListOptions, token string, limit int) (*listPage, error) {
+    p := &listPage{spec: l, limit: limit}
+    p.field, p.order = l.sortOf(opts)
+    if token == "" {
+        return p, nil
+    }
+
+    cursor, err := decodeCursor(token)
+    if err != nil {
+        return nil, err
+    }
+    if cursor.Sort != p.field || cursor.Order != p.order {
+        return nil, &ValidationError{Entity: "list_query", Field: "cursor", Message: "was issued for another sort or order"}
+    }
+    p.cursor = cursor
+    return p, nil
+}
+
+// keyColumns selects the sort key and tiebreak of each item. List queries add
+// them after their own columns and scan them into a listKey.
+func (p *listPage) keyColumns() string {
+    return ",\n            " + p.spec.sorts[p.field] + " AS sort_key,\n            " + p.spec.tiebreak + " AS sort_id"
+}
+
+// backward reports whether the page is read from its end
+func (p *listPage) backward() bool {
+    return p.cursor != nil && p.cursor.Before
+}
+
+// clauses returns the clauses of the list query with opts, limited to the items
+// beyond the cursor and sorted in the direction they are read
+func (p *listPage) clauses(opts ListOptions) *listClauses {
+    c := p.spec.clauses(opts)
+
+    order := p.order
+    if p.backward() {
+        order = map[string]string{"asc": "desc", "desc": "asc"}[order]
+    }
+    c.orderBy = p.spec.orderBy(p.field, order)
+    if p.cursor == nil {
+        return c
+    }
+
+    // SQLite sorts NULL keys before all others
+    key, id := "("+p.spec.sorts[p.field]+")", p.spec.tiebreak
+    var condition string
+    var args []interface{}
+    switch {
+    case order == "asc" && p.cursor.Key == nil:
+        condition = "((" + key + " IS NULL AND " + id + " > ?) OR " + key + " IS NOT NULL)"
+        args = []interface{}{p.cursor.ID}
+    case order == "asc":
+        condition = "(" + key + " > ? OR (" + key + " = ? AND " + id + " > ?))"
+        args = []interface{}{p.cursor.Key, p.cursor.Key, p.cursor.ID}
+    case p.cursor.Key == nil:
+        condition = "(" + key + " IS NULL AND " + id + " < ?)"
+        args = []interface{}{p.cursor.ID}
+    default:
+        condition = "(" + key + " < ? OR (" + key + " = ? AND " + id + " < ?) OR " + key + " IS NULL)"
+        args = []interface{}{p.cursor.Key, p.cursor.Key, p.cursor.ID}
+    }
+
+    if p.spec.grouped {
+        c.having = append(c.having, condition)
+        c.havingArgs = append(c.havingArgs, args...)
+    } else {
+        c.conditions = append(c.conditions, condition)
+        c.whereArgs = append(c.whereArgs, args...)
+    }
+    return c
+}
+
+// query limits a list query built with clauses to the page and one more item,
+// which tells whether the list goes on. Its rows come in list order, so that a
+// page read backward starts with the extra item.
+func (p *listPage) query(query string) string {
+    query += " LIMIT " + strconv.Itoa(p.limit+1)
+    if !p.backward() {
+        return query
+    }
+    order := strings.ToUpper(p.order)
+    return "SELECT * FROM (" + query + ") ORDER BY sort_key " + order + ", sort_id " + order
+}
+
+// result returns which of the rows read with query are on the page, given their
+// keys, and the cursors of the pages before and after it
+func (p *listPage) result(keys []listKey) (from, to int, pagination *models.CursorPagination) {
+    from, to = 0, len(keys)
+    more := len(keys) > p.limit
+    if more && p.backward() {
+        from = 1
+    } else if more {
+        to = p.limit
+    }
+
+    pagination = &models.CursorPagination{ItemsPerPage: p.limit}
+    if from == to {
+        return from, to, pagination
+    }
+    first, last := keys[from], keys[to-1]
+    if (p.backward() && more) || (!p.backward() && p.cursor != nil) {
+        pagination.PrevCursor = p.cursorAt(first, true)
+    }
+    if p.backward() || more {
+        pagination.NextCursor = p.cursorAt(last, false)
+    }
+    return from, to, pagination
+}
+
+// cursorAt returns the cursor before or after the item with key
+func (p *listPage) cursorAt(key listKey, before bool) *string {
+    c := &listCursor{Sort: p.field, Order: p.order, Key: key.Key, ID: key.ID, Before: before}
+    return c.encode()
+}
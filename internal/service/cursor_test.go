@@ -0,0 +1,153 @@
+package service
+
+import (
+    "encoding/base64"
+    "errors"
+    "reflect"
+    "testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+    tests := []struct {
+        name   string
+        cursor listCursor
+    }{
+        {"integer key", listCursor{Sort: "id", Order: "asc", Key: int64(42), ID: 42}},
+        {"large integer key", listCursor{Sort: "id", Order: "desc", Key: int64(1) << 60, ID: 7}},
+        {"float key", listCursor{Sort: "accuracy", Order: "desc", Key: 66.5, ID: 3}},
+        {"string key", listCursor{Sort: "arabic", Order: "asc", Key: "كتاب", ID: 9}},
+        {"null key", listCursor{Sort: "accuracy", Order: "asc", Key: nil, ID: 12}},
+        {"before", listCursor{Sort: "english", Order: "asc", Key: "book", ID: 5, Before: true}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := decodeCursor(*tt.cursor.encode())
+            if err != nil {
+                t.Fatalf("decodeCursor() error = %v", err)
+            }
+            if !reflect.DeepEqual(*got, tt.cursor) {
+                t.Errorf("decodeCursor() = %#v, want %#v", *got, tt.cursor)
+            }
+        })
+    }
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+    encode := func(json string) string {
+        return base64.RawURLEncoding.EncodeToString([]byte(json))
+    }
+
+    tests := []struct {
+        name  string
+        token string
+    }{
+        {"not base64", "%%%"},
+        {"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","o":"asc","k":1,"i":1}`))},
+        {"not JSON", encode("id:1")},
+        {"truncated JSON", encode(`{"s":"id","o":"asc","k":1`)},
+        {"object key", encode(`{"s":"id","o":"asc","k":{"x":1},"i":1}`)},
+        {"array key", encode(`{"s":"id","o":"asc","k":[1],"i":1}`)},
+        {"boolean key", encode(`{"s":"id","o":"asc","k":true,"i":1}`)},
+        {"number out of range", encode(`{"s":"id","o":"asc","k":1e999,"i":1}`)},
+        {"string ID", encode(`{"s":"id","o":"asc","k":1,"i":"1"}`)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := decodeCursor(tt.token)
+            var validation *ValidationError
+            if !errors.As(err, &validation) || validation.Field != "cursor" {
+                t.Errorf("decodeCursor(%q) error = %v, want a cursor ValidationError", tt.token, err)
+            }
+        })
+    }
+}
+
+func TestPageChecksCursorList(t *testing.T) {
+    issued := func(sort, order string) string {
+        return *(&listCursor{Sort: sort, Order: order, Key: int64(3), ID: 3}).encode()
+    }
+
+    tests := []struct {
+        name    string
+        opts    ListOptions
+        token   string
+        wantErr bool
+    }{
+        {"no cursor", ListOptions{}, "", false},
+        {"default sort", ListOptions{}, issued("id", "asc"), false},
+        {"same sort", ListOptions{Sort: "english", Order: "desc"}, issued("english", "desc"), false},
+        {"other sort", ListOptions{Sort: "english"}, issued("id", "asc"), true},
+        {"other order", ListOptions{Sort: "id", Order: "desc"}, issued("id", "asc"), true},
+        {"tampered", ListOptions{}, "not-a-cursor", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := wordListSpec.page(tt.opts, tt.token, 10)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("page() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestPageResult(t *testing.T) {
+    keys := func(ids ...int64) []listKey {
+        var k []listKey
+        for _, id := range ids {
+            k = append(k, listKey{Key: id, ID: id})
+        }
+        return k
+    }
+    cursorOf := func(id int64, before bool) *listCursor {
+        return &listCursor{Sort: "id", Order: "asc", Key: id, ID: id, Before: before}
+    }
+
+    tests := []struct {
+        name     string
+        cursor   *listCursor
+        keys     []listKey
+        from, to int
+        prev     *listCursor
+        next     *listCursor
+    }{
+        {"first page", nil, keys(1, 2, 3), 0, 2, nil, cursorOf(2, false)},
+        {"only page", nil, keys(1, 2), 0, 2, nil, nil},
+        {"empty", nil, nil, 0, 0, nil, nil},
+        {"middle page", cursorOf(2, false), keys(3, 4, 5), 0, 2, cursorOf(3, true), cursorOf(4, false)},
+        {"last page", cursorOf(2, false), keys(3), 0, 1, cursorOf(3, true), nil},
+        {"page read backward", cursorOf(5, true), keys(2, 3, 4), 1, 3, cursorOf(3, true), cursorOf(4, false)},
+        {"first page read backward", cursorOf(3, true), keys(1, 2), 0, 2, nil, cursorOf(2, false)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := &listPage{spec: wordListSpec, field: "id", order: "asc", cursor: tt.cursor, limit: 2}
+            from, to, pagination := p.result(tt.keys)
+            if from != tt.from || to != tt.to {
+                t.Errorf("result() rows = %d..%d, want %d..%d", from, to, tt.from, tt.to)
+            }
+            checkCursor(t, "PrevCursor", pagination.PrevCursor, tt.prev)
+            checkCursor(t, "NextCursor", pagination.NextCursor, tt.next)
+        })
+    }
+}
+
+func checkCursor(t *testing.T, name string, token *string, want *listCursor) {
+    t.Helper()
+    if token == nil || want == nil {
+        if token != nil || want != nil {
+            t.Errorf("%s = %v, want %v", name, token, want)
+        }
+        return
+    }
+    got, err := decodeCursor(*token)
+    if err != nil {
+        t.Fatalf("%s does not decode: %v", name, err)
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("%s = %#v, want %#v", name, got, want)
+    }
+}
@@ -2,19 +2,21 @@ package service
 
 import (
     "database/sql"
-    "fmt"
-    "log"
     "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
 )
 
 // LastStudySessionResponse represents the last study session with stats
 type LastStudySessionResponse struct {
-    ID           int64      `json:"id"`
-    ActivityName string     `json:"activity_name"`
-    GroupName    string     `json:"group_name"`
-    StartTime    time.Time  `json:"start_time"`
-    EndTime      *time.Time `json:"end_time,omitempty"`
-    Stats        struct {
+    ID              int64      `json:"id"`
+    ActivityName    string     `json:"activity_name"`
+    GroupName       string     `json:"group_name"`
+    Status          string     `json:"status"`
+    StartTime       time.Time  `json:"start_time"`
+    EndTime         *time.Time `json:"end_time,omitempty"`
+    DurationSeconds int64      `json:"duration_seconds"`
+    Stats           struct {
         TotalWords   int `json:"total_words"`
         CorrectCount int `json:"correct_count"`
         WrongCount   int `json:"wrong_count"`
@@ -26,28 +28,44 @@ type DailyStats struct {
     Date         string  `json:"date"`
     CorrectCount int     `json:"correct_count"`
     WrongCount   int     `json:"wrong_count"`
+    AverageGrade float64 `json:"average_grade"`
 }
 
 // TotalStats represents overall study statistics
 type TotalStats struct {
-    TotalWordsStudied int     `json:"total_words_studied"`
-    TotalCorrect      int     `json:"total_correct"`
-    TotalWrong        int     `json:"total_wrong"`
-    AccuracyRate      float64 `json:"accuracy_rate"`
+    TotalWordsStudied     int     `json:"total_words_studied"`
+    TotalCorrect          int     `json:"total_correct"`
+    TotalWrong            int     `json:"total_wrong"`
+    AccuracyRate          float64 `json:"accuracy_rate"`
+    AverageGrade          float64 `json:"average_grade"`
+    AverageResponseTimeMs float64 `json:"average_response_time_ms"`
+}
+
+// DirectionStats represents review statistics for one prompt direction
+type DirectionStats struct {
+    Direction             string  `json:"direction"`
+    CorrectCount          int     `json:"correct_count"`
+    WrongCount            int     `json:"wrong_count"`
+    AccuracyRate          float64 `json:"accuracy_rate"`
+    AverageGrade          float64 `json:"average_grade"`
+    AverageResponseTimeMs float64 `json:"average_response_time_ms"`
 }
 
 // StudyProgressResponse represents the complete study progress
 type StudyProgressResponse struct {
-    DailyStats []DailyStats `json:"daily_stats"`
-    TotalStats TotalStats   `json:"total_stats"`
+    DailyStats     []DailyStats     `json:"daily_stats"`
+    TotalStats     TotalStats       `json:"total_stats"`
+    DirectionStats []DirectionStats `json:"direction_stats"`
 }
 
 // QuickStatsLastSession represents the last study session summary
 type QuickStatsLastSession struct {
-    ActivityName string `json:"activity_name"`
-    GroupName    string `json:"group_name"`
-    CorrectCount int    `json:"correct_count"`
-    WrongCount   int    `json:"wrong_count"`
+    ActivityName    string `json:"activity_name"`
+    GroupName       string `json:"group_name"`
+    Status          string `json:"status"`
+    DurationSeconds int64  `json:"duration_seconds"`
+    CorrectCount    int    `json:"correct_count"`
+    WrongCount      int    `json:"wrong_count"`
 }
 
 // QuickStatsResponse represents the dashboard quick statistics
@@ -58,12 +76,9 @@ type QuickStatsResponse struct {
     LastStudySession       QuickStatsLastSession `json:"last_study_session"`
 }
 
-// GetLastStudySession returns the most recent study session with stats
-func GetLastStudySession() (*LastStudySessionResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
+// GetLastStudySession returns a learner's most recent study session with stats
+func (s *Service) GetLastStudySession(userID int64) (*LastStudySessionResponse, error) {
+    db := s.db
 
     var session LastStudySessionResponse
     err := db.QueryRow(`
@@ -71,7 +86,10 @@ func GetLastStudySession() (*LastStudySessionResponse, error) {
             ss.id,
             sa.name as activity_name,
             g.name as group_name,
+            ss.status,
             ss.created_at as start_time,
+            ss.ended_at as end_time,
+            ` + sessionDurationSQL + ` as duration_seconds,
             (
                 SELECT COUNT(DISTINCT wri.word_id)
                 FROM word_review_items wri
@@ -90,53 +108,63 @@ func GetLastStudySession() (*LastStudySessionResponse, error) {
         FROM study_sessions ss
         JOIN study_activities sa ON ss.study_activity_id = sa.id
         JOIN groups g ON ss.group_id = g.id
+        WHERE ss.user_id IS ?
         ORDER BY ss.created_at DESC
         LIMIT 1`,
-    ).Scan(
+        userScope(userID)).Scan(
         &session.ID,
         &session.ActivityName,
         &session.GroupName,
+        &session.Status,
         &session.StartTime,
+        &session.EndTime,
+        &session.DurationSeconds,
         &session.Stats.TotalWords,
         &session.Stats.CorrectCount,
         &session.Stats.WrongCount,
     )
+    if err == sql.ErrNoRows {
+        return nil, ErrNoStudySessions
+    }
     if err != nil {
-        log.Printf("Error getting last study session: %v", err)
+        logging.Errorf("Error getting last study session: %v", err)
         return nil, err
     }
 
     return &session, nil
 }
 
-// GetStudyProgress returns the study progress over time
-func GetStudyProgress() (*StudyProgressResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
+// GetStudyProgress returns a learner's study progress over time
+func (s *Service) GetStudyProgress(userID int64) (*StudyProgressResponse, error) {
+    db := s.db
 
     // Get daily stats for the last 7 days
     rows, err := db.Query(`
         SELECT 
-            DATE(created_at) as study_date,
-            SUM(CASE WHEN correct = 1 THEN 1 ELSE 0 END) as correct_count,
-            SUM(CASE WHEN correct = 0 THEN 1 ELSE 0 END) as wrong_count
-        FROM word_review_items
-        WHERE created_at >= date('now', '-7 days')
-        GROUP BY DATE(created_at)
-        ORDER BY study_date DESC`)
+            DATE(wri.created_at) as study_date,
+            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
+            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count,
+            COALESCE(AVG(wri.grade), 0) as average_grade
+        FROM word_review_items wri
+        JOIN study_sessions ss ON wri.study_session_id = ss.id
+        WHERE wri.created_at >= date('now', '-7 days') AND ss.user_id IS ?
+        GROUP BY DATE(wri.created_at)
+        ORDER BY study_date DESC`,
+        userScope(userID))
     if err != nil {
-        log.Printf("Error getting daily stats: %v", err)
+        logging.Errorf("Error getting daily stats: %v", err)
         return nil, err
     }
     defer rows.Close()
 
-    var response StudyProgressResponse
+    response := StudyProgressResponse{
+        DailyStats:     []DailyStats{},
+        DirectionStats: []DirectionStats{},
+    }
     for rows.Next() {
         var stats DailyStats
-        if err := rows.Scan(&stats.Date, &stats.CorrectCount, &stats.WrongCount); err != nil {
-            log.Printf("Error scanning daily stats: %v", err)
+        if err := rows.Scan(&stats.Date, &stats.CorrectCount, &stats.WrongCount, &stats.AverageGrade); err != nil {
+            logging.Errorf("Error scanning daily stats: %v", err)
             return nil, err
         }
         response.DailyStats = append(response.DailyStats, stats)
@@ -145,15 +173,22 @@ func GetStudyProgress() (*StudyProgressResponse, error) {
     // Get total stats
     err = db.QueryRow(`
         SELECT 
-            COUNT(DISTINCT word_id) as total_words,
-            SUM(CASE WHEN correct = 1 THEN 1 ELSE 0 END) as total_correct,
-            SUM(CASE WHEN correct = 0 THEN 1 ELSE 0 END) as total_wrong
-        FROM word_review_items`).Scan(
+            COUNT(DISTINCT wri.word_id) as total_words,
+            COALESCE(SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END), 0) as total_correct,
+            COALESCE(SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END), 0) as total_wrong,
+            COALESCE(AVG(wri.grade), 0) as average_grade,
+            COALESCE(AVG(wri.response_time_ms), 0) as average_response_time_ms
+        FROM word_review_items wri
+        JOIN study_sessions ss ON wri.study_session_id = ss.id
+        WHERE ss.user_id IS ?`,
+        userScope(userID)).Scan(
         &response.TotalStats.TotalWordsStudied,
         &response.TotalStats.TotalCorrect,
-        &response.TotalStats.TotalWrong)
+        &response.TotalStats.TotalWrong,
+        &response.TotalStats.AverageGrade,
+        &response.TotalStats.AverageResponseTimeMs)
     if err != nil {
-        log.Printf("Error getting total stats: %v", err)
+        logging.Errorf("Error getting total stats: %v", err)
         return nil, err
     }
 
@@ -163,38 +198,78 @@ func GetStudyProgress() (*StudyProgressResponse, error) {
         response.TotalStats.AccuracyRate = float64(response.TotalStats.TotalCorrect) / float64(totalAttempts) * 100
     }
 
+    // Get stats per prompt direction for reviews that recorded one
+    rows, err = db.Query(`
+        SELECT 
+            wri.direction,
+            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
+            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count,
+            COALESCE(AVG(wri.grade), 0) as average_grade,
+            COALESCE(AVG(wri.response_time_ms), 0) as average_response_time_ms
+        FROM word_review_items wri
+        JOIN study_sessions ss ON wri.study_session_id = ss.id
+        WHERE wri.direction IS NOT NULL AND ss.user_id IS ?
+        GROUP BY wri.direction
+        ORDER BY wri.direction`,
+        userScope(userID))
+    if err != nil {
+        logging.Errorf("Error getting direction stats: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var stats DirectionStats
+        if err := rows.Scan(
+            &stats.Direction,
+            &stats.CorrectCount,
+            &stats.WrongCount,
+            &stats.AverageGrade,
+            &stats.AverageResponseTimeMs,
+        ); err != nil {
+            logging.Errorf("Error scanning direction stats: %v", err)
+            return nil, err
+        }
+        if attempts := stats.CorrectCount + stats.WrongCount; attempts > 0 {
+            stats.AccuracyRate = float64(stats.CorrectCount) / float64(attempts) * 100
+        }
+        response.DirectionStats = append(response.DirectionStats, stats)
+    }
+
     return &response, nil
 }
 
-// GetQuickStats returns a quick overview of learning progress
-func GetQuickStats() (*QuickStatsResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
+// GetQuickStats returns a quick overview of a learner's progress
+func (s *Service) GetQuickStats(userID int64) (*QuickStatsResponse, error) {
+    db := s.db
 
     var stats QuickStatsResponse
 
     // Get total words available
     err := db.QueryRow("SELECT COUNT(*) FROM words").Scan(&stats.TotalWordsAvailable)
     if err != nil {
-        log.Printf("Error counting total words: %v", err)
+        logging.Errorf("Error counting total words: %v", err)
         return nil, err
     }
 
     // Get number of unique words studied
     err = db.QueryRow(`
-        SELECT COUNT(DISTINCT word_id) 
-        FROM word_review_items`).Scan(&stats.WordsStudied)
+        SELECT COUNT(DISTINCT wri.word_id) 
+        FROM word_review_items wri
+        JOIN study_sessions ss ON wri.study_session_id = ss.id
+        WHERE ss.user_id IS ?`,
+        userScope(userID)).Scan(&stats.WordsStudied)
     if err != nil {
-        log.Printf("Error counting studied words: %v", err)
+        logging.Errorf("Error counting studied words: %v", err)
         return nil, err
     }
 
     // Get total study sessions completed
-    err = db.QueryRow("SELECT COUNT(*) FROM study_sessions").Scan(&stats.StudySessionsCompleted)
+    err = db.QueryRow(
+        "SELECT COUNT(*) FROM study_sessions WHERE status = ? AND user_id IS ?",
+        SessionStatusCompleted, userScope(userID)).Scan(&stats.StudySessionsCompleted)
     if err != nil {
-        log.Printf("Error counting study sessions: %v", err)
+        logging.Errorf("Error counting study sessions: %v", err)
         return nil, err
     }
 
@@ -203,6 +278,8 @@ func GetQuickStats() (*QuickStatsResponse, error) {
         SELECT 
             sa.name as activity_name,
             g.name as group_name,
+            ss.status,
+            ` + sessionDurationSQL + ` as duration_seconds,
             (
                 SELECT COUNT(*) 
                 FROM word_review_items wri 
@@ -216,16 +293,19 @@ func GetQuickStats() (*QuickStatsResponse, error) {
         FROM study_sessions ss
         JOIN study_activities sa ON ss.study_activity_id = sa.id
         JOIN groups g ON ss.group_id = g.id
+        WHERE ss.user_id IS ?
         ORDER BY ss.created_at DESC
         LIMIT 1
-    `).Scan(
+    `, userScope(userID)).Scan(
         &stats.LastStudySession.ActivityName,
         &stats.LastStudySession.GroupName,
+        &stats.LastStudySession.Status,
+        &stats.LastStudySession.DurationSeconds,
         &stats.LastStudySession.CorrectCount,
         &stats.LastStudySession.WrongCount,
     )
     if err != nil && err != sql.ErrNoRows {
-        log.Printf("Error getting last session stats: %v", err)
+        logging.Errorf("Error getting last session stats: %v", err)
         return nil, err
     }
 
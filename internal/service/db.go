@@ -2,39 +2,194 @@ package service
 
 import (
 	"database/sql"
-	"log"
+	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/minhalzubairi/lang-portal/backend-go/db/migrations"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
 )
 
-// DB is the global database instance
-var DB *sql.DB
+// queryer is implemented by both *sql.DB and *sql.Tx so helpers can run inside or outside a transaction
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Connection settings applied by OpenDB. WAL lets readers work while a review is
+// written, writers wait up to busyTimeout for each other instead of failing, and
+// transactions take the write lock when they begin so two of them cannot deadlock
+// upgrading their read locks.
+const (
+	busyTimeout  = 5 * time.Second
+	maxOpenConns = 8
+)
+
+// OpenDB opens the SQLite database at dbPath in WAL mode with foreign keys
+// enforced, and checks the connection
+func OpenDB(dbPath string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=%d&_foreign_keys=on&_txlock=immediate",
+		dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// The settings above are made per connection, so keep connections around
+	// rather than reopening them
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Word search needs FTS5, which go-sqlite3 only compiles in on request
+	var fts5 bool
+	if err = db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if !fts5 {
+		db.Close()
+		return nil, fmt.Errorf("SQLite was built without FTS5; build with -tags sqlite_fts5")
+	}
 
-// InitDB initializes the database connection
-func InitDB(dbPath string) error {
-	var err error
-	DB, err = sql.Open("sqlite3", dbPath)
+	logging.Infof("Database connection established")
+	return db, nil
+}
+
+// InitDB opens the SQLite database at dbPath and brings its schema up to date with
+// the migrations built into the binary. With migrate unset the schema is only
+// checked, and pending migrations are logged instead of applied. Either way a
+// database with edited or unknown migrations is refused.
+func InitDB(dbPath string, migrate bool) (*sql.DB, error) {
+	db, err := OpenDB(dbPath)
 	if err != nil {
+		return nil, err
+	}
+
+	if !migrate {
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if len(pending) > 0 {
+			logging.Warnf("%d migrations are pending (%s); start with migrations enabled to apply them",
+				len(pending), strings.Join(pending, ", "))
+		}
+	} else {
+		applied, err := migrations.Apply(db)
+		for _, name := range applied {
+			logging.Infof("Applied migration: %s", name)
+		}
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	if err := checkForeignKeys(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := normalizeStoredWords(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// normalizeStoredWords fills in the normalized Arabic and transliteration of words
+// stored without them, as by other SQLite clients, or normalized differently by an
+// earlier version. The search index follows through its update trigger. Databases
+// whose schema predates the normalized columns are left alone.
+func normalizeStoredWords(db *sql.DB) error {
+	var hasColumns bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM pragma_table_info('words') WHERE name = 'arabic_normalized')").Scan(&hasColumns)
+	if err != nil || !hasColumns {
 		return err
 	}
 
-	if err = DB.Ping(); err != nil {
+	rows, err := db.Query("SELECT id, arabic, roman, arabic_normalized, roman_normalized FROM words")
+	if err != nil {
+		logging.Errorf("Error reading words to normalize: %v", err)
+		return err
+	}
+	type normalized struct {
+		id            int64
+		arabic, roman string
+	}
+	var changed []normalized
+	for rows.Next() {
+		var id int64
+		var arabicText, roman, storedArabic, storedRoman string
+		if err := rows.Scan(&id, &arabicText, &roman, &storedArabic, &storedRoman); err != nil {
+			rows.Close()
+			logging.Errorf("Error scanning word to normalize: %v", err)
+			return err
+		}
+		word := normalized{id, arabic.Normalize(arabicText), arabic.NormalizeRoman(roman)}
+		if word.arabic != storedArabic || word.roman != storedRoman {
+			changed = append(changed, word)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logging.Errorf("Error reading words to normalize: %v", err)
 		return err
 	}
+	if len(changed) == 0 {
+		return nil
+	}
 
-	log.Println("Database connection established")
+	tx, err := db.Begin()
+	if err != nil {
+		logging.Errorf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+	for _, word := range changed {
+		_, err := tx.Exec("UPDATE words SET arabic_normalized = ?, roman_normalized = ? WHERE id = ?",
+			word.arabic, word.roman, word.id)
+		if err != nil {
+			logging.Errorf("Error normalizing word %d: %v", word.id, err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		logging.Errorf("Error committing transaction: %v", err)
+		return err
+	}
+	logging.Infof("Normalized the search forms of %d words", len(changed))
 	return nil
 }
 
-// GetDB returns the database instance
-func GetDB() *sql.DB {
-	return DB
-}
+// checkForeignKeys warns about rows referencing missing rows. Foreign keys were
+// not enforced before, so older databases may have some; they are reported
+// rather than refused.
+func checkForeignKeys(db *sql.DB) error {
+	rows, err := db.Query("SELECT \"table\", COUNT(*) FROM pragma_foreign_key_check GROUP BY \"table\"")
+	if err != nil {
+		logging.Errorf("Error checking foreign keys: %v", err)
+		return err
+	}
+	defer rows.Close()
 
-// CloseDB closes the database connection
-func CloseDB() error {
-	if DB != nil {
-		return DB.Close()
+	for rows.Next() {
+		var table string
+		var count int
+		if err := rows.Scan(&table, &count); err != nil {
+			logging.Errorf("Error checking foreign keys: %v", err)
+			return err
+		}
+		logging.Warnf("%d rows of %s reference rows that do not exist", count, table)
 	}
-	return nil
+	return rows.Err()
 }
@@ -0,0 +1,153 @@
+package service
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "strings"
+)
+
+// Kinds of errors for requests the Service refuses. Errors of each kind match it
+// with errors.Is, and name the code and HTTP status clients get for them with
+// their Code and Status methods; any other error is a failure of the server.
+var (
+    // ErrNotFound is matched by NotFoundError
+    ErrNotFound = errors.New("not found")
+    // ErrValidation is matched by ValidationError
+    ErrValidation = errors.New("invalid request")
+    // ErrConflict is matched by ConflictError
+    ErrConflict = errors.New("conflict")
+    // ErrForbidden is matched by requests the caller is not allowed to make
+    ErrForbidden = errors.New("forbidden")
+)
+
+// Errors with no entity to report
+var (
+    // ErrTeacherRequired refuses class and assignment requests from learners
+    ErrTeacherRequired error = &refusal{"TEACHER_REQUIRED", http.StatusForbidden, "teacher role required", ErrForbidden}
+    // ErrInvalidCredentials refuses a login with an unknown username or a wrong password
+    ErrInvalidCredentials error = &refusal{"INVALID_CREDENTIALS", http.StatusUnauthorized, "invalid credentials", nil}
+    // ErrNoStudySessions reports that a learner has not studied yet
+    ErrNoStudySessions error = &refusal{"NO_STUDY_SESSIONS", http.StatusNotFound, "no study sessions", ErrNotFound}
+)
+
+// refusal is an error with no entity to report, of an optional kind
+type refusal struct {
+    code    string
+    status  int
+    message string
+    kind    error
+}
+
+func (e *refusal) Error() string {
+    return e.message
+}
+
+func (e *refusal) Is(target error) bool {
+    return e.kind != nil && target == e.kind
+}
+
+func (e *refusal) Code() string {
+    return e.code
+}
+
+func (e *refusal) Status() int {
+    return e.status
+}
+
+// NotFoundError reports an entity that does not exist, or that belongs to
+// another learner
+type NotFoundError struct {
+    // Entity is the kind of entity, such as "word" or "session"
+    Entity string
+}
+
+func (e *NotFoundError) Error() string {
+    return e.Entity + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+    return target == ErrNotFound
+}
+
+// Code is <ENTITY>_NOT_FOUND
+func (e *NotFoundError) Code() string {
+    return strings.ToUpper(e.Entity) + "_NOT_FOUND"
+}
+
+func (e *NotFoundError) Status() int {
+    return http.StatusNotFound
+}
+
+// notFound turns sql.ErrNoRows into a NotFoundError for entity and returns other
+// errors unchanged
+func notFound(err error, entity string) error {
+    if err == sql.ErrNoRows {
+        return &NotFoundError{Entity: entity}
+    }
+    return err
+}
+
+// Reasons of a ConflictError
+const (
+    ReasonAlreadyExists  = "already_exists"
+    ReasonHasReviews     = "has_reviews"
+    ReasonHasSessions    = "has_sessions"
+    ReasonHasAssignments = "has_assignments"
+    ReasonNotActive      = "not_active"
+    ReasonTaken          = "taken"
+    ReasonConflict       = "conflict"
+)
+
+// ConflictError reports a change that the stored state of an entity does not allow
+type ConflictError struct {
+    // Entity is the kind of entity, such as "word" or "session"
+    Entity string
+    // Reason names the conflict, such as ReasonHasReviews
+    Reason string
+    // Message describes the conflict to the client
+    Message string
+    // ExistingID is the entity a new one would duplicate, for ReasonAlreadyExists
+    ExistingID int64
+}
+
+func (e *ConflictError) Error() string {
+    return e.Message
+}
+
+func (e *ConflictError) Is(target error) bool {
+    return target == ErrConflict
+}
+
+// Code is <ENTITY>_<REASON>
+func (e *ConflictError) Code() string {
+    return strings.ToUpper(e.Entity + "_" + e.Reason)
+}
+
+func (e *ConflictError) Status() int {
+    return http.StatusConflict
+}
+
+// ClientMessage is Message, which describes the conflict better than the message
+// of its code
+func (e *ConflictError) ClientMessage() string {
+    return e.Message
+}
+
+// Details holds the ID of the existing entity for ReasonAlreadyExists
+func (e *ConflictError) Details() interface{} {
+    if e.ExistingID == 0 {
+        return nil
+    }
+    return map[string]int64{"existing_id": e.ExistingID}
+}
+
+// invalid names what failed validation in a ValidationError that does not name it
+// yet, and returns err
+func invalid(err error, entity string) error {
+    var validationErr *ValidationError
+    if errors.As(err, &validationErr) && validationErr.Entity == "" {
+        validationErr.Entity = entity
+    }
+    return err
+}
@@ -1,9 +1,9 @@
 package service
 
 import (
-    "fmt"
-    "log"
+    "database/sql"
     "time"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
 )
 
@@ -25,6 +25,7 @@ type GroupDetailResponse struct {
 
 // WordWithStats represents a word with its review statistics
 type WordWithStats struct {
+    ID           int64  `json:"id"`
     Arabic       string `json:"arabic"`
     Roman        string `json:"roman"`
     English      string `json:"english"`
@@ -33,47 +34,16 @@ type WordWithStats struct {
 }
 
 // GetGroups returns all word groups
-func GetGroups(page, perPage int) ([]GroupResponse, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, nil, fmt.Errorf("database connection not initialized")
-    }
-
-    offset := (page - 1) * perPage
-
-    // Get total count
-    var total int
-    err := db.QueryRow("SELECT COUNT(*) FROM groups").Scan(&total)
-    if err != nil {
-        log.Printf("Error counting groups: %v", err)
+func (s *Service) GetGroups(opts ListOptions, page, perPage int) ([]GroupResponse, *models.Pagination, error) {
+    if err := groupListSpec.validate(opts); err != nil {
         return nil, nil, err
     }
+    offset := (page - 1) * perPage
 
-    // Get groups with word count
-    rows, err := db.Query(`
-        SELECT 
-            g.id,
-            g.name,
-            (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
-        FROM groups g
-        ORDER BY g.name
-        LIMIT ? OFFSET ?`,
-        perPage, offset)
+    groups, total, err := s.Groups.List(opts, perPage, offset)
     if err != nil {
-        log.Printf("Error querying groups: %v", err)
         return nil, nil, err
     }
-    defer rows.Close()
-
-    var groups []GroupResponse
-    for rows.Next() {
-        var g GroupResponse
-        if err := rows.Scan(&g.ID, &g.Name, &g.WordCount); err != nil {
-            log.Printf("Error scanning group: %v", err)
-            return nil, nil, err
-        }
-        groups = append(groups, g)
-    }
 
     pagination := &models.Pagination{
         CurrentPage:  page,
@@ -86,95 +56,21 @@ func GetGroups(page, perPage int) ([]GroupResponse, *models.Pagination, error) {
 }
 
 // GetGroup returns a single group by ID with stats
-func GetGroup(id int64) (*GroupDetailResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
-
-    var group GroupDetailResponse
-    err := db.QueryRow(`
-        SELECT 
-            g.id,
-            g.name,
-            (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
-        FROM groups g
-        WHERE g.id = ?`, id).Scan(&group.ID, &group.Name, &group.Stats.TotalWordCount)
-    if err != nil {
-        log.Printf("Error getting group %d: %v", id, err)
-        return nil, err
-    }
-
-    return &group, nil
+func (s *Service) GetGroup(id int64) (*GroupDetailResponse, error) {
+    return s.Groups.Get(id)
 }
 
-// GetGroupWords returns paginated words in a group with their stats
-func GetGroupWords(groupID int64, page, perPage int) ([]WordWithStats, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, nil, fmt.Errorf("database connection not initialized")
-    }
-
-    offset := (page - 1) * perPage
-
-    // Get total count
-    var total int
-    err := db.QueryRow(`
-        SELECT COUNT(*)
-        FROM words_groups
-        WHERE group_id = ?`, groupID).Scan(&total)
-    if err != nil {
-        log.Printf("Error counting group words: %v", err)
+// GetGroupWords returns paginated words in a group with a learner's stats
+func (s *Service) GetGroupWords(groupID, userID int64, opts ListOptions, page, perPage int) ([]WordWithStats, *models.Pagination, error) {
+    if err := groupWordListSpec.validate(opts); err != nil {
         return nil, nil, err
     }
+    offset := (page - 1) * perPage
 
-    // Get words with their stats
-    rows, err := db.Query(`
-        SELECT 
-            w.arabic,
-            w.roman,
-            w.english,
-            COALESCE(correct.count, 0) as correct_count,
-            COALESCE(wrong.count, 0) as wrong_count
-        FROM words w
-        JOIN words_groups wg ON w.id = wg.word_id
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 1
-            GROUP BY word_id
-        ) correct ON w.id = correct.word_id
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 0
-            GROUP BY word_id
-        ) wrong ON w.id = wrong.word_id
-        WHERE wg.group_id = ?
-        ORDER BY w.id
-        LIMIT ? OFFSET ?`,
-        groupID, perPage, offset)
+    words, total, err := s.Groups.ListWords(groupID, userID, opts, perPage, offset)
     if err != nil {
-        log.Printf("Error querying group words: %v", err)
         return nil, nil, err
     }
-    defer rows.Close()
-
-    var words []WordWithStats
-    for rows.Next() {
-        var w WordWithStats
-        if err := rows.Scan(
-            &w.Arabic,
-            &w.Roman,
-            &w.English,
-            &w.CorrectCount,
-            &w.WrongCount,
-        ); err != nil {
-            log.Printf("Error scanning word: %v", err)
-            return nil, nil, err
-        }
-        words = append(words, w)
-    }
 
     pagination := &models.Pagination{
         CurrentPage:  page,
@@ -188,70 +84,26 @@ func GetGroupWords(groupID int64, page, perPage int) ([]WordWithStats, *models.P
 
 // GroupStudySession represents a study session with activity details
 type GroupStudySession struct {
-    ID               int64     `json:"id"`
-    ActivityName     string    `json:"activity_name"`
-    GroupName        string    `json:"group_name"`
-    StartTime        time.Time `json:"start_time"`
-    EndTime         *time.Time `json:"end_time,omitempty"`
-    ReviewItemsCount int       `json:"review_items_count"`
+    ID               int64      `json:"id"`
+    ActivityName     string     `json:"activity_name"`
+    GroupName        string     `json:"group_name"`
+    Status           string     `json:"status"`
+    StartTime        time.Time  `json:"start_time"`
+    EndTime          *time.Time `json:"end_time,omitempty"`
+    ReviewItemsCount int        `json:"review_items_count"`
 }
 
-// GetGroupStudySessions returns paginated study sessions for a group
-func GetGroupStudySessions(groupID int64, page, perPage int) ([]GroupStudySession, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, nil, fmt.Errorf("database connection not initialized")
-    }
-
-    offset := (page - 1) * perPage
-
-    // Get total count
-    var total int
-    err := db.QueryRow(`
-        SELECT COUNT(*)
-        FROM study_sessions ss
-        WHERE ss.group_id = ?`, groupID).Scan(&total)
-    if err != nil {
-        log.Printf("Error counting group study sessions: %v", err)
+// GetGroupStudySessions returns a learner's paginated study sessions for a group
+func (s *Service) GetGroupStudySessions(groupID, userID int64, opts ListOptions, page, perPage int) ([]GroupStudySession, *models.Pagination, error) {
+    if err := groupSessionListSpec.validate(opts); err != nil {
         return nil, nil, err
     }
+    offset := (page - 1) * perPage
 
-    // Get study sessions with related data
-    rows, err := db.Query(`
-        SELECT 
-            ss.id,
-            sa.name as activity_name,
-            g.name as group_name,
-            ss.created_at as start_time,
-            (SELECT COUNT(*) FROM word_review_items wri WHERE wri.study_session_id = ss.id) as review_count
-        FROM study_sessions ss
-        JOIN study_activities sa ON ss.study_activity_id = sa.id
-        JOIN groups g ON ss.group_id = g.id
-        WHERE ss.group_id = ?
-        ORDER BY ss.created_at DESC
-        LIMIT ? OFFSET ?`,
-        groupID, perPage, offset)
+    sessions, total, err := s.Groups.ListSessions(groupID, userID, opts, perPage, offset)
     if err != nil {
-        log.Printf("Error querying group study sessions: %v", err)
         return nil, nil, err
     }
-    defer rows.Close()
-
-    var sessions []GroupStudySession
-    for rows.Next() {
-        var s GroupStudySession
-        if err := rows.Scan(
-            &s.ID,
-            &s.ActivityName,
-            &s.GroupName,
-            &s.StartTime,
-            &s.ReviewItemsCount,
-        ); err != nil {
-            log.Printf("Error scanning study session: %v", err)
-            return nil, nil, err
-        }
-        sessions = append(sessions, s)
-    }
 
     pagination := &models.Pagination{
         CurrentPage:  page,
@@ -262,3 +114,122 @@ func GetGroupStudySessions(groupID int64, page, perPage int) ([]GroupStudySessio
 
     return sessions, pagination, nil
 }
+
+// GroupRequest represents the request body for creating or renaming a group
+type GroupRequest struct {
+    Name string `json:"name" binding:"required"`
+}
+
+// GroupWordsRequest represents a batch of words to add to or remove from a group
+type GroupWordsRequest struct {
+    WordIDs []int64 `json:"word_ids" binding:"required,min=1"`
+}
+
+// GroupWordsResponse reports what happened to each word in a membership batch
+type GroupWordsResponse struct {
+    GroupID   int64   `json:"group_id"`
+    Added     []int64 `json:"added,omitempty"`
+    Removed   []int64 `json:"removed,omitempty"`
+    Unchanged []int64 `json:"unchanged"`
+    NotFound  []int64 `json:"not_found"`
+    WordCount int     `json:"word_count"`
+}
+
+// DeleteGroupResponse represents the result of deleting a group
+type DeleteGroupResponse struct {
+    ID                     int64 `json:"id"`
+    RemovedWordMemberships int64 `json:"removed_word_memberships"`
+}
+
+// duplicateGroup reports that another group already uses the requested name
+func duplicateGroup(existingID int64) error {
+    return &ConflictError{
+        Entity:     "group",
+        Reason:     ReasonAlreadyExists,
+        Message:    "Group already exists",
+        ExistingID: existingID,
+    }
+}
+
+// findGroupByName returns the ID of another group with the given name, compared
+// case-insensitively, or 0 if there is none. excludeID skips the group being renamed.
+func findGroupByName(q queryer, name string, excludeID int64) (int64, error) {
+    var id int64
+    err := q.QueryRow(`
+        SELECT id
+        FROM groups
+        WHERE LOWER(name) = LOWER(?) AND id != ?
+        LIMIT 1`,
+        name, excludeID).Scan(&id)
+    if err == sql.ErrNoRows {
+        return 0, nil
+    }
+    if err != nil {
+        logging.Errorf("Error looking up group by name: %v", err)
+        return 0, err
+    }
+    return id, nil
+}
+
+// CreateGroup creates a new, empty group
+func (s *Service) CreateGroup(req *GroupRequest) (*GroupDetailResponse, error) {
+    name, err := requireText("name", req.Name)
+    if err != nil {
+        return nil, invalid(err, "group")
+    }
+
+    existingID, err := s.Groups.FindByName(name, 0)
+    if err != nil {
+        return nil, err
+    }
+    if existingID != 0 {
+        return nil, duplicateGroup(existingID)
+    }
+
+    id, err := s.Groups.Create(name)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.GetGroup(id)
+}
+
+// UpdateGroup renames a group
+func (s *Service) UpdateGroup(id int64, req *GroupRequest) (*GroupDetailResponse, error) {
+    name, err := requireText("name", req.Name)
+    if err != nil {
+        return nil, invalid(err, "group")
+    }
+
+    existingID, err := s.Groups.FindByName(name, id)
+    if err != nil {
+        return nil, err
+    }
+    if existingID != 0 {
+        return nil, duplicateGroup(existingID)
+    }
+
+    if err := s.Groups.Rename(id, name); err != nil {
+        return nil, err
+    }
+
+    return s.GetGroup(id)
+}
+
+// DeleteGroup deletes a group and its word memberships; the words themselves are kept.
+// Groups that have been studied or assigned are refused so their sessions and
+// assignments keep pointing at a group.
+func (s *Service) DeleteGroup(id int64) (*DeleteGroupResponse, error) {
+    return s.Groups.Delete(id)
+}
+
+// AddGroupWords adds a batch of words to a group in a single transaction. Words that are
+// already members or do not exist are reported rather than treated as errors.
+func (s *Service) AddGroupWords(groupID int64, wordIDs []int64) (*GroupWordsResponse, error) {
+    return s.Groups.ChangeWords(groupID, wordIDs, true)
+}
+
+// RemoveGroupWords removes a batch of words from a group in a single transaction
+func (s *Service) RemoveGroupWords(groupID int64, wordIDs []int64) (*GroupWordsResponse, error) {
+    return s.Groups.ChangeWords(groupID, wordIDs, false)
+}
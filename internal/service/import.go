@@ -0,0 +1,337 @@
+package service
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "path/filepath"
+    "strings"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// Formats accepted by ImportWords
+const (
+    ImportFormatCSV  = "csv"
+    ImportFormatTSV  = "tsv"
+    ImportFormatJSON = "json"
+)
+
+// Row outcomes reported by ImportWords
+const (
+    ImportStatusInserted  = "inserted"
+    ImportStatusDuplicate = "duplicate"
+    ImportStatusInvalid   = "invalid"
+)
+
+// importColumns are the word fields a delimited file can map its columns to
+var importColumns = map[string]bool{
+    "arabic":  true,
+    "roman":   true,
+    "english": true,
+    "parts":   true,
+}
+
+// defaultImportColumns is the column order assumed for files without a header row
+var defaultImportColumns = []string{"arabic", "roman", "english", "parts"}
+
+// ImportOptions controls how a vocabulary file is imported
+type ImportOptions struct {
+    Format string
+    // Columns names the word field of each column for delimited files without a
+    // header row. A header row naming the columns always takes precedence.
+    Columns []string
+    // GroupName adds every imported word to this group, creating it if needed
+    GroupName string
+}
+
+// ImportRowResult reports what happened to a single row of an import
+type ImportRowResult struct {
+    Row     int    `json:"row"`
+    Status  string `json:"status"`
+    WordID  int64  `json:"word_id,omitempty"`
+    Arabic  string `json:"arabic,omitempty"`
+    English string `json:"english,omitempty"`
+    Group   string `json:"group,omitempty"`
+    Message string `json:"message,omitempty"`
+}
+
+// ImportReport summarizes a vocabulary import
+type ImportReport struct {
+    Inserted  int               `json:"inserted"`
+    Skipped   int               `json:"skipped"`
+    Invalid   int               `json:"invalid"`
+    GroupID   *int64            `json:"group_id,omitempty"`
+    GroupName string            `json:"group_name,omitempty"`
+    Rows      []ImportRowResult `json:"rows"`
+}
+
+// importRow is a parsed row waiting to be validated and stored
+type importRow struct {
+    number  int
+    arabic  string
+    roman   string
+    english string
+    parts   json.RawMessage
+}
+
+// ImportWords imports a CSV, TSV or seed-format JSON vocabulary file in a single
+// transaction. Invalid rows and words that already exist are reported and skipped
+// without failing the import; duplicates are still added to the group.
+func (s *Service) ImportWords(r io.Reader, opts ImportOptions) (*ImportReport, error) {
+    db := s.db
+
+    var rows []importRow
+    var err error
+    switch opts.Format {
+    case ImportFormatCSV:
+        rows, err = parseDelimited(r, ',', opts.Columns)
+    case ImportFormatTSV:
+        rows, err = parseDelimited(r, '\t', opts.Columns)
+    case ImportFormatJSON:
+        rows, err = parseImportJSON(r)
+    default:
+        err = &ValidationError{Field: "format", Message: "must be csv, tsv or json"}
+    }
+    if err != nil {
+        return nil, invalid(err, "import")
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    report := &ImportReport{Rows: []ImportRowResult{}}
+
+    var groupID int64
+    if name := strings.TrimSpace(opts.GroupName); name != "" {
+        groupID, err = ensureGroup(tx, name)
+        if err != nil {
+            return nil, err
+        }
+        report.GroupID = &groupID
+        report.GroupName = name
+    }
+
+    for _, row := range rows {
+        result := ImportRowResult{Row: row.number}
+
+        word, err := normalizeWord(row.arabic, row.roman, row.english, row.parts)
+        if err != nil {
+            result.Status = ImportStatusInvalid
+            result.Arabic = row.arabic
+            result.English = row.english
+            result.Message = err.Error()
+            report.Invalid++
+            report.Rows = append(report.Rows, result)
+            continue
+        }
+        result.Arabic = word.Arabic
+        result.English = word.English
+
+        existingID, err := findDuplicateWord(tx, word.Arabic, word.English, 0)
+        if err != nil {
+            return nil, err
+        }
+
+        if existingID != 0 {
+            result.Status = ImportStatusDuplicate
+            result.WordID = existingID
+            report.Skipped++
+        } else {
+            inserted, err := tx.Exec(`
+                INSERT INTO words (arabic, roman, english, parts, arabic_normalized, roman_normalized)
+                VALUES (?, ?, ?, ?, ?, ?)`,
+                word.Arabic, word.Roman, word.English, storedParts(word),
+                arabic.Normalize(word.Arabic), arabic.NormalizeRoman(word.Roman))
+            if err != nil {
+                logging.Errorf("Error importing word on row %d: %v", row.number, err)
+                return nil, err
+            }
+            if result.WordID, err = inserted.LastInsertId(); err != nil {
+                return nil, err
+            }
+            result.Status = ImportStatusInserted
+            report.Inserted++
+        }
+
+        if groupID != 0 {
+            _, err = tx.Exec(
+                "INSERT OR IGNORE INTO words_groups (word_id, group_id) VALUES (?, ?)",
+                result.WordID, groupID)
+            if err != nil {
+                logging.Errorf("Error adding imported word %d to group %d: %v", result.WordID, groupID, err)
+                return nil, err
+            }
+        }
+
+        report.Rows = append(report.Rows, result)
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return report, nil
+}
+
+// ensureGroup returns the ID of the group with the given name, creating it if needed
+func ensureGroup(q queryer, name string) (int64, error) {
+    groupID, err := findGroupByName(q, name, 0)
+    if err != nil || groupID != 0 {
+        return groupID, err
+    }
+
+    result, err := q.Exec("INSERT INTO groups (name) VALUES (?)", name)
+    if err != nil {
+        logging.Errorf("Error creating group: %v", err)
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// parseDelimited reads CSV or TSV rows. If every cell of the first row names a word
+// field it is used as the header, otherwise columns are mapped using columns or, if
+// that is empty, the default arabic, roman, english, parts order.
+func parseDelimited(r io.Reader, delimiter rune, columns []string) ([]importRow, error) {
+    reader := csv.NewReader(r)
+    reader.Comma = delimiter
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+    if delimiter == '\t' {
+        reader.LazyQuotes = true
+    }
+
+    records, err := reader.ReadAll()
+    if err != nil {
+        return nil, &ValidationError{Field: "file", Message: err.Error()}
+    }
+    if len(records) == 0 {
+        return nil, &ValidationError{Field: "file", Message: "contains no rows"}
+    }
+    if len(records[0]) > 0 {
+        // Spreadsheet exports often start with a byte order mark
+        records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+    }
+
+    first := 0
+    if header, ok := parseHeader(records[0]); ok {
+        columns = header
+        first = 1
+    } else if len(columns) == 0 {
+        columns = defaultImportColumns
+    }
+    for _, column := range columns {
+        if !importColumns[column] {
+            return nil, &ValidationError{
+                Field:   "columns",
+                Message: fmt.Sprintf("unknown column %q, expected arabic, roman, english or parts", column),
+            }
+        }
+    }
+
+    var rows []importRow
+    for i, record := range records[first:] {
+        if isBlankRecord(record) {
+            continue
+        }
+        row := importRow{number: first + i + 1}
+        for j, column := range columns {
+            if j >= len(record) {
+                break
+            }
+            value := record[j]
+            switch column {
+            case "arabic":
+                row.arabic = value
+            case "roman":
+                row.roman = value
+            case "english":
+                row.english = value
+            case "parts":
+                if strings.TrimSpace(value) != "" {
+                    row.parts = json.RawMessage(value)
+                }
+            }
+        }
+        rows = append(rows, row)
+    }
+
+    return rows, nil
+}
+
+// parseHeader returns the column names of a header row, or false if the row is data
+func parseHeader(record []string) ([]string, bool) {
+    header := make([]string, len(record))
+    for i, cell := range record {
+        name := strings.ToLower(strings.TrimSpace(cell))
+        if !importColumns[name] {
+            return nil, false
+        }
+        header[i] = name
+    }
+    return header, true
+}
+
+func isBlankRecord(record []string) bool {
+    for _, cell := range record {
+        if strings.TrimSpace(cell) != "" {
+            return false
+        }
+    }
+    return true
+}
+
+// parseImportJSON reads the seed file format: an array of word objects
+func parseImportJSON(r io.Reader) ([]importRow, error) {
+    var words []struct {
+        Arabic  string          `json:"arabic"`
+        Roman   string          `json:"roman"`
+        English string          `json:"english"`
+        Parts   json.RawMessage `json:"parts"`
+    }
+    if err := json.NewDecoder(r).Decode(&words); err != nil {
+        return nil, &ValidationError{Field: "file", Message: err.Error()}
+    }
+
+    rows := make([]importRow, len(words))
+    for i, word := range words {
+        rows[i] = importRow{
+            number:  i + 1,
+            arabic:  word.Arabic,
+            roman:   word.Roman,
+            english: word.English,
+            parts:   word.Parts,
+        }
+    }
+    return rows, nil
+}
+
+// DetectImportFormat guesses the format of a vocabulary file from its file name or
+// content type, returning an empty string if neither is recognized
+func DetectImportFormat(filename, contentType string) string {
+    switch strings.ToLower(filepath.Ext(filename)) {
+    case ".csv":
+        return ImportFormatCSV
+    case ".tsv", ".tab":
+        return ImportFormatTSV
+    case ".json":
+        return ImportFormatJSON
+    }
+
+    switch contentType {
+    case "text/csv":
+        return ImportFormatCSV
+    case "text/tab-separated-values":
+        return ImportFormatTSV
+    case "application/json":
+        return ImportFormatJSON
+    }
+    return ""
+}
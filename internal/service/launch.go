@@ -0,0 +1,153 @@
+package service
+
+import (
+    "crypto/subtle"
+    "database/sql"
+    "errors"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/launch"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// Default token lifetimes
+const (
+    // DefaultLaunchTokenTTL is how long a launch token is accepted after the session starts
+    DefaultLaunchTokenTTL = 5 * time.Minute
+    // DefaultSessionTokenTTL is how long a session token authorizes writing to its session
+    DefaultSessionTokenTTL = 12 * time.Hour
+)
+
+// resolveSessionLaunch fills in the activity's launch URL template for a new session,
+// issuing a launch token when the template asks for one, and issues the session token
+func (s *Service) resolveSessionLaunch(session *CreateStudySessionResponse, template, apiBase string) error {
+    params := launch.Params{
+        SessionID:  session.ID,
+        GroupID:    session.GroupID,
+        ActivityID: session.StudyActivityID,
+        APIBase:    apiBase,
+    }
+    if s.opts.APIBase != "" {
+        params.APIBase = s.opts.APIBase
+    }
+
+    claims := launch.Claims{
+        SessionID:  session.ID,
+        GroupID:    session.GroupID,
+        ActivityID: session.StudyActivityID,
+        Nonce:      session.nonce,
+    }
+
+    if launch.UsesToken(template) {
+        claims.Scope = launch.ScopeLaunch
+        token, issued, err := s.signer.Sign(claims, s.opts.LaunchTokenTTL)
+        if err != nil {
+            return err
+        }
+        expiry := issued.Expiry()
+        params.Token = token
+        session.LaunchToken = token
+        session.LaunchTokenExpiresAt = &expiry
+    }
+
+    claims.Scope = launch.ScopeSession
+    token, issued, err := s.signer.Sign(claims, s.opts.SessionTokenTTL)
+    if err != nil {
+        return err
+    }
+    session.SessionToken = token
+    session.SessionTokenExpiresAt = issued.Expiry()
+
+    session.LaunchURL, err = launch.Resolve(template, params)
+    return err
+}
+
+// LaunchTokenRequest represents the request body for verifying a launch token
+type LaunchTokenRequest struct {
+    Token string `json:"token" binding:"required"`
+}
+
+// LaunchTokenResponse describes the study session a launch token was issued for
+type LaunchTokenResponse struct {
+    SessionID       int64     `json:"session_id"`
+    GroupID         int64     `json:"group_id"`
+    GroupName       string    `json:"group_name"`
+    StudyActivityID int64     `json:"study_activity_id"`
+    ActivityName    string    `json:"activity_name"`
+    Status          string    `json:"status"`
+    ExpiresAt       time.Time `json:"expires_at"`
+    // SessionToken authorizes the activity to write to the session
+    SessionToken          string    `json:"session_token"`
+    SessionTokenExpiresAt time.Time `json:"session_token_expires_at"`
+}
+
+// VerifyLaunchToken checks a launch token and returns the session it was issued for,
+// with a new session token for it. It returns launch.ErrInvalidToken or
+// launch.ErrExpiredToken for tokens that are not accepted, and a NotFoundError
+// when the session has since been deleted.
+func (s *Service) VerifyLaunchToken(token string) (*LaunchTokenResponse, error) {
+    db := s.db
+
+    claims, err := s.signer.Verify(token, launch.ScopeLaunch)
+    if err != nil {
+        return nil, err
+    }
+
+    response := LaunchTokenResponse{ExpiresAt: claims.Expiry()}
+    err = db.QueryRow(`
+        SELECT ss.id, ss.group_id, g.name, ss.study_activity_id, sa.name, ss.status
+        FROM study_sessions ss
+        JOIN groups g ON g.id = ss.group_id
+        JOIN study_activities sa ON sa.id = ss.study_activity_id
+        WHERE ss.id = ? AND ss.group_id = ? AND ss.study_activity_id = ? AND ss.nonce = ?`,
+        claims.SessionID, claims.GroupID, claims.ActivityID, claims.Nonce).Scan(
+        &response.SessionID,
+        &response.GroupID,
+        &response.GroupName,
+        &response.StudyActivityID,
+        &response.ActivityName,
+        &response.Status,
+    )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "session"}
+        }
+        logging.Errorf("Error getting session for launch token: %v", err)
+        return nil, err
+    }
+
+    sessionClaims := *claims
+    sessionClaims.Scope = launch.ScopeSession
+    sessionToken, issued, err := s.signer.Sign(sessionClaims, s.opts.SessionTokenTTL)
+    if err != nil {
+        return nil, err
+    }
+    response.SessionToken = sessionToken
+    response.SessionTokenExpiresAt = issued.Expiry()
+
+    return &response, nil
+}
+
+// VerifySessionToken checks a session token and returns the ID of the study session
+// it authorizes writing to. Tokens of a session that has since been deleted are
+// rejected with launch.ErrInvalidToken, even when a later session got its ID.
+func (s *Service) VerifySessionToken(token string) (int64, error) {
+    claims, err := s.signer.Verify(token, launch.ScopeSession)
+    if err != nil {
+        return 0, err
+    }
+
+    state, err := s.Sessions.State(claims.SessionID)
+    var notFoundErr *NotFoundError
+    if errors.As(err, &notFoundErr) {
+        return 0, launch.ErrInvalidToken
+    }
+    if err != nil {
+        return 0, err
+    }
+    if state.GroupID != claims.GroupID || state.StudyActivityID != claims.ActivityID ||
+        subtle.ConstantTimeCompare([]byte(state.Nonce), []byte(claims.Nonce)) != 1 {
+        return 0, launch.ErrInvalidToken
+    }
+    return claims.SessionID, nil
+}
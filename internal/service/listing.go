@@ -0,0 +1,402 @@
+package service
+
+import (
+    "sort"
+    "strings"
+    "time"
+)
+
+// ListOptions sort and filter a list. Every list accepts the sort fields and
+// filters that apply to its items and refuses the others with a ValidationError.
+type ListOptions struct {
+    // Sort names the field to sort by; lists keep their own order when it is empty
+    Sort string
+    // Order is asc or desc. When empty, lists sort ascending by Sort or keep the
+    // direction of their own order.
+    Order string
+
+    // Search matches words by their Arabic, transliteration or English. Arabic is
+    // compared without tashkeel and letter variants, and the last term also matches
+    // the start of longer words. Unless sorted otherwise, best matches come first.
+    Search string
+    // From and To keep items started in the range, including From and excluding To
+    From *time.Time
+    To   *time.Time
+    // GroupID and ActivityID keep items of a group or study activity
+    GroupID    int64
+    ActivityID int64
+    // MinAccuracy keeps items that were reviewed and answered correctly at least
+    // this percentage of the time
+    MinAccuracy *float64
+}
+
+// Filters of a ListOptions, named after their query parameters
+const (
+    filterSearch      = "q"
+    filterFrom        = "from"
+    filterTo          = "to"
+    filterGroupID     = "group_id"
+    filterActivityID  = "activity_id"
+    filterMinAccuracy = "min_accuracy"
+)
+
+// listFilterValue is a filter set in a ListOptions and the value it compares with
+type listFilterValue struct {
+    name  string
+    value interface{}
+}
+
+// filters returns the filters set in o, in a fixed order
+func (o ListOptions) filters() []listFilterValue {
+    var filters []listFilterValue
+    if o.Search != "" {
+        filters = append(filters, listFilterValue{filterSearch, o.Search})
+    }
+    if o.From != nil {
+        filters = append(filters, listFilterValue{filterFrom, o.From.UTC().Format(time.RFC3339)})
+    }
+    if o.To != nil {
+        filters = append(filters, listFilterValue{filterTo, o.To.UTC().Format(time.RFC3339)})
+    }
+    if o.GroupID != 0 {
+        filters = append(filters, listFilterValue{filterGroupID, o.GroupID})
+    }
+    if o.ActivityID != 0 {
+        filters = append(filters, listFilterValue{filterActivityID, o.ActivityID})
+    }
+    if o.MinAccuracy != nil {
+        filters = append(filters, listFilterValue{filterMinAccuracy, *o.MinAccuracy})
+    }
+    return filters
+}
+
+// listSpec describes how a list query can be sorted and filtered
+type listSpec struct {
+    // sorts maps the fields the list can be sorted by to SQL expressions
+    sorts map[string]string
+    // defaultSort and defaultOrder give the list's own order
+    defaultSort  string
+    defaultOrder string
+    // tiebreak orders items that sort equal, so that pages do not overlap
+    tiebreak string
+    // filters maps the filters the list accepts to how they are applied
+    filters map[string]listFilter
+    // grouped lists aggregate rows with GROUP BY, so that reading them after a
+    // cursor compares sort keys after grouping
+    grouped bool
+}
+
+// listFilter is an SQL condition with one parameter, compared with the filter value
+type listFilter struct {
+    // condition is empty for filters the repository applies itself
+    condition string
+    // aggregate conditions are checked on groups, after GROUP BY
+    aggregate bool
+}
+
+// validate checks that the list accepts opts
+func (l *listSpec) validate(opts ListOptions) error {
+    if _, ok := l.sorts[opts.Sort]; opts.Sort != "" && !ok {
+        fields := make([]string, 0, len(l.sorts))
+        for field := range l.sorts {
+            fields = append(fields, field)
+        }
+        sort.Strings(fields)
+        return &ValidationError{Entity: "list_query", Field: "sort", Message: "must be one of " + strings.Join(fields, ", ")}
+    }
+    if opts.Order != "" && opts.Order != "asc" && opts.Order != "desc" {
+        return &ValidationError{Entity: "list_query", Field: "order", Message: "must be asc or desc"}
+    }
+
+    for _, filter := range opts.filters() {
+        if _, ok := l.filters[filter.name]; !ok {
+            return &ValidationError{Entity: "list_query", Field: filter.name, Message: "is not supported by this list"}
+        }
+    }
+    if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+        return &ValidationError{Entity: "list_query", Field: filterTo, Message: "must be after from"}
+    }
+    if opts.MinAccuracy != nil && (*opts.MinAccuracy < 0 || *opts.MinAccuracy > 100) {
+        return &ValidationError{Entity: "list_query", Field: filterMinAccuracy, Message: "must be between 0 and 100"}
+    }
+    return nil
+}
+
+// listClauses are the filters and order of a ListOptions as SQL
+type listClauses struct {
+    conditions []string
+    whereArgs  []interface{}
+    having     []string
+    havingArgs []interface{}
+    orderBy    string
+}
+
+// clauses turns options accepted by validate into SQL
+func (l *listSpec) clauses(opts ListOptions) *listClauses {
+    c := &listClauses{}
+    for _, value := range opts.filters() {
+        filter := l.filters[value.name]
+        switch {
+        case filter.condition == "":
+        case filter.aggregate:
+            c.having = append(c.having, filter.condition)
+            c.havingArgs = append(c.havingArgs, value.value)
+        default:
+            c.conditions = append(c.conditions, filter.condition)
+            c.whereArgs = append(c.whereArgs, value.value)
+        }
+    }
+
+    c.orderBy = l.orderBy(l.sortOf(opts))
+    return c
+}
+
+// sortOf returns the field and order a list is sorted by with opts
+func (l *listSpec) sortOf(opts ListOptions) (field, order string) {
+    field, order = opts.Sort, opts.Order
+    if field == "" {
+        field = l.defaultSort
+        if order == "" {
+            order = l.defaultOrder
+        }
+    }
+    if order == "" {
+        order = "asc"
+    }
+    return field, order
+}
+
+// orderBy returns an ORDER BY clause sorting by field, then by the tiebreak, in order
+func (l *listSpec) orderBy(field, order string) string {
+    order = strings.ToUpper(order)
+    return " ORDER BY " + l.sorts[field] + " " + order + ", " + l.tiebreak + " " + order
+}
+
+// where returns a WHERE clause requiring conditions and the list's filters, or ""
+func (c *listClauses) where(conditions ...string) string {
+    conditions = append(conditions, c.conditions...)
+    if len(conditions) == 0 {
+        return ""
+    }
+    return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// havingClause returns a HAVING clause with the list's aggregate filters, or ""
+func (c *listClauses) havingClause() string {
+    if len(c.having) == 0 {
+        return ""
+    }
+    return " HAVING " + strings.Join(c.having, " AND ")
+}
+
+// args returns the arguments of a list query: those of the query itself, which
+// must all come before its WHERE conditions end, then those of the filters
+func (c *listClauses) args(queryArgs ...interface{}) []interface{} {
+    args := append(queryArgs, c.whereArgs...)
+    return append(args, c.havingArgs...)
+}
+
+// countList returns the number of rows a list query selects
+func countList(q queryer, query string, args []interface{}) (int, error) {
+    var total int
+    err := q.QueryRow("SELECT COUNT(*) FROM ("+query+")", args...).Scan(&total)
+    return total, err
+}
+
+// Accuracy of reviews as a percentage, NULL when there are none
+const (
+    // wordAccuracySQL uses the correct and wrong counts joined to a word list
+    wordAccuracySQL = `(COALESCE(correct.count, 0) * 100.0 / NULLIF(COALESCE(correct.count, 0) + COALESCE(wrong.count, 0), 0))`
+    // reviewAccuracySQL aggregates the reviews joined as wri
+    reviewAccuracySQL = `(SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) * 100.0 / NULLIF(COUNT(wri.id), 0))`
+    // sessionAccuracySQL looks up the reviews of the session ss
+    sessionAccuracySQL = `(SELECT AVG(wri.correct) * 100.0 FROM word_review_items wri WHERE wri.study_session_id = ss.id)`
+)
+
+// Conditions of the session date filters
+const (
+    sessionFromSQL = "julianday(ss.created_at) >= julianday(?)"
+    sessionToSQL   = "julianday(ss.created_at) < julianday(?)"
+)
+
+// wordSorts are the sort fields of word lists
+var wordSorts = map[string]string{
+    "id":            "w.id",
+    "arabic":        "w.arabic",
+    "roman":         "w.roman COLLATE NOCASE",
+    "english":       "w.english COLLATE NOCASE",
+    "correct_count": "correct_count",
+    "wrong_count":   "wrong_count",
+    "accuracy":      wordAccuracySQL,
+}
+
+// wordListSpec sorts and filters the vocabulary
+var wordListSpec = &listSpec{
+    sorts:       wordSorts,
+    defaultSort: "id",
+    tiebreak:    "w.id",
+    filters: map[string]listFilter{
+        filterSearch:      {},
+        filterGroupID:     {condition: "w.id IN (SELECT word_id FROM words_groups WHERE group_id = ?)"},
+        filterMinAccuracy: {condition: wordAccuracySQL + " >= ?"},
+    },
+}
+
+// groupWordListSpec sorts and filters the words of a group
+var groupWordListSpec = &listSpec{
+    sorts:       wordSorts,
+    defaultSort: "id",
+    tiebreak:    "w.id",
+    filters: map[string]listFilter{
+        filterMinAccuracy: {condition: wordAccuracySQL + " >= ?"},
+    },
+}
+
+// groupListSpec sorts the groups
+var groupListSpec = &listSpec{
+    sorts: map[string]string{
+        "id":         "g.id",
+        "name":       "g.name COLLATE NOCASE",
+        "word_count": "word_count",
+    },
+    defaultSort: "name",
+    tiebreak:    "g.id",
+}
+
+// activityListSpec sorts and filters the study activities, by a learner's stats
+var activityListSpec = &listSpec{
+    sorts: map[string]string{
+        "id":             "sa.id",
+        "name":           "sa.name COLLATE NOCASE",
+        "total_sessions": "total_sessions",
+        "total_reviews":  "total_reviews",
+        "accuracy":       reviewAccuracySQL,
+    },
+    defaultSort: "name",
+    tiebreak:    "sa.id",
+    filters: map[string]listFilter{
+        filterMinAccuracy: {condition: reviewAccuracySQL + " >= ?", aggregate: true},
+    },
+}
+
+// sessionSorts are the sort fields of session lists that join their reviews as wri.
+// They compare times as numbers, so that the keys of cursors keep their order.
+var sessionSorts = map[string]string{
+    "id":            "ss.id",
+    "start_time":    "julianday(ss.created_at)",
+    "end_time":      "julianday(ss.ended_at)",
+    "duration":      sessionDurationSQL,
+    "total_words":   reviewedWordsSQL,
+    "correct_count": correctReviewsSQL,
+    "wrong_count":   wrongReviewsSQL,
+    "accuracy":      reviewAccuracySQL,
+}
+
+// sessionListSpec sorts and filters a learner's study sessions
+var sessionListSpec = &listSpec{
+    sorts:        sessionSorts,
+    defaultSort:  "start_time",
+    defaultOrder: "desc",
+    tiebreak:     "ss.id",
+    filters: map[string]listFilter{
+        filterFrom:        {condition: sessionFromSQL},
+        filterTo:          {condition: sessionToSQL},
+        filterGroupID:     {condition: "ss.group_id = ?"},
+        filterActivityID:  {condition: "ss.study_activity_id = ?"},
+        filterMinAccuracy: {condition: reviewAccuracySQL + " >= ?", aggregate: true},
+    },
+    grouped: true,
+}
+
+// activitySessionListSpec sorts and filters a learner's sessions of an activity
+var activitySessionListSpec = &listSpec{
+    sorts:        sessionSorts,
+    defaultSort:  "start_time",
+    defaultOrder: "desc",
+    tiebreak:     "ss.id",
+    filters: map[string]listFilter{
+        filterFrom:        {condition: sessionFromSQL},
+        filterTo:          {condition: sessionToSQL},
+        filterGroupID:     {condition: "ss.group_id = ?"},
+        filterMinAccuracy: {condition: reviewAccuracySQL + " >= ?", aggregate: true},
+    },
+}
+
+// groupSessionListSpec sorts and filters a learner's sessions of a group
+var groupSessionListSpec = &listSpec{
+    sorts: map[string]string{
+        "id":           "ss.id",
+        "start_time":   "ss.created_at",
+        "end_time":     "ss.ended_at",
+        "review_count": "review_count",
+        "accuracy":     sessionAccuracySQL,
+    },
+    defaultSort:  "start_time",
+    defaultOrder: "desc",
+    tiebreak:     "ss.id",
+    filters: map[string]listFilter{
+        filterFrom:        {condition: sessionFromSQL},
+        filterTo:          {condition: sessionToSQL},
+        filterActivityID:  {condition: "ss.study_activity_id = ?"},
+        filterMinAccuracy: {condition: sessionAccuracySQL + " >= ?"},
+    },
+}
+
+// sessionWordListSpec sorts the words reviewed in a session
+var sessionWordListSpec = &listSpec{
+    sorts: map[string]string{
+        "reviewed_at":      "julianday(wri.created_at)",
+        "arabic":           "w.arabic",
+        "roman":            "w.roman COLLATE NOCASE",
+        "english":          "w.english COLLATE NOCASE",
+        "grade":            "wri.grade",
+        "response_time_ms": "wri.response_time_ms",
+    },
+    defaultSort: "reviewed_at",
+    tiebreak:    "wri.id",
+}
+
+// ListQuery describes the sort fields and filters a list accepts
+type ListQuery struct {
+    Sorts        []string
+    DefaultSort  string
+    DefaultOrder string
+    Filters      []string
+}
+
+// listSpecs names the lists of the API
+var listSpecs = map[string]*listSpec{
+    "words":             wordListSpec,
+    "group_words":       groupWordListSpec,
+    "groups":            groupListSpec,
+    "activities":        activityListSpec,
+    "sessions":          sessionListSpec,
+    "activity_sessions": activitySessionListSpec,
+    "group_sessions":    groupSessionListSpec,
+    "session_words":     sessionWordListSpec,
+}
+
+// ListQueryOf describes the query parameters the named list accepts, so that they
+// can be documented. It returns false for an unknown list.
+func ListQueryOf(name string) (ListQuery, bool) {
+    l, ok := listSpecs[name]
+    if !ok {
+        return ListQuery{}, false
+    }
+
+    query := ListQuery{DefaultSort: l.defaultSort, DefaultOrder: l.defaultOrder}
+    if query.DefaultOrder == "" {
+        query.DefaultOrder = "asc"
+    }
+    for field := range l.sorts {
+        query.Sorts = append(query.Sorts, field)
+    }
+    sort.Strings(query.Sorts)
+    // Keep the filters in the order of ListOptions.filters
+    for _, name := range []string{filterSearch, filterFrom, filterTo, filterGroupID, filterActivityID, filterMinAccuracy} {
+        if _, ok := l.filters[name]; ok {
+            query.Filters = append(query.Filters, name)
+        }
+    }
+    return query, true
+}
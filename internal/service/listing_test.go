@@ -0,0 +1,70 @@
+package service
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestListSpecValidate(t *testing.T) {
+    day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    nextDay := day.AddDate(0, 0, 1)
+    percent := func(f float64) *float64 { return &f }
+
+    tests := []struct {
+        name      string
+        spec      *listSpec
+        opts      ListOptions
+        wantField string
+    }{
+        {"defaults", wordListSpec, ListOptions{}, ""},
+        {"sort and order", wordListSpec, ListOptions{Sort: "accuracy", Order: "desc"}, ""},
+        {"unknown sort", wordListSpec, ListOptions{Sort: "start_time"}, "sort"},
+        {"unknown order", wordListSpec, ListOptions{Sort: "id", Order: "up"}, "order"},
+        {"supported filters", wordListSpec, ListOptions{Search: "kitab", GroupID: 1, MinAccuracy: percent(50)}, ""},
+        {"unsupported filter", wordListSpec, ListOptions{ActivityID: 1}, filterActivityID},
+        {"accuracy below range", wordListSpec, ListOptions{MinAccuracy: percent(-1)}, filterMinAccuracy},
+        {"accuracy above range", wordListSpec, ListOptions{MinAccuracy: percent(100.5)}, filterMinAccuracy},
+        {"date range", sessionListSpec, ListOptions{From: &day, To: &nextDay}, ""},
+        {"empty date range", sessionListSpec, ListOptions{From: &day, To: &day}, filterTo},
+        {"reversed date range", sessionListSpec, ListOptions{From: &nextDay, To: &day}, filterTo},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := tt.spec.validate(tt.opts)
+            if tt.wantField == "" {
+                if err != nil {
+                    t.Errorf("validate() error = %v, want nil", err)
+                }
+                return
+            }
+            var validation *ValidationError
+            if !errors.As(err, &validation) || validation.Field != tt.wantField {
+                t.Errorf("validate() error = %v, want a ValidationError of %s", err, tt.wantField)
+            }
+        })
+    }
+}
+
+func TestListSpecSortOf(t *testing.T) {
+    tests := []struct {
+        name                 string
+        opts                 ListOptions
+        wantField, wantOrder string
+    }{
+        {"own order", ListOptions{}, "start_time", "desc"},
+        {"sort ascending by default", ListOptions{Sort: "id"}, "id", "asc"},
+        {"sort and order", ListOptions{Sort: "id", Order: "desc"}, "id", "desc"},
+        {"order of own sort", ListOptions{Order: "asc"}, "start_time", "asc"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            field, order := sessionListSpec.sortOf(tt.opts)
+            if field != tt.wantField || order != tt.wantOrder {
+                t.Errorf("sortOf() = %s %s, want %s %s", field, order, tt.wantField, tt.wantOrder)
+            }
+        })
+    }
+}
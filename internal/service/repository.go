@@ -0,0 +1,151 @@
+package service
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// WordRepository stores the vocabulary
+type WordRepository interface {
+    // List returns a page of words with a learner's review counts, and the total
+    // number of words; opts must be accepted by wordListSpec
+    List(userID int64, opts ListOptions, limit, offset int) ([]WordWithStats, int, error)
+    // Get returns a word with a learner's review counts and its groups, or a NotFoundError
+    Get(id, userID int64) (*WordDetailResponse, error)
+    // GetFields returns the stored fields of a word, or a NotFoundError
+    GetFields(id int64) (*CreateWordRequest, error)
+    // Exists reports whether a word exists
+    Exists(id int64) (bool, error)
+    // FindDuplicate returns the ID of another word with the same Arabic and English text, or 0.
+    // Arabic is compared normalized and English case-insensitively.
+    FindDuplicate(arabic, english string, excludeID int64) (int64, error)
+    // Create stores a new word and returns its ID
+    Create(word *CreateWordRequest) (int64, error)
+    // Update replaces the fields of a word, returning a NotFoundError if it does not exist
+    Update(id int64, word *CreateWordRequest) error
+    // Delete removes a word with its group memberships. A reviewed word is only removed
+    // together with its reviews when force is set.
+    Delete(id int64, force bool) (*DeleteWordResponse, error)
+}
+
+// GroupRepository stores word groups and their members
+type GroupRepository interface {
+    // List returns a page of groups and the total number of groups; opts must be
+    // accepted by groupListSpec
+    List(opts ListOptions, limit, offset int) ([]GroupResponse, int, error)
+    // Get returns a group with its stats, or a NotFoundError
+    Get(id int64) (*GroupDetailResponse, error)
+    // Exists reports whether a group exists
+    Exists(id int64) (bool, error)
+    // ListWords returns a page of a group's words with a learner's review counts, and
+    // their total number; opts must be accepted by groupWordListSpec
+    ListWords(groupID, userID int64, opts ListOptions, limit, offset int) ([]WordWithStats, int, error)
+    // ListSessions returns a page of a learner's sessions for a group, and their total
+    // number; opts must be accepted by groupSessionListSpec
+    ListSessions(groupID, userID int64, opts ListOptions, limit, offset int) ([]GroupStudySession, int, error)
+    // FindByName returns the ID of another group with the name, or 0
+    FindByName(name string, excludeID int64) (int64, error)
+    // Create stores a new, empty group and returns its ID
+    Create(name string) (int64, error)
+    // Rename renames a group, returning a NotFoundError if it does not exist
+    Rename(id int64, name string) error
+    // Delete removes a group that has never been studied or assigned, with its memberships
+    Delete(id int64) (*DeleteGroupResponse, error)
+    // ChangeWords adds words to or removes them from a group in one transaction
+    ChangeWords(groupID int64, wordIDs []int64, add bool) (*GroupWordsResponse, error)
+}
+
+// ActivityRepository stores study activities
+type ActivityRepository interface {
+    // List returns a page of activities with a learner's stats, and the total number of
+    // activities; opts must be accepted by activityListSpec
+    List(userID int64, opts ListOptions, limit, offset int) ([]ActivityResponse, int, error)
+    // Get returns an activity with a learner's stats and recent sessions, or a NotFoundError
+    Get(id, userID int64) (*ActivityDetailResponse, error)
+    // Exists reports whether an activity exists
+    Exists(id int64) (bool, error)
+    // ListSessions returns a page of a learner's sessions for an activity, and their
+    // total number; opts must be accepted by activitySessionListSpec
+    ListSessions(activityID, userID int64, opts ListOptions, limit, offset int) ([]ActivitySessionResponse, int, error)
+    // LaunchURL returns an activity's launch URL template, or a NotFoundError
+    LaunchURL(id int64) (string, error)
+    // Create stores a new activity and returns its ID
+    Create(req *CreateActivityRequest) (int64, error)
+}
+
+// SessionState is what the service needs to know about a session before writing to it
+type SessionState struct {
+    Status          string
+    GroupID         int64
+    StudyActivityID int64
+    // UserID is 0 for sessions of the guest learner
+    UserID int64
+    // Nonce tells the session apart from earlier sessions with the same ID
+    Nonce string
+}
+
+// SessionRepository stores study sessions and the words reviewed in them
+type SessionRepository interface {
+    // List returns limit of a learner's sessions from a cursor, or from the start when
+    // cursor is empty, and the cursors around them; opts must be accepted by
+    // sessionListSpec. A cursor of another list is a ValidationError.
+    List(userID int64, opts ListOptions, cursor string, limit int) ([]StudySessionResponse, *models.CursorPagination, error)
+    // Get returns a learner's session with its reviewed words, or a NotFoundError
+    Get(id, userID int64) (*StudySessionDetailResponse, error)
+    // State returns the state of any learner's session, or a NotFoundError
+    State(id int64) (*SessionState, error)
+    // ListWords returns limit of the words reviewed in a learner's session from a
+    // cursor, like List, and the cursors around them; opts must be accepted by
+    // sessionWordListSpec
+    ListWords(sessionID, userID int64, opts ListOptions, cursor string, limit int) ([]SessionWordResponse, *models.CursorPagination, error)
+    // Create starts an active session
+    Create(groupID, activityID, userID int64) (*CreateStudySessionResponse, error)
+    // Complete ends a session if it is still active
+    Complete(id int64) error
+    // AbandonIdle abandons active sessions without activity for longer than timeout,
+    // returning how many were abandoned
+    AbandonIdle(timeout time.Duration) (int64, error)
+}
+
+// WordReview is a graded review to be recorded
+type WordReview struct {
+    SessionID      int64
+    WordID         int64
+    UserID         int64
+    Grade          int
+    Correct        bool
+    Answer         string
+    Direction      string
+    ResponseTimeMs *int64
+}
+
+// ReviewRepository stores word reviews
+type ReviewRepository interface {
+    // Create records a review and reschedules the word for the learner in one
+    // transaction, which also checks that the session is still active
+    Create(review *WordReview) (*CreateWordReviewResponse, error)
+}
+
+// Repositories holds the storage used by a Service
+type Repositories struct {
+    Words      WordRepository
+    Groups     GroupRepository
+    Activities ActivityRepository
+    Sessions   SessionRepository
+    Reviews    ReviewRepository
+}
+
+// NewSQLiteRepositories returns repositories that store their data in a SQLite
+// database and reschedule reviewed words with scheduler
+func NewSQLiteRepositories(db *sql.DB, scheduler srs.Scheduler) Repositories {
+    return Repositories{
+        Words:      &sqliteWordRepository{db: db},
+        Groups:     &sqliteGroupRepository{db: db},
+        Activities: &sqliteActivityRepository{db: db},
+        Sessions:   &sqliteSessionRepository{db: db},
+        Reviews:    &sqliteReviewRepository{db: db, scheduler: scheduler},
+    }
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// Prompt directions a word can be reviewed in
+const (
+    DirectionArabicToEnglish = "arabic_to_english"
+    DirectionEnglishToArabic = "english_to_arabic"
+    DirectionRomanToArabic   = "roman_to_arabic"
+)
+
+var reviewDirections = map[string]bool{
+    DirectionArabicToEnglish: true,
+    DirectionEnglishToArabic: true,
+    DirectionRomanToArabic:   true,
+}
+
+// ReviewGrade is a review's quality on the 0-5 SM-2 scale. In JSON it may be given
+// either as a number or as one of the ratings "again", "hard", "good" or "easy".
+type ReviewGrade int
+
+// UnmarshalJSON accepts a numeric grade or a rating name
+func (g *ReviewGrade) UnmarshalJSON(data []byte) error {
+    var rating string
+    if err := json.Unmarshal(data, &rating); err == nil {
+        grade, ok := srs.GradeFromRating(strings.ToLower(rating))
+        if !ok {
+            return fmt.Errorf("unknown rating %q, expected again, hard, good or easy", rating)
+        }
+        *g = ReviewGrade(grade)
+        return nil
+    }
+
+    var grade int
+    if err := json.Unmarshal(data, &grade); err != nil {
+        return fmt.Errorf("grade must be a number from 0 to 5 or a rating")
+    }
+    *g = ReviewGrade(grade)
+    return nil
+}
+
+// resolveReview validates a review request and works out its grade and correctness.
+// A grade implies correctness; a bare is_correct is graded as good or incorrect.
+func resolveReview(req *CreateWordReviewRequest) (int, bool, error) {
+    if req.Direction != "" && !reviewDirections[req.Direction] {
+        return 0, false, &ValidationError{
+            Field:   "direction",
+            Message: "must be arabic_to_english, english_to_arabic or roman_to_arabic",
+        }
+    }
+    if req.ResponseTimeMs != nil && *req.ResponseTimeMs < 0 {
+        return 0, false, &ValidationError{Field: "response_time_ms", Message: "must not be negative"}
+    }
+
+    if req.Grade == nil {
+        if req.IsCorrect == nil {
+            return 0, false, &ValidationError{Field: "grade", Message: "either grade or is_correct is required"}
+        }
+        if *req.IsCorrect {
+            return srs.GradeGood, true, nil
+        }
+        return srs.GradeIncorrect, false, nil
+    }
+
+    grade := int(*req.Grade)
+    if grade < srs.GradeBlackout || grade > srs.GradePerfect {
+        return 0, false, &ValidationError{Field: "grade", Message: "must be between 0 and 5"}
+    }
+
+    correct := grade >= srs.PassingGrade
+    if req.IsCorrect != nil && *req.IsCorrect != correct {
+        return 0, false, &ValidationError{Field: "is_correct", Message: "contradicts the grade"}
+    }
+
+    return grade, correct, nil
+}
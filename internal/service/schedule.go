@@ -0,0 +1,220 @@
+package service
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// sqliteTimeFormat matches the format SQLite uses for CURRENT_TIMESTAMP
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// ReviewQueueItem represents a word that is due for review
+type ReviewQueueItem struct {
+    ID           int64      `json:"id"`
+    Arabic       string     `json:"arabic"`
+    Roman        string     `json:"roman"`
+    English      string     `json:"english"`
+    IsNew        bool       `json:"is_new"`
+    DueAt        *time.Time `json:"due_at,omitempty"`
+    IntervalDays int        `json:"interval_days"`
+    EaseFactor   float64    `json:"ease_factor"`
+    Repetitions  int        `json:"repetitions"`
+}
+
+// ReviewQueueResponse represents the words due for review in a group by a learner
+type ReviewQueueResponse struct {
+    GroupID   int64             `json:"group_id"`
+    Scheduler string            `json:"scheduler"`
+    Items     []ReviewQueueItem `json:"items"`
+}
+
+// scheduleReview advances a learner's schedule for a word with scheduler after a
+// review graded 0-5. userID 0 is the guest learner.
+func scheduleReview(q queryer, scheduler srs.Scheduler, userID, wordID int64, grade int, reviewedAt time.Time) error {
+    var state srs.State
+    var dueAt, lastReviewedAt time.Time
+    err := q.QueryRow(`
+        SELECT ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+        FROM word_schedules
+        WHERE user_id = ? AND word_id = ?`,
+        userID, wordID).Scan(
+        &state.EaseFactor,
+        &state.IntervalDays,
+        &state.Repetitions,
+        &dueAt,
+        &lastReviewedAt,
+    )
+    if err != nil && err != sql.ErrNoRows {
+        logging.Errorf("Error getting schedule for word %d: %v", wordID, err)
+        return err
+    }
+    state.DueAt = dueAt
+    state.LastReviewedAt = lastReviewedAt
+
+    next := scheduler.Next(state, grade, reviewedAt.UTC())
+
+    _, err = q.Exec(`
+        INSERT INTO word_schedules (user_id, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id, word_id) DO UPDATE SET
+            ease_factor = excluded.ease_factor,
+            interval_days = excluded.interval_days,
+            repetitions = excluded.repetitions,
+            due_at = excluded.due_at,
+            last_reviewed_at = excluded.last_reviewed_at`,
+        userID,
+        wordID,
+        next.EaseFactor,
+        next.IntervalDays,
+        next.Repetitions,
+        next.DueAt.Format(sqliteTimeFormat),
+        next.LastReviewedAt.Format(sqliteTimeFormat),
+    )
+    if err != nil {
+        logging.Errorf("Error saving schedule for word %d: %v", wordID, err)
+        return err
+    }
+
+    return nil
+}
+
+// GetReviewQueue returns up to limit words in a group that are due for review by a
+// learner. Overdue words come first, most overdue first, followed by words the learner
+// has never reviewed.
+func (s *Service) GetReviewQueue(groupID, userID int64, limit int) (*ReviewQueueResponse, error) {
+    db := s.db
+
+    groupExists, err := s.Groups.Exists(groupID)
+    if err != nil {
+        return nil, err
+    }
+    if !groupExists {
+        return nil, &NotFoundError{Entity: "group"}
+    }
+
+    rows, err := db.Query(`
+        SELECT 
+            w.id,
+            w.arabic,
+            w.roman,
+            w.english,
+            ws.due_at,
+            COALESCE(ws.interval_days, 0) as interval_days,
+            COALESCE(ws.ease_factor, 0) as ease_factor,
+            COALESCE(ws.repetitions, 0) as repetitions
+        FROM words w
+        JOIN words_groups wg ON w.id = wg.word_id
+        LEFT JOIN word_schedules ws ON w.id = ws.word_id AND ws.user_id = ?
+        WHERE wg.group_id = ?
+        AND (ws.word_id IS NULL OR julianday(ws.due_at) <= julianday('now'))
+        ORDER BY ws.word_id IS NULL, julianday(ws.due_at), w.id
+        LIMIT ?`,
+        userID, groupID, limit)
+    if err != nil {
+        logging.Errorf("Error querying review queue: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    response := &ReviewQueueResponse{
+        GroupID:   groupID,
+        Scheduler: s.opts.Scheduler.Name(),
+        Items:     []ReviewQueueItem{},
+    }
+    for rows.Next() {
+        var item ReviewQueueItem
+        if err := rows.Scan(
+            &item.ID,
+            &item.Arabic,
+            &item.Roman,
+            &item.English,
+            &item.DueAt,
+            &item.IntervalDays,
+            &item.EaseFactor,
+            &item.Repetitions,
+        ); err != nil {
+            logging.Errorf("Error scanning review queue item: %v", err)
+            return nil, err
+        }
+        item.IsNew = item.DueAt == nil
+        response.Items = append(response.Items, item)
+    }
+    if err := rows.Err(); err != nil {
+        logging.Errorf("Error reading review queue: %v", err)
+        return nil, err
+    }
+
+    return response, nil
+}
+
+// GetSessionReviewQueue returns the words due for review in a study session's group
+// by the session's learner
+func (s *Service) GetSessionReviewQueue(sessionID int64, limit int) (*ReviewQueueResponse, error) {
+    state, err := s.Sessions.State(sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.GetReviewQueue(state.GroupID, state.UserID, limit)
+}
+
+// rebuildSchedule recomputes every learner's schedule of a word by replaying the
+// word's whole review history, oldest first, through the scheduler
+func rebuildSchedule(q queryer, scheduler srs.Scheduler, wordID int64) error {
+    if _, err := q.Exec("DELETE FROM word_schedules WHERE word_id = ?", wordID); err != nil {
+        logging.Errorf("Error clearing schedule for word %d: %v", wordID, err)
+        return err
+    }
+
+    rows, err := q.Query(`
+        SELECT COALESCE(ss.user_id, 0), wri.correct, wri.grade, wri.created_at
+        FROM word_review_items wri
+        JOIN study_sessions ss ON ss.id = wri.study_session_id
+        WHERE wri.word_id = ?
+        ORDER BY wri.created_at, wri.id`,
+        wordID)
+    if err != nil {
+        logging.Errorf("Error getting reviews of word %d: %v", wordID, err)
+        return err
+    }
+
+    type pastReview struct {
+        userID     int64
+        grade      int
+        reviewedAt time.Time
+    }
+    var reviews []pastReview
+    for rows.Next() {
+        var correct bool
+        var grade sql.NullInt64
+        var review pastReview
+        if err := rows.Scan(&review.userID, &correct, &grade, &review.reviewedAt); err != nil {
+            rows.Close()
+            logging.Errorf("Error scanning review of word %d: %v", wordID, err)
+            return err
+        }
+        review.grade = int(grade.Int64)
+        if !grade.Valid {
+            review.grade = srs.GradeIncorrect
+            if correct {
+                review.grade = srs.GradeGood
+            }
+        }
+        reviews = append(reviews, review)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    // Rows must be closed before writing within the same transaction
+    for _, review := range reviews {
+        if err := scheduleReview(q, scheduler, review.userID, wordID, review.grade, review.reviewedAt); err != nil {
+            return err
+        }
+    }
+    return nil
+}
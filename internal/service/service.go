@@ -0,0 +1,72 @@
+package service
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/launch"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// Service implements the portal's features. Words, groups, activities, sessions and
+// reviews are reached through its repositories; features that span many tables in a
+// single transaction, such as imports, archives, classes and resets, query db directly.
+type Service struct {
+    Repositories
+    db     *sql.DB
+    opts   Options
+    signer *launch.Signer
+}
+
+// Options configure a Service. Fields left zero take their defaults.
+type Options struct {
+    // Scheduler is the spaced-repetition algorithm used for new reviews, SM-2 by default
+    Scheduler srs.Scheduler
+    // TokenSecret signs launch and session tokens. Without one a random secret is
+    // used, so tokens issued before a restart are rejected.
+    TokenSecret string
+    // LaunchTokenTTL and SessionTokenTTL set the lifetime of newly issued tokens
+    LaunchTokenTTL  time.Duration
+    SessionTokenTTL time.Duration
+    // SessionIdleTimeout is how long an active session may go without a review
+    // before it is abandoned
+    SessionIdleTimeout time.Duration
+    // APIBase overrides the API URL substituted for {api_base} in launch URLs. When
+    // empty the URL the client used to reach the API is used.
+    APIBase string
+}
+
+// withDefaults fills in the fields of o left zero
+func (o Options) withDefaults() Options {
+    if o.Scheduler == nil {
+        o.Scheduler = srs.SM2{}
+    }
+    if o.LaunchTokenTTL == 0 {
+        o.LaunchTokenTTL = DefaultLaunchTokenTTL
+    }
+    if o.SessionTokenTTL == 0 {
+        o.SessionTokenTTL = DefaultSessionTokenTTL
+    }
+    if o.SessionIdleTimeout == 0 {
+        o.SessionIdleTimeout = DefaultSessionIdleTimeout
+    }
+    return o
+}
+
+// New returns a Service that stores its data in db
+func New(db *sql.DB, opts Options) *Service {
+    opts = opts.withDefaults()
+    return NewWithRepositories(NewSQLiteRepositories(db, opts.Scheduler), db, opts)
+}
+
+// NewWithRepositories returns a Service that uses repos for its core data and db for
+// the rest. repos should reschedule reviews with the scheduler of opts.
+func NewWithRepositories(repos Repositories, db *sql.DB, opts Options) *Service {
+    opts = opts.withDefaults()
+    return &Service{
+        Repositories: repos,
+        db:           db,
+        opts:         opts,
+        signer:       launch.NewSigner([]byte(opts.TokenSecret)),
+    }
+}
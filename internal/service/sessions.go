@@ -1,27 +1,59 @@
 package service
 
 import (
+    "context"
     "database/sql"
-    "fmt"
-    "log"
     "time"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
 )
 
+// Study session lifecycle states
+const (
+    SessionStatusActive    = "active"
+    SessionStatusCompleted = "completed"
+    SessionStatusAbandoned = "abandoned"
+)
+
+// DefaultSessionIdleTimeout is how long an active session may go without a review
+// before it is abandoned, unless Options set otherwise
+const DefaultSessionIdleTimeout = 30 * time.Minute
+
+// errSessionNotActive refuses changes to a session that was completed or abandoned
+var errSessionNotActive = &ConflictError{
+    Entity:  "session",
+    Reason:  ReasonNotActive,
+    Message: "Study session is no longer active",
+}
+
+// sessionDurationSQL computes a session's length in seconds, measuring active sessions up to now
+const sessionDurationSQL = `CAST(ROUND((julianday(COALESCE(ss.ended_at, CURRENT_TIMESTAMP)) - julianday(ss.created_at)) * 86400) AS INTEGER)`
+
+// Counts of the reviews joined as wri
+const (
+    reviewedWordsSQL  = `COUNT(DISTINCT wri.word_id)`
+    correctReviewsSQL = `COALESCE(SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END), 0)`
+    wrongReviewsSQL   = `COALESCE(SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END), 0)`
+)
+
+// sessionStatsSQL aggregates the reviews joined as wri into a StudySessionStats
+const sessionStatsSQL = reviewedWordsSQL + ` as total_words,
+            ` + correctReviewsSQL + ` as correct_count,
+            ` + wrongReviewsSQL + ` as wrong_count`
+
 // StudySessionDetailResponse represents a detailed study session
 type StudySessionDetailResponse struct {
-    ID           int64            `json:"id"`
-    ActivityName string           `json:"activity_name"`
-    GroupName    string           `json:"group_name"`
-    StartTime    time.Time        `json:"start_time"`
-    Stats        StudySessionStats `json:"stats"`
-    Words        []struct {
-        Arabic     string    `json:"arabic"`
-        Roman      string    `json:"roman"`
-        English    string    `json:"english"`
-        IsCorrect  bool      `json:"is_correct"`
-        ReviewedAt time.Time `json:"reviewed_at"`
-    } `json:"words"`
+    ID              int64                 `json:"id"`
+    ActivityName    string                `json:"activity_name"`
+    GroupName       string                `json:"group_name"`
+    Status          string                `json:"status"`
+    StartTime       time.Time             `json:"start_time"`
+    EndTime         *time.Time            `json:"end_time,omitempty"`
+    DurationSeconds int64                 `json:"duration_seconds"`
+    Stats           StudySessionStats     `json:"stats"`
+    Words           []SessionWordResponse `json:"words"`
+    // Statements holds the session's xAPI statements when requested
+    Statements []XAPISessionStatement `json:"statements,omitempty"`
 }
 
 // StudySessionStats represents statistics for a study session
@@ -33,179 +65,164 @@ type StudySessionStats struct {
 
 // StudySessionResponse represents a study session with its details
 type StudySessionResponse struct {
-    ID           int64            `json:"id"`
-    ActivityName string           `json:"activity_name"`
-    GroupName    string           `json:"group_name"`
-    StartTime    time.Time        `json:"start_time"`
-    Stats        StudySessionStats `json:"stats"`
+    ID              int64             `json:"id"`
+    ActivityName    string            `json:"activity_name"`
+    GroupName       string            `json:"group_name"`
+    Status          string            `json:"status"`
+    StartTime       time.Time         `json:"start_time"`
+    EndTime         *time.Time        `json:"end_time,omitempty"`
+    DurationSeconds int64             `json:"duration_seconds"`
+    Stats           StudySessionStats `json:"stats"`
 }
 
-// GetStudySessions returns a paginated list of study sessions
-func GetStudySessions(page, perPage int) ([]StudySessionResponse, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, nil, fmt.Errorf("database connection not initialized")
+// GetStudySessions returns a page of a learner's study sessions, starting at a
+// cursor, or at the start of the list when cursor is empty
+func (s *Service) GetStudySessions(userID int64, opts ListOptions, cursor string, perPage int) ([]StudySessionResponse, *models.CursorPagination, error) {
+    if err := sessionListSpec.validate(opts); err != nil {
+        return nil, nil, err
     }
+    return s.Sessions.List(userID, opts, cursor, perPage)
+}
 
-    offset := (page - 1) * perPage
+// CreateStudySessionRequest represents the request to start a study session
+type CreateStudySessionRequest struct {
+    GroupID         int64 `json:"group_id" binding:"required"`
+    StudyActivityID int64 `json:"study_activity_id" binding:"required"`
+}
+
+// CreateStudySessionResponse represents a newly started study session
+type CreateStudySessionResponse struct {
+    ID              int64     `json:"id"`
+    GroupID         int64     `json:"group_id"`
+    StudyActivityID int64     `json:"study_activity_id"`
+    Status          string    `json:"status"`
+    StartTime       time.Time `json:"start_time"`
+    LaunchURL       string    `json:"launch_url"`
+    // LaunchToken is set when the activity's launch URL asks for one
+    LaunchToken          string     `json:"launch_token,omitempty"`
+    LaunchTokenExpiresAt *time.Time `json:"launch_token_expires_at,omitempty"`
+    // SessionToken authorizes writing reviews to the session
+    SessionToken          string    `json:"session_token"`
+    SessionTokenExpiresAt time.Time `json:"session_token_expires_at"`
+
+    // nonce is bound into the tokens of the session
+    nonce string
+}
 
-    // Get total count
-    var total int
-    err := db.QueryRow("SELECT COUNT(*) FROM study_sessions").Scan(&total)
+// CreateStudySession starts a learner's study session for a group and study activity
+// and resolves the activity's launch URL for it. apiBase is the absolute URL of the
+// API as seen by the client, used when Options.APIBase is not set.
+func (s *Service) CreateStudySession(groupID, activityID, userID int64, apiBase string) (*CreateStudySessionResponse, error) {
+    // Verify group exists
+    groupExists, err := s.Groups.Exists(groupID)
     if err != nil {
-        log.Printf("Error counting study sessions: %v", err)
-        return nil, nil, err
+        return nil, err
+    }
+    if !groupExists {
+        return nil, &NotFoundError{Entity: "group"}
     }
 
-    // Get sessions with their stats
-    rows, err := db.Query(`
-        SELECT 
-            ss.id,
-            sa.name as activity_name,
-            g.name as group_name,
-            ss.created_at as start_time,
-            COUNT(DISTINCT wri.word_id) as total_words,
-            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
-            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count
-        FROM study_sessions ss
-        JOIN study_activities sa ON ss.study_activity_id = sa.id
-        JOIN groups g ON ss.group_id = g.id
-        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
-        GROUP BY ss.id
-        ORDER BY ss.created_at DESC
-        LIMIT ? OFFSET ?`,
-        perPage, offset)
+    // Verify activity exists and get its launch URL
+    launchURL, err := s.Activities.LaunchURL(activityID)
     if err != nil {
-        log.Printf("Error querying study sessions: %v", err)
-        return nil, nil, err
+        return nil, err
     }
-    defer rows.Close()
-
-    var sessions []StudySessionResponse
-    for rows.Next() {
-        var s StudySessionResponse
-        err := rows.Scan(
-            &s.ID,
-            &s.ActivityName,
-            &s.GroupName,
-            &s.StartTime,
-            &s.Stats.TotalWords,
-            &s.Stats.CorrectCount,
-            &s.Stats.WrongCount,
-        )
-        if err != nil {
-            log.Printf("Error scanning session: %v", err)
-            return nil, nil, err
-        }
-        sessions = append(sessions, s)
+
+    session, err := s.Sessions.Create(groupID, activityID, userID)
+    if err != nil {
+        return nil, err
     }
 
-    pagination := &models.Pagination{
-        CurrentPage:  page,
-        ItemsPerPage: perPage,
-        TotalItems:   total,
-        TotalPages:   (total + perPage - 1) / perPage,
+    err = s.resolveSessionLaunch(session, launchURL, apiBase)
+    if err != nil {
+        logging.Errorf("Error resolving launch URL for session %d: %v", session.ID, err)
+        return nil, err
     }
 
-    return sessions, pagination, nil
+    return session, nil
+}
+
+// GetStudySession returns a single study session of a learner by ID with its details.
+// Sessions of other learners are not found.
+func (s *Service) GetStudySession(id, userID int64) (*StudySessionDetailResponse, error) {
+    return s.Sessions.Get(id, userID)
 }
 
-// GetStudySession returns a single study session by ID with its details
-func GetStudySession(id int64) (*StudySessionDetailResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
+// CompleteStudySession marks an active study session as completed
+func (s *Service) CompleteStudySession(id int64) (*StudySessionDetailResponse, error) {
+    if _, err := s.AbandonIdleSessions(); err != nil {
+        return nil, err
     }
 
-    var session StudySessionDetailResponse
-
-    // Get session details
-    err := db.QueryRow(`
-        SELECT 
-            ss.id,
-            sa.name as activity_name,
-            g.name as group_name,
-            ss.created_at as start_time,
-            COUNT(DISTINCT wri.word_id) as total_words,
-            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
-            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count
-        FROM study_sessions ss
-        JOIN study_activities sa ON ss.study_activity_id = sa.id
-        JOIN groups g ON ss.group_id = g.id
-        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
-        WHERE ss.id = ?
-        GROUP BY ss.id`,
-        id).Scan(
-            &session.ID,
-            &session.ActivityName,
-            &session.GroupName,
-            &session.StartTime,
-            &session.Stats.TotalWords,
-            &session.Stats.CorrectCount,
-            &session.Stats.WrongCount,
-        )
+    state, err := s.Sessions.State(id)
     if err != nil {
-        if err == sql.ErrNoRows {
-            return nil, err
-        }
-        log.Printf("Error getting study session %d: %v", id, err)
         return nil, err
     }
+    if state.Status != SessionStatusActive {
+        return nil, errSessionNotActive
+    }
 
-    // Get reviewed words
-    rows, err := db.Query(`
-        SELECT 
-            w.arabic,
-            w.roman,
-            w.english,
-            wri.correct as is_correct,
-            wri.created_at as reviewed_at
-        FROM word_review_items wri
-        JOIN words w ON wri.word_id = w.id
-        WHERE wri.study_session_id = ?
-        ORDER BY wri.created_at`,
-        id)
-    if err != nil {
-        log.Printf("Error getting session words: %v", err)
+    if err := s.Sessions.Complete(id); err != nil {
         return nil, err
     }
-    defer rows.Close()
-
-    for rows.Next() {
-        var word struct {
-            Arabic     string    `json:"arabic"`
-            Roman      string    `json:"roman"`
-            English    string    `json:"english"`
-            IsCorrect  bool      `json:"is_correct"`
-            ReviewedAt time.Time `json:"reviewed_at"`
+
+    return s.GetStudySession(id, state.UserID)
+}
+
+// AbandonIdleSessions marks active sessions that have had no activity for longer than
+// Options.SessionIdleTimeout as abandoned, ending them at their last review. It returns the
+// number of sessions abandoned.
+func (s *Service) AbandonIdleSessions() (int64, error) {
+    return s.Sessions.AbandonIdle(s.opts.SessionIdleTimeout)
+}
+
+// WatchIdleSessions abandons idle sessions every interval until ctx is done. It is
+// meant to be run in its own goroutine.
+func (s *Service) WatchIdleSessions(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
         }
-        err := rows.Scan(
-            &word.Arabic,
-            &word.Roman,
-            &word.English,
-            &word.IsCorrect,
-            &word.ReviewedAt,
-        )
+
+        count, err := s.AbandonIdleSessions()
         if err != nil {
-            log.Printf("Error scanning word: %v", err)
-            return nil, err
+            continue
+        }
+        if count > 0 {
+            logging.Infof("Abandoned %d idle study sessions", count)
         }
-        session.Words = append(session.Words, word)
     }
-
-    return &session, nil
 }
 
-// ResetHistory deletes all study sessions and word reviews
-func ResetHistory() error {
-    db := GetDB()
-    if db == nil {
-        return fmt.Errorf("database connection not initialized")
-    }
+// ResetHistory deletes all study sessions and word reviews of every learner. User
+// accounts are kept.
+func (s *Service) ResetHistory() error {
+    db := s.db
 
     tx, err := db.Begin()
     if err != nil {
-        log.Printf("Error starting transaction: %v", err)
+        logging.Errorf("Error starting transaction: %v", err)
+        return err
+    }
+
+    // Forget review schedules, which are derived from the history
+    _, err = tx.Exec("DELETE FROM word_schedules")
+    if err != nil {
+        tx.Rollback()
+        logging.Errorf("Error deleting word schedules: %v", err)
+        return err
+    }
+
+    // Delete xAPI statements, which are part of the learning record
+    _, err = tx.Exec("DELETE FROM xapi_statements")
+    if err != nil {
+        tx.Rollback()
+        logging.Errorf("Error deleting xAPI statements: %v", err)
         return err
     }
 
@@ -213,7 +230,7 @@ func ResetHistory() error {
     _, err = tx.Exec("DELETE FROM word_review_items")
     if err != nil {
         tx.Rollback()
-        log.Printf("Error deleting word reviews: %v", err)
+        logging.Errorf("Error deleting word reviews: %v", err)
         return err
     }
 
@@ -221,12 +238,12 @@ func ResetHistory() error {
     _, err = tx.Exec("DELETE FROM study_sessions")
     if err != nil {
         tx.Rollback()
-        log.Printf("Error deleting study sessions: %v", err)
+        logging.Errorf("Error deleting study sessions: %v", err)
         return err
     }
 
     if err := tx.Commit(); err != nil {
-        log.Printf("Error committing transaction: %v", err)
+        logging.Errorf("Error committing transaction: %v", err)
         return err
     }
 
@@ -234,22 +251,26 @@ func ResetHistory() error {
 }
 
 // FullReset deletes all data and resets the database to initial state
-func FullReset() error {
-    db := GetDB()
-    if db == nil {
-        return fmt.Errorf("database connection not initialized")
-    }
+func (s *Service) FullReset() error {
+    db := s.db
 
     tx, err := db.Begin()
     if err != nil {
-        log.Printf("Error starting transaction: %v", err)
+        logging.Errorf("Error starting transaction: %v", err)
         return err
     }
 
     // Delete all data in reverse order of dependencies
     tables := []string{
+        "xapi_statements",
+        "word_schedules",
         "word_review_items",
         "study_sessions",
+        "login_sessions",
+        "assignments",
+        "class_members",
+        "classes",
+        "users",
         "words_groups",
         "words",
         "groups",
@@ -260,7 +281,7 @@ func FullReset() error {
         _, err = tx.Exec("DELETE FROM " + table)
         if err != nil {
             tx.Rollback()
-            log.Printf("Error deleting from %s: %v", table, err)
+            logging.Errorf("Error deleting from %s: %v", table, err)
             return err
         }
 
@@ -268,12 +289,12 @@ func FullReset() error {
         _, err = tx.Exec("UPDATE SQLITE_SEQUENCE SET SEQ=0 WHERE NAME=?", table)
         if err != nil {
             // Ignore errors here as the table might not exist
-            log.Printf("Note: Could not reset sequence for %s (this is usually OK)", table)
+            logging.Infof("Note: Could not reset sequence for %s (this is usually OK)", table)
         }
     }
 
     if err := tx.Commit(); err != nil {
-        log.Printf("Error committing transaction: %v", err)
+        logging.Errorf("Error committing transaction: %v", err)
         return err
     }
 
@@ -282,157 +303,117 @@ func FullReset() error {
 
 // SessionWordResponse represents a word in a study session
 type SessionWordResponse struct {
-    Arabic     string    `json:"arabic"`
-    Roman      string    `json:"roman"`
-    English    string    `json:"english"`
-    IsCorrect  bool      `json:"is_correct"`
-    ReviewedAt time.Time `json:"reviewed_at"`
+    WordID         int64     `json:"word_id"`
+    Arabic         string    `json:"arabic"`
+    Roman          string    `json:"roman"`
+    English        string    `json:"english"`
+    IsCorrect      bool      `json:"is_correct"`
+    Grade          *int      `json:"grade,omitempty"`
+    Answer         *string   `json:"answer,omitempty"`
+    Direction      *string   `json:"direction,omitempty"`
+    ResponseTimeMs *int64    `json:"response_time_ms,omitempty"`
+    ReviewedAt     time.Time `json:"reviewed_at"`
 }
 
-// CreateWordReviewRequest represents the request to create a word review
+// CreateWordReviewRequest represents the request to create a word review. Either
+// a grade or is_correct must be given; the other fields are optional.
 type CreateWordReviewRequest struct {
-    IsCorrect bool `json:"is_correct" binding:"required"`
+    IsCorrect      *bool        `json:"is_correct"`
+    Grade          *ReviewGrade `json:"grade"`
+    Answer         string       `json:"answer"`
+    Direction      string       `json:"direction"`
+    ResponseTimeMs *int64       `json:"response_time_ms"`
 }
 
 // CreateWordReviewResponse represents the response after creating a word review
 type CreateWordReviewResponse struct {
-    ID         int64     `json:"id"`
-    WordID     int64     `json:"word_id"`
-    SessionID  int64     `json:"session_id"`
-    IsCorrect  bool      `json:"is_correct"`
-    ReviewedAt time.Time `json:"reviewed_at"`
+    ID             int64     `json:"id"`
+    WordID         int64     `json:"word_id"`
+    SessionID      int64     `json:"session_id"`
+    IsCorrect      bool      `json:"is_correct"`
+    Grade          int       `json:"grade"`
+    Answer         *string   `json:"answer,omitempty"`
+    Direction      *string   `json:"direction,omitempty"`
+    ResponseTimeMs *int64    `json:"response_time_ms,omitempty"`
+    ReviewedAt     time.Time `json:"reviewed_at"`
 }
 
-// GetStudySessionWords returns all words reviewed in a study session
-func GetStudySessionWords(sessionID int64, page, perPage int) ([]SessionWordResponse, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, nil, fmt.Errorf("database connection not initialized")
-    }
-
-    offset := (page - 1) * perPage
-
-    // Get total count
-    var total int
-    err := db.QueryRow(`
-        SELECT COUNT(*)
-        FROM word_review_items
-        WHERE study_session_id = ?`,
-        sessionID).Scan(&total)
-    if err != nil {
-        log.Printf("Error counting session words: %v", err)
-        return nil, nil, err
-    }
-
-    // Get words with their review status
-    rows, err := db.Query(`
-        SELECT 
+// sessionWordColumns selects a reviewed word in the order scanned by scanSessionWord
+const sessionWordColumns = `
+            w.id,
             w.arabic,
             w.roman,
             w.english,
             wri.correct as is_correct,
-            wri.created_at as reviewed_at
-        FROM word_review_items wri
-        JOIN words w ON wri.word_id = w.id
-        WHERE wri.study_session_id = ?
-        ORDER BY wri.created_at
-        LIMIT ? OFFSET ?`,
-        sessionID, perPage, offset)
-    if err != nil {
-        log.Printf("Error querying session words: %v", err)
-        return nil, nil, err
-    }
-    defer rows.Close()
-
-    var words []SessionWordResponse
-    for rows.Next() {
-        var w SessionWordResponse
-        err := rows.Scan(
-            &w.Arabic,
-            &w.Roman,
-            &w.English,
-            &w.IsCorrect,
-            &w.ReviewedAt,
-        )
-        if err != nil {
-            log.Printf("Error scanning word: %v", err)
-            return nil, nil, err
-        }
-        words = append(words, w)
-    }
+            wri.grade,
+            wri.answer,
+            wri.direction,
+            wri.response_time_ms,
+            wri.created_at as reviewed_at`
+
+// scanSessionWord scans a row selected with sessionWordColumns, followed by any
+// further columns into extra
+func scanSessionWord(rows *sql.Rows, extra ...interface{}) (SessionWordResponse, error) {
+    var w SessionWordResponse
+    dest := []interface{}{
+        &w.WordID,
+        &w.Arabic,
+        &w.Roman,
+        &w.English,
+        &w.IsCorrect,
+        &w.Grade,
+        &w.Answer,
+        &w.Direction,
+        &w.ResponseTimeMs,
+        &w.ReviewedAt,
+    }
+    err := rows.Scan(append(dest, extra...)...)
+    return w, err
+}
 
-    pagination := &models.Pagination{
-        CurrentPage:  page,
-        ItemsPerPage: perPage,
-        TotalItems:   total,
-        TotalPages:   (total + perPage - 1) / perPage,
+// GetStudySessionWords returns a page of the words reviewed in a learner's study
+// session, starting at a cursor, or at the first review when cursor is empty
+func (s *Service) GetStudySessionWords(sessionID, userID int64, opts ListOptions, cursor string, perPage int) ([]SessionWordResponse, *models.CursorPagination, error) {
+    if err := sessionWordListSpec.validate(opts); err != nil {
+        return nil, nil, err
     }
-
-    return words, pagination, nil
+    return s.Sessions.ListWords(sessionID, userID, opts, cursor, perPage)
 }
 
 // CreateWordReview creates a new word review for a study session
-func CreateWordReview(sessionID, wordID int64, req *CreateWordReviewRequest) (*CreateWordReviewResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
-
-    // Verify session exists
-    var sessionExists bool
-    err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM study_sessions WHERE id = ?)", sessionID).Scan(&sessionExists)
+func (s *Service) CreateWordReview(sessionID, wordID int64, req *CreateWordReviewRequest) (*CreateWordReviewResponse, error) {
+    grade, correct, err := resolveReview(req)
     if err != nil {
-        log.Printf("Error checking session existence: %v", err)
-        return nil, err
-    }
-    if !sessionExists {
-        return nil, fmt.Errorf("session not found")
+        return nil, invalid(err, "review")
     }
 
-    // Verify word exists
-    var wordExists bool
-    err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM words WHERE id = ?)", wordID).Scan(&wordExists)
-    if err != nil {
-        log.Printf("Error checking word existence: %v", err)
-        return nil, err
-    }
-    if !wordExists {
-        return nil, fmt.Errorf("word not found")
-    }
-
-    // Create the review
-    result, err := db.Exec(`
-        INSERT INTO word_review_items (word_id, study_session_id, correct, created_at)
-        VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
-        wordID, sessionID, req.IsCorrect)
-    if err != nil {
-        log.Printf("Error creating word review: %v", err)
+    if _, err := s.AbandonIdleSessions(); err != nil {
         return nil, err
     }
 
-    reviewID, err := result.LastInsertId()
+    // Verify session exists; Reviews.Create checks it is still active
+    state, err := s.Sessions.State(sessionID)
     if err != nil {
-        log.Printf("Error getting last insert ID: %v", err)
         return nil, err
     }
 
-    // Get the created review
-    var review CreateWordReviewResponse
-    err = db.QueryRow(`
-        SELECT id, word_id, study_session_id, correct, created_at
-        FROM word_review_items
-        WHERE id = ?`,
-        reviewID).Scan(
-        &review.ID,
-        &review.WordID,
-        &review.SessionID,
-        &review.IsCorrect,
-        &review.ReviewedAt,
-    )
+    // Verify word exists
+    wordExists, err := s.Words.Exists(wordID)
     if err != nil {
-        log.Printf("Error getting created review: %v", err)
         return nil, err
     }
-
-    return &review, nil
+    if !wordExists {
+        return nil, &NotFoundError{Entity: "word"}
+    }
+
+    return s.Reviews.Create(&WordReview{
+        SessionID:      sessionID,
+        WordID:         wordID,
+        UserID:         state.UserID,
+        Grade:          grade,
+        Correct:        correct,
+        Answer:         req.Answer,
+        Direction:      req.Direction,
+        ResponseTimeMs: req.ResponseTimeMs,
+    })
 }
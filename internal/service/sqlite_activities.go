@@ -0,0 +1,253 @@
+package service
+
+import (
+    "database/sql"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// sqliteActivityRepository is the ActivityRepository stored in the study_activities table
+type sqliteActivityRepository struct {
+    db *sql.DB
+}
+
+func (r *sqliteActivityRepository) List(userID int64, opts ListOptions, limit, offset int) ([]ActivityResponse, int, error) {
+    list := activityListSpec.clauses(opts)
+    query := `
+        SELECT
+            sa.id,
+            sa.name,
+            sa.thumbnail_url,
+            sa.description,
+            sa.launch_url,
+            COUNT(DISTINCT ss.id) as total_sessions,
+            COUNT(wri.id) as total_reviews,
+            COALESCE(` + reviewAccuracySQL + `, 0) as accuracy_rate
+        FROM study_activities sa
+        LEFT JOIN study_sessions ss ON sa.id = ss.study_activity_id AND ss.user_id IS ?
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id` +
+        list.where() + `
+        GROUP BY sa.id` + list.havingClause()
+    args := list.args(userScope(userID))
+
+    // Get total count
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting activities: %v", err)
+        return nil, 0, err
+    }
+
+    // Get activities with their stats
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying activities: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    activities := []ActivityResponse{}
+    for rows.Next() {
+        var a ActivityResponse
+        err := rows.Scan(
+            &a.ID,
+            &a.Name,
+            &a.ThumbnailURL,
+            &a.Description,
+            &a.LaunchURL,
+            &a.Stats.TotalSessions,
+            &a.Stats.TotalWordsReviewed,
+            &a.Stats.AccuracyRate,
+        )
+        if err != nil {
+            logging.Errorf("Error scanning activity: %v", err)
+            return nil, 0, err
+        }
+        activities = append(activities, a)
+    }
+
+    return activities, total, rows.Err()
+}
+
+func (r *sqliteActivityRepository) Get(id, userID int64) (*ActivityDetailResponse, error) {
+    var activity ActivityDetailResponse
+
+    // Get activity details with overall stats
+    err := r.db.QueryRow(`
+        SELECT
+            sa.id,
+            sa.name,
+            sa.thumbnail_url,
+            sa.description,
+            sa.launch_url,
+            COUNT(DISTINCT ss.id) as total_sessions,
+            COUNT(wri.id) as total_reviews,
+            COALESCE(
+                SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) * 100.0 /
+                NULLIF(COUNT(wri.id), 0),
+                0
+            ) as accuracy_rate
+        FROM study_activities sa
+        LEFT JOIN study_sessions ss ON sa.id = ss.study_activity_id AND ss.user_id IS ?
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+        WHERE sa.id = ?
+        GROUP BY sa.id`,
+        userScope(userID), id).Scan(
+            &activity.ID,
+            &activity.Name,
+            &activity.ThumbnailURL,
+            &activity.Description,
+            &activity.LaunchURL,
+            &activity.Stats.TotalSessions,
+            &activity.Stats.TotalWordsReviewed,
+            &activity.Stats.AccuracyRate,
+        )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "activity"}
+        }
+        logging.Errorf("Error getting activity %d: %v", id, err)
+        return nil, err
+    }
+
+    // Get recent sessions (last 5)
+    rows, err := r.db.Query(`
+        SELECT
+            ss.id,
+            g.name as group_name,
+            ss.created_at as start_time,
+            COUNT(DISTINCT wri.word_id) as total_words,
+            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
+            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count
+        FROM study_sessions ss
+        JOIN groups g ON ss.group_id = g.id
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+        WHERE ss.study_activity_id = ? AND ss.user_id IS ?
+        GROUP BY ss.id
+        ORDER BY ss.created_at DESC
+        LIMIT 5`,
+        id, userScope(userID))
+    if err != nil {
+        logging.Errorf("Error getting recent sessions: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    activity.RecentSessions = []RecentSession{}
+    for rows.Next() {
+        var session RecentSession
+        err := rows.Scan(
+            &session.ID,
+            &session.GroupName,
+            &session.StartTime,
+            &session.Stats.TotalWords,
+            &session.Stats.CorrectCount,
+            &session.Stats.WrongCount,
+        )
+        if err != nil {
+            logging.Errorf("Error scanning session: %v", err)
+            return nil, err
+        }
+        activity.RecentSessions = append(activity.RecentSessions, session)
+    }
+
+    return &activity, nil
+}
+
+func (r *sqliteActivityRepository) Exists(id int64) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM study_activities WHERE id = ?)", id).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking activity existence: %v", err)
+    }
+    return exists, err
+}
+
+func (r *sqliteActivityRepository) ListSessions(activityID, userID int64, opts ListOptions, limit, offset int) ([]ActivitySessionResponse, int, error) {
+    list := activitySessionListSpec.clauses(opts)
+    query := `
+        SELECT
+            ss.id,
+            g.name as group_name,
+            ss.status,
+            ss.created_at as start_time,
+            ss.ended_at as end_time,
+            ` + sessionDurationSQL + ` as duration_seconds,
+            COUNT(DISTINCT wri.word_id) as total_words,
+            SUM(CASE WHEN wri.correct = 1 THEN 1 ELSE 0 END) as correct_count,
+            SUM(CASE WHEN wri.correct = 0 THEN 1 ELSE 0 END) as wrong_count
+        FROM study_sessions ss
+        JOIN groups g ON ss.group_id = g.id
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id` +
+        list.where("ss.study_activity_id = ?", "ss.user_id IS ?") + `
+        GROUP BY ss.id` + list.havingClause()
+    args := list.args(activityID, userScope(userID))
+
+    // Get total count
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting activity sessions: %v", err)
+        return nil, 0, err
+    }
+
+    // Get sessions with stats
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying activity sessions: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    sessions := []ActivitySessionResponse{}
+    for rows.Next() {
+        var s ActivitySessionResponse
+        err := rows.Scan(
+            &s.ID,
+            &s.GroupName,
+            &s.Status,
+            &s.StartTime,
+            &s.EndTime,
+            &s.DurationSeconds,
+            &s.Stats.TotalWords,
+            &s.Stats.CorrectCount,
+            &s.Stats.WrongCount,
+        )
+        if err != nil {
+            logging.Errorf("Error scanning session: %v", err)
+            return nil, 0, err
+        }
+        sessions = append(sessions, s)
+    }
+
+    return sessions, total, rows.Err()
+}
+
+func (r *sqliteActivityRepository) LaunchURL(id int64) (string, error) {
+    var launchURL sql.NullString
+    err := r.db.QueryRow("SELECT launch_url FROM study_activities WHERE id = ?", id).Scan(&launchURL)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return "", &NotFoundError{Entity: "activity"}
+        }
+        logging.Errorf("Error getting launch URL of activity %d: %v", id, err)
+        return "", err
+    }
+    return launchURL.String, nil
+}
+
+func (r *sqliteActivityRepository) Create(req *CreateActivityRequest) (int64, error) {
+    result, err := r.db.Exec(`
+        INSERT INTO study_activities (name, thumbnail_url, description, launch_url)
+        VALUES (?, ?, ?, ?)`,
+        req.Name, req.ThumbnailURL, req.Description, req.LaunchURL)
+    if err != nil {
+        logging.Errorf("Error creating activity: %v", err)
+        return 0, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return 0, err
+    }
+    return id, nil
+}
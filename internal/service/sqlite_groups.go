@@ -0,0 +1,373 @@
+package service
+
+import (
+    "database/sql"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// sqliteGroupRepository is the GroupRepository stored in the groups and words_groups tables
+type sqliteGroupRepository struct {
+    db *sql.DB
+}
+
+func (r *sqliteGroupRepository) List(opts ListOptions, limit, offset int) ([]GroupResponse, int, error) {
+    list := groupListSpec.clauses(opts)
+    query := `
+        SELECT
+            g.id,
+            g.name,
+            (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
+        FROM groups g` + list.where()
+    args := list.args()
+
+    // Get total count
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting groups: %v", err)
+        return nil, 0, err
+    }
+
+    // Get groups with word count
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying groups: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    groups := []GroupResponse{}
+    for rows.Next() {
+        var g GroupResponse
+        if err := rows.Scan(&g.ID, &g.Name, &g.WordCount); err != nil {
+            logging.Errorf("Error scanning group: %v", err)
+            return nil, 0, err
+        }
+        groups = append(groups, g)
+    }
+
+    return groups, total, rows.Err()
+}
+
+func (r *sqliteGroupRepository) Get(id int64) (*GroupDetailResponse, error) {
+    var group GroupDetailResponse
+    err := r.db.QueryRow(`
+        SELECT
+            g.id,
+            g.name,
+            (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
+        FROM groups g
+        WHERE g.id = ?`, id).Scan(&group.ID, &group.Name, &group.Stats.TotalWordCount)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "group"}
+        }
+        logging.Errorf("Error getting group %d: %v", id, err)
+        return nil, err
+    }
+
+    return &group, nil
+}
+
+func (r *sqliteGroupRepository) Exists(id int64) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM groups WHERE id = ?)", id).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking group existence: %v", err)
+    }
+    return exists, err
+}
+
+func (r *sqliteGroupRepository) ListWords(groupID, userID int64, opts ListOptions, limit, offset int) ([]WordWithStats, int, error) {
+    list := groupWordListSpec.clauses(opts)
+    query := `
+        SELECT
+            w.id,
+            w.arabic,
+            w.roman,
+            w.english,
+            COALESCE(correct.count, 0) as correct_count,
+            COALESCE(wrong.count, 0) as wrong_count
+        FROM words w
+        JOIN words_groups wg ON w.id = wg.word_id
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 1 AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) correct ON w.id = correct.word_id
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 0 AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) wrong ON w.id = wrong.word_id` + list.where("wg.group_id = ?")
+    args := list.args(userScope(userID), userScope(userID), groupID)
+
+    // Get total count
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting group words: %v", err)
+        return nil, 0, err
+    }
+
+    // Get words with their stats
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying group words: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    words, err := scanWordsWithStats(rows)
+    if err != nil {
+        return nil, 0, err
+    }
+    return words, total, nil
+}
+
+func (r *sqliteGroupRepository) ListSessions(groupID, userID int64, opts ListOptions, limit, offset int) ([]GroupStudySession, int, error) {
+    list := groupSessionListSpec.clauses(opts)
+    query := `
+        SELECT
+            ss.id,
+            sa.name as activity_name,
+            g.name as group_name,
+            ss.status,
+            ss.created_at as start_time,
+            ss.ended_at as end_time,
+            (SELECT COUNT(*) FROM word_review_items wri WHERE wri.study_session_id = ss.id) as review_count
+        FROM study_sessions ss
+        JOIN study_activities sa ON ss.study_activity_id = sa.id
+        JOIN groups g ON ss.group_id = g.id` + list.where("ss.group_id = ?", "ss.user_id IS ?")
+    args := list.args(groupID, userScope(userID))
+
+    // Get total count
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting group study sessions: %v", err)
+        return nil, 0, err
+    }
+
+    // Get study sessions with related data
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying group study sessions: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    sessions := []GroupStudySession{}
+    for rows.Next() {
+        var s GroupStudySession
+        if err := rows.Scan(
+            &s.ID,
+            &s.ActivityName,
+            &s.GroupName,
+            &s.Status,
+            &s.StartTime,
+            &s.EndTime,
+            &s.ReviewItemsCount,
+        ); err != nil {
+            logging.Errorf("Error scanning study session: %v", err)
+            return nil, 0, err
+        }
+        sessions = append(sessions, s)
+    }
+
+    return sessions, total, rows.Err()
+}
+
+func (r *sqliteGroupRepository) FindByName(name string, excludeID int64) (int64, error) {
+    return findGroupByName(r.db, name, excludeID)
+}
+
+func (r *sqliteGroupRepository) Create(name string) (int64, error) {
+    result, err := r.db.Exec("INSERT INTO groups (name) VALUES (?)", name)
+    if err != nil {
+        logging.Errorf("Error creating group: %v", err)
+        return 0, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return 0, err
+    }
+    return id, nil
+}
+
+func (r *sqliteGroupRepository) Rename(id int64, name string) error {
+    result, err := r.db.Exec("UPDATE groups SET name = ? WHERE id = ?", name, id)
+    if err != nil {
+        logging.Errorf("Error updating group %d: %v", id, err)
+        return err
+    }
+
+    updated, err := result.RowsAffected()
+    if err != nil {
+        logging.Errorf("Error getting affected rows: %v", err)
+        return err
+    }
+    if updated == 0 {
+        return &NotFoundError{Entity: "group"}
+    }
+    return nil
+}
+
+func (r *sqliteGroupRepository) Delete(id int64) (*DeleteGroupResponse, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM groups WHERE id = ?)", id).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking group existence: %v", err)
+        return nil, err
+    }
+    if !exists {
+        return nil, &NotFoundError{Entity: "group"}
+    }
+
+    var sessions int
+    err = tx.QueryRow("SELECT COUNT(*) FROM study_sessions WHERE group_id = ?", id).Scan(&sessions)
+    if err != nil {
+        logging.Errorf("Error counting group study sessions: %v", err)
+        return nil, err
+    }
+    if sessions > 0 {
+        return nil, &ConflictError{
+            Entity:  "group",
+            Reason:  ReasonHasSessions,
+            Message: "Group has study sessions and cannot be deleted",
+        }
+    }
+
+    var assignments int
+    err = tx.QueryRow("SELECT COUNT(*) FROM assignments WHERE group_id = ?", id).Scan(&assignments)
+    if err != nil {
+        logging.Errorf("Error counting group assignments: %v", err)
+        return nil, err
+    }
+    if assignments > 0 {
+        return nil, &ConflictError{
+            Entity:  "group",
+            Reason:  ReasonHasAssignments,
+            Message: "Group is assigned to a class and cannot be deleted",
+        }
+    }
+
+    response := &DeleteGroupResponse{ID: id}
+
+    result, err := tx.Exec("DELETE FROM words_groups WHERE group_id = ?", id)
+    if err != nil {
+        logging.Errorf("Error deleting word memberships of group %d: %v", id, err)
+        return nil, err
+    }
+    if response.RemovedWordMemberships, err = result.RowsAffected(); err != nil {
+        return nil, err
+    }
+
+    if _, err = tx.Exec("DELETE FROM groups WHERE id = ?", id); err != nil {
+        logging.Errorf("Error deleting group %d: %v", id, err)
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return response, nil
+}
+
+func (r *sqliteGroupRepository) ChangeWords(groupID int64, wordIDs []int64, add bool) (*GroupWordsResponse, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM groups WHERE id = ?)", groupID).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking group existence: %v", err)
+        return nil, err
+    }
+    if !exists {
+        return nil, &NotFoundError{Entity: "group"}
+    }
+
+    response := &GroupWordsResponse{
+        GroupID:   groupID,
+        Unchanged: []int64{},
+        NotFound:  []int64{},
+    }
+    seen := make(map[int64]bool)
+
+    for _, wordID := range wordIDs {
+        if seen[wordID] {
+            continue
+        }
+        seen[wordID] = true
+
+        var wordExists bool
+        err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM words WHERE id = ?)", wordID).Scan(&wordExists)
+        if err != nil {
+            logging.Errorf("Error checking word existence: %v", err)
+            return nil, err
+        }
+        if !wordExists {
+            response.NotFound = append(response.NotFound, wordID)
+            continue
+        }
+
+        var result sql.Result
+        if add {
+            result, err = tx.Exec(
+                "INSERT OR IGNORE INTO words_groups (word_id, group_id) VALUES (?, ?)",
+                wordID, groupID)
+        } else {
+            result, err = tx.Exec(
+                "DELETE FROM words_groups WHERE word_id = ? AND group_id = ?",
+                wordID, groupID)
+        }
+        if err != nil {
+            logging.Errorf("Error changing membership of word %d in group %d: %v", wordID, groupID, err)
+            return nil, err
+        }
+
+        changed, err := result.RowsAffected()
+        if err != nil {
+            return nil, err
+        }
+        switch {
+        case changed == 0:
+            response.Unchanged = append(response.Unchanged, wordID)
+        case add:
+            response.Added = append(response.Added, wordID)
+        default:
+            response.Removed = append(response.Removed, wordID)
+        }
+    }
+
+    err = tx.QueryRow("SELECT COUNT(*) FROM words_groups WHERE group_id = ?", groupID).Scan(&response.WordCount)
+    if err != nil {
+        logging.Errorf("Error counting group words: %v", err)
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return response, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+)
+
+// sqliteReviewRepository is the ReviewRepository stored in the word_review_items table
+type sqliteReviewRepository struct {
+    db        *sql.DB
+    scheduler srs.Scheduler
+}
+
+func (r *sqliteReviewRepository) Create(review *WordReview) (*CreateWordReviewResponse, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    // Check the session in the transaction, so it cannot be completed or abandoned
+    // before the review is written
+    var status string
+    err = tx.QueryRow("SELECT status FROM study_sessions WHERE id = ?", review.SessionID).Scan(&status)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            logging.Errorf("Error getting status of session %d: %v", review.SessionID, err)
+        }
+        return nil, notFound(err, "session")
+    }
+    if status != SessionStatusActive {
+        return nil, errSessionNotActive
+    }
+
+    created, err := insertReview(tx, r.scheduler, review)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return created, nil
+}
+
+// insertReview records a review and reschedules the word for the learner with
+// scheduler, as part of the caller's transaction
+func insertReview(tx queryer, scheduler srs.Scheduler, review *WordReview) (*CreateWordReviewResponse, error) {
+    // Create the review
+    result, err := tx.Exec(`
+        INSERT INTO word_review_items (
+            word_id, study_session_id, correct, grade, answer, direction, response_time_ms, created_at
+        )
+        VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+        review.WordID, review.SessionID, review.Correct, review.Grade,
+        sql.NullString{String: review.Answer, Valid: review.Answer != ""},
+        sql.NullString{String: review.Direction, Valid: review.Direction != ""},
+        review.ResponseTimeMs)
+    if err != nil {
+        logging.Errorf("Error creating word review: %v", err)
+        return nil, err
+    }
+
+    reviewID, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return nil, err
+    }
+
+    // Reschedule the word based on the grade
+    if err := scheduleReview(tx, scheduler, review.UserID, review.WordID, review.Grade, time.Now()); err != nil {
+        return nil, err
+    }
+
+    // Get the created review
+    var created CreateWordReviewResponse
+    err = tx.QueryRow(`
+        SELECT id, word_id, study_session_id, correct, grade, answer, direction, response_time_ms, created_at
+        FROM word_review_items
+        WHERE id = ?`,
+        reviewID).Scan(
+        &created.ID,
+        &created.WordID,
+        &created.SessionID,
+        &created.IsCorrect,
+        &created.Grade,
+        &created.Answer,
+        &created.Direction,
+        &created.ResponseTimeMs,
+        &created.ReviewedAt,
+    )
+    if err != nil {
+        logging.Errorf("Error getting created review: %v", err)
+        return nil, err
+    }
+
+    return &created, nil
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
+)
+
+// sqliteSessionRepository is the SessionRepository stored in the study_sessions table
+type sqliteSessionRepository struct {
+    db *sql.DB
+}
+
+func (r *sqliteSessionRepository) List(userID int64, opts ListOptions, cursor string, limit int) ([]StudySessionResponse, *models.CursorPagination, error) {
+    page, err := sessionListSpec.page(opts, cursor, limit)
+    if err != nil {
+        return nil, nil, err
+    }
+    list := page.clauses(opts)
+    query := `
+        SELECT
+            ss.id,
+            sa.name as activity_name,
+            g.name as group_name,
+            ss.status,
+            ss.created_at as start_time,
+            ss.ended_at as end_time,
+            ` + sessionDurationSQL + ` as duration_seconds,
+            ` + sessionStatsSQL + page.keyColumns() + `
+        FROM study_sessions ss
+        JOIN study_activities sa ON ss.study_activity_id = sa.id
+        JOIN groups g ON ss.group_id = g.id
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id` +
+        list.where("ss.user_id IS ?") + `
+        GROUP BY ss.id` + list.havingClause() + list.orderBy
+
+    // Get sessions with their stats
+    rows, err := r.db.Query(page.query(query), list.args(userScope(userID))...)
+    if err != nil {
+        logging.Errorf("Error querying study sessions: %v", err)
+        return nil, nil, err
+    }
+    defer rows.Close()
+
+    sessions := []StudySessionResponse{}
+    var keys []listKey
+    for rows.Next() {
+        var s StudySessionResponse
+        var key listKey
+        err := rows.Scan(
+            &s.ID,
+            &s.ActivityName,
+            &s.GroupName,
+            &s.Status,
+            &s.StartTime,
+            &s.EndTime,
+            &s.DurationSeconds,
+            &s.Stats.TotalWords,
+            &s.Stats.CorrectCount,
+            &s.Stats.WrongCount,
+            &key.Key,
+            &key.ID,
+        )
+        if err != nil {
+            logging.Errorf("Error scanning session: %v", err)
+            return nil, nil, err
+        }
+        sessions = append(sessions, s)
+        keys = append(keys, key)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, nil, err
+    }
+
+    from, to, pagination := page.result(keys)
+    return sessions[from:to], pagination, nil
+}
+
+func (r *sqliteSessionRepository) Get(id, userID int64) (*StudySessionDetailResponse, error) {
+    var session StudySessionDetailResponse
+
+    // Get session details
+    err := r.db.QueryRow(`
+        SELECT
+            ss.id,
+            sa.name as activity_name,
+            g.name as group_name,
+            ss.status,
+            ss.created_at as start_time,
+            ss.ended_at as end_time,
+            ` + sessionDurationSQL + ` as duration_seconds,
+            ` + sessionStatsSQL + `
+        FROM study_sessions ss
+        JOIN study_activities sa ON ss.study_activity_id = sa.id
+        JOIN groups g ON ss.group_id = g.id
+        LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+        WHERE ss.id = ? AND ss.user_id IS ?
+        GROUP BY ss.id`,
+        id, userScope(userID)).Scan(
+            &session.ID,
+            &session.ActivityName,
+            &session.GroupName,
+            &session.Status,
+            &session.StartTime,
+            &session.EndTime,
+            &session.DurationSeconds,
+            &session.Stats.TotalWords,
+            &session.Stats.CorrectCount,
+            &session.Stats.WrongCount,
+        )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "session"}
+        }
+        logging.Errorf("Error getting study session %d: %v", id, err)
+        return nil, err
+    }
+
+    // Get reviewed words
+    rows, err := r.db.Query(`
+        SELECT ` + sessionWordColumns + `
+        FROM word_review_items wri
+        JOIN words w ON wri.word_id = w.id
+        WHERE wri.study_session_id = ?
+        ORDER BY wri.created_at`,
+        id)
+    if err != nil {
+        logging.Errorf("Error getting session words: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    session.Words = []SessionWordResponse{}
+    for rows.Next() {
+        word, err := scanSessionWord(rows)
+        if err != nil {
+            logging.Errorf("Error scanning word: %v", err)
+            return nil, err
+        }
+        session.Words = append(session.Words, word)
+    }
+    if err := rows.Err(); err != nil {
+        logging.Errorf("Error reading session words: %v", err)
+        return nil, err
+    }
+
+    return &session, nil
+}
+
+func (r *sqliteSessionRepository) State(id int64) (*SessionState, error) {
+    var state SessionState
+    var userID sql.NullInt64
+    err := r.db.QueryRow(
+        "SELECT status, group_id, study_activity_id, user_id, nonce FROM study_sessions WHERE id = ?", id).Scan(
+        &state.Status,
+        &state.GroupID,
+        &state.StudyActivityID,
+        &userID,
+        &state.Nonce,
+    )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "session"}
+        }
+        logging.Errorf("Error getting state of session %d: %v", id, err)
+        return nil, err
+    }
+    state.UserID = userID.Int64
+    return &state, nil
+}
+
+func (r *sqliteSessionRepository) ListWords(sessionID, userID int64, opts ListOptions, cursor string, limit int) ([]SessionWordResponse, *models.CursorPagination, error) {
+    page, err := sessionWordListSpec.page(opts, cursor, limit)
+    if err != nil {
+        return nil, nil, err
+    }
+    list := page.clauses(opts)
+    query := `
+        SELECT ` + sessionWordColumns + page.keyColumns() + `
+        FROM word_review_items wri
+        JOIN words w ON wri.word_id = w.id
+        JOIN study_sessions ss ON wri.study_session_id = ss.id` +
+        list.where("wri.study_session_id = ?", "ss.user_id IS ?") + list.orderBy
+
+    // Get words with their review status
+    rows, err := r.db.Query(page.query(query), list.args(sessionID, userScope(userID))...)
+    if err != nil {
+        logging.Errorf("Error querying session words: %v", err)
+        return nil, nil, err
+    }
+    defer rows.Close()
+
+    words := []SessionWordResponse{}
+    var keys []listKey
+    for rows.Next() {
+        var key listKey
+        w, err := scanSessionWord(rows, &key.Key, &key.ID)
+        if err != nil {
+            logging.Errorf("Error scanning word: %v", err)
+            return nil, nil, err
+        }
+        words = append(words, w)
+        keys = append(keys, key)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, nil, err
+    }
+
+    from, to, pagination := page.result(keys)
+    return words[from:to], pagination, nil
+}
+
+func (r *sqliteSessionRepository) Create(groupID, activityID, userID int64) (*CreateStudySessionResponse, error) {
+    result, err := r.db.Exec(`
+        INSERT INTO study_sessions (group_id, study_activity_id, user_id, status, created_at, nonce)
+        VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, lower(hex(randomblob(16))))`,
+        groupID, activityID, userScope(userID), SessionStatusActive)
+    if err != nil {
+        logging.Errorf("Error creating study session: %v", err)
+        return nil, err
+    }
+
+    sessionID, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return nil, err
+    }
+
+    // Get the created session
+    var session CreateStudySessionResponse
+    err = r.db.QueryRow(`
+        SELECT id, group_id, study_activity_id, status, created_at, nonce
+        FROM study_sessions
+        WHERE id = ?`,
+        sessionID).Scan(
+        &session.ID,
+        &session.GroupID,
+        &session.StudyActivityID,
+        &session.Status,
+        &session.StartTime,
+        &session.nonce,
+    )
+    if err != nil {
+        logging.Errorf("Error getting created session: %v", err)
+        return nil, err
+    }
+
+    return &session, nil
+}
+
+func (r *sqliteSessionRepository) Complete(id int64) error {
+    _, err := r.db.Exec(`
+        UPDATE study_sessions
+        SET status = ?, ended_at = CURRENT_TIMESTAMP
+        WHERE id = ? AND status = ?`,
+        SessionStatusCompleted, id, SessionStatusActive)
+    if err != nil {
+        logging.Errorf("Error completing session %d: %v", id, err)
+    }
+    return err
+}
+
+func (r *sqliteSessionRepository) AbandonIdle(timeout time.Duration) (int64, error) {
+    result, err := r.db.Exec(`
+        UPDATE study_sessions
+        SET status = ?,
+            ended_at = COALESCE(
+                (SELECT MAX(wri.created_at) FROM word_review_items wri WHERE wri.study_session_id = study_sessions.id),
+                created_at
+            )
+        WHERE status = ?
+        AND julianday(COALESCE(
+                (SELECT MAX(wri.created_at) FROM word_review_items wri WHERE wri.study_session_id = study_sessions.id),
+                created_at
+            )) < julianday('now') - ? / 86400.0`,
+        SessionStatusAbandoned, SessionStatusActive, timeout.Seconds())
+    if err != nil {
+        logging.Errorf("Error abandoning idle sessions: %v", err)
+        return 0, err
+    }
+
+    return result.RowsAffected()
+}
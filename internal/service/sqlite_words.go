@@ -0,0 +1,382 @@
+package service
+
+import (
+    "database/sql"
+    "encoding/json"
+    "strings"
+    "unicode"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// sqliteWordRepository is the WordRepository stored in the words table
+type sqliteWordRepository struct {
+    db *sql.DB
+}
+
+func (r *sqliteWordRepository) List(userID int64, opts ListOptions, limit, offset int) ([]WordWithStats, int, error) {
+    list := wordListSpec.clauses(opts)
+
+    // Searches join the full-text index and rank its matches first
+    from := "words w"
+    var searchArgs []interface{}
+    if opts.Search != "" {
+        match := searchExpression(opts.Search)
+        if match == "" {
+            return []WordWithStats{}, 0, nil
+        }
+        from = "words w JOIN (SELECT rowid, rank FROM words_search WHERE words_search MATCH ?) s ON s.rowid = w.id"
+        searchArgs = append(searchArgs, match)
+        if opts.Sort == "" {
+            list.orderBy = " ORDER BY s.rank, w.id"
+        }
+    }
+
+    query := `
+        SELECT
+            w.id,
+            w.arabic,
+            w.roman,
+            w.english,
+            COALESCE(correct.count, 0) as correct_count,
+            COALESCE(wrong.count, 0) as wrong_count
+        FROM ` + from + `
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 1 AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) correct ON w.id = correct.word_id
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 0 AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) wrong ON w.id = wrong.word_id` + list.where()
+    args := list.args(append(searchArgs, userScope(userID), userScope(userID))...)
+
+    // Get total count for pagination
+    total, err := countList(r.db, query, args)
+    if err != nil {
+        logging.Errorf("Error counting words: %v", err)
+        return nil, 0, err
+    }
+
+    // Get words with their stats
+    rows, err := r.db.Query(query+list.orderBy+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+    if err != nil {
+        logging.Errorf("Error querying words: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    words, err := scanWordsWithStats(rows)
+    if err != nil {
+        return nil, 0, err
+    }
+    return words, total, nil
+}
+
+// searchExpression turns a search into an FTS5 query over words_search, normalized
+// the way the index is. Searches in Arabic script match the arabic column, others
+// the transliteration or the English. Every term has to match, and the last one may
+// be the start of a word so that words are found while they are typed. It returns
+// "" when the search has no terms.
+func searchExpression(search string) string {
+    if arabic.IsArabicScript(search) {
+        terms := searchTerms(arabic.Normalize(search))
+        if terms == "" {
+            return ""
+        }
+        return "arabic : (" + terms + ")"
+    }
+
+    roman := searchTerms(arabic.NormalizeRoman(search))
+    english := searchTerms(search)
+    switch {
+    case roman == "" && english == "":
+        return ""
+    case roman == "":
+        return "english : (" + english + ")"
+    case english == "":
+        return "roman : (" + roman + ")"
+    }
+    return "roman : (" + roman + ") OR english : (" + english + ")"
+}
+
+// searchTerms quotes the words of text as FTS5 strings, the last one as a prefix
+func searchTerms(text string) string {
+    words := strings.FieldsFunc(text, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    if len(words) == 0 {
+        return ""
+    }
+
+    terms := make([]string, len(words))
+    for i, word := range words {
+        terms[i] = `"` + word + `"`
+    }
+    terms[len(terms)-1] += "*"
+    return strings.Join(terms, " ")
+}
+
+// scanWordsWithStats scans rows of id, arabic, roman, english, correct_count and wrong_count
+func scanWordsWithStats(rows *sql.Rows) ([]WordWithStats, error) {
+    words := []WordWithStats{}
+    for rows.Next() {
+        var w WordWithStats
+        if err := rows.Scan(
+            &w.ID,
+            &w.Arabic,
+            &w.Roman,
+            &w.English,
+            &w.CorrectCount,
+            &w.WrongCount,
+        ); err != nil {
+            logging.Errorf("Error scanning word: %v", err)
+            return nil, err
+        }
+        words = append(words, w)
+    }
+    return words, rows.Err()
+}
+
+func (r *sqliteWordRepository) Get(id, userID int64) (*WordDetailResponse, error) {
+    var word WordDetailResponse
+    var parts sql.NullString
+
+    // Get word details with stats
+    err := r.db.QueryRow(`
+        SELECT
+            w.id,
+            w.arabic,
+            w.roman,
+            w.english,
+            w.parts,
+            COALESCE(correct.count, 0) as correct_count,
+            COALESCE(wrong.count, 0) as wrong_count
+        FROM words w
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 1 AND wri.word_id = ? AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) correct ON w.id = correct.word_id
+        LEFT JOIN (
+            SELECT wri.word_id, COUNT(*) as count
+            FROM word_review_items wri
+            JOIN study_sessions ss ON wri.study_session_id = ss.id
+            WHERE wri.correct = 0 AND wri.word_id = ? AND ss.user_id IS ?
+            GROUP BY wri.word_id
+        ) wrong ON w.id = wrong.word_id
+        WHERE w.id = ?`,
+        id, userScope(userID), id, userScope(userID), id).Scan(
+            &word.ID,
+            &word.Arabic,
+            &word.Roman,
+            &word.English,
+            &parts,
+            &word.Stats.CorrectCount,
+            &word.Stats.WrongCount,
+        )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "word"}
+        }
+        logging.Errorf("Error getting word %d: %v", id, err)
+        return nil, err
+    }
+    if parts.Valid {
+        word.Parts = json.RawMessage(parts.String)
+    }
+
+    // Get groups for this word
+    rows, err := r.db.Query(`
+        SELECT g.name
+        FROM groups g
+        JOIN words_groups wg ON g.id = wg.group_id
+        WHERE wg.word_id = ?
+        ORDER BY g.name`,
+        id)
+    if err != nil {
+        logging.Errorf("Error getting word groups: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    word.Groups = []WordGroup{}
+    for rows.Next() {
+        var group WordGroup
+        if err := rows.Scan(&group.Name); err != nil {
+            logging.Errorf("Error scanning group: %v", err)
+            return nil, err
+        }
+        word.Groups = append(word.Groups, group)
+    }
+
+    return &word, nil
+}
+
+func (r *sqliteWordRepository) GetFields(id int64) (*CreateWordRequest, error) {
+    var word CreateWordRequest
+    var parts sql.NullString
+    err := r.db.QueryRow("SELECT arabic, roman, english, parts FROM words WHERE id = ?", id).Scan(
+        &word.Arabic,
+        &word.Roman,
+        &word.English,
+        &parts,
+    )
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, &NotFoundError{Entity: "word"}
+        }
+        logging.Errorf("Error getting word %d: %v", id, err)
+        return nil, err
+    }
+    if parts.Valid {
+        word.Parts = json.RawMessage(parts.String)
+    }
+    return &word, nil
+}
+
+func (r *sqliteWordRepository) Exists(id int64) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM words WHERE id = ?)", id).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking word existence: %v", err)
+    }
+    return exists, err
+}
+
+func (r *sqliteWordRepository) FindDuplicate(arabic, english string, excludeID int64) (int64, error) {
+    return findDuplicateWord(r.db, arabic, english, excludeID)
+}
+
+func (r *sqliteWordRepository) Create(word *CreateWordRequest) (int64, error) {
+    result, err := r.db.Exec(`
+        INSERT INTO words (arabic, roman, english, parts, arabic_normalized, roman_normalized)
+        VALUES (?, ?, ?, ?, ?, ?)`,
+        word.Arabic, word.Roman, word.English, storedParts(word),
+        arabic.Normalize(word.Arabic), arabic.NormalizeRoman(word.Roman))
+    if err != nil {
+        logging.Errorf("Error creating word: %v", err)
+        return 0, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return 0, err
+    }
+    return id, nil
+}
+
+func (r *sqliteWordRepository) Update(id int64, word *CreateWordRequest) error {
+    result, err := r.db.Exec(`
+        UPDATE words
+        SET arabic = ?, roman = ?, english = ?, parts = ?, arabic_normalized = ?, roman_normalized = ?
+        WHERE id = ?`,
+        word.Arabic, word.Roman, word.English, storedParts(word),
+        arabic.Normalize(word.Arabic), arabic.NormalizeRoman(word.Roman), id)
+    if err != nil {
+        logging.Errorf("Error updating word %d: %v", id, err)
+        return err
+    }
+
+    updated, err := result.RowsAffected()
+    if err != nil {
+        logging.Errorf("Error getting affected rows: %v", err)
+        return err
+    }
+    if updated == 0 {
+        return &NotFoundError{Entity: "word"}
+    }
+    return nil
+}
+
+func (r *sqliteWordRepository) Delete(id int64, force bool) (*DeleteWordResponse, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var exists bool
+    err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM words WHERE id = ?)", id).Scan(&exists)
+    if err != nil {
+        logging.Errorf("Error checking word existence: %v", err)
+        return nil, err
+    }
+    if !exists {
+        return nil, &NotFoundError{Entity: "word"}
+    }
+
+    var reviews int64
+    err = tx.QueryRow("SELECT COUNT(*) FROM word_review_items WHERE word_id = ?", id).Scan(&reviews)
+    if err != nil {
+        logging.Errorf("Error counting word reviews: %v", err)
+        return nil, err
+    }
+    if reviews > 0 && !force {
+        return nil, &ConflictError{
+            Entity:  "word",
+            Reason:  ReasonHasReviews,
+            Message: "Word has review history; pass force=true to delete it together with its reviews",
+        }
+    }
+
+    response := &DeleteWordResponse{ID: id}
+
+    // Delete dependent rows before the word itself. xAPI statements are kept but
+    // no longer point at the deleted reviews.
+    _, err = tx.Exec(`
+        UPDATE xapi_statements
+        SET word_review_item_id = NULL
+        WHERE word_review_item_id IN (SELECT id FROM word_review_items WHERE word_id = ?)`,
+        id)
+    if err != nil {
+        logging.Errorf("Error unlinking xAPI statements of word %d: %v", id, err)
+        return nil, err
+    }
+
+    result, err := tx.Exec("DELETE FROM word_review_items WHERE word_id = ?", id)
+    if err != nil {
+        logging.Errorf("Error deleting reviews of word %d: %v", id, err)
+        return nil, err
+    }
+    if response.RemovedReviews, err = result.RowsAffected(); err != nil {
+        return nil, err
+    }
+
+    if _, err = tx.Exec("DELETE FROM word_schedules WHERE word_id = ?", id); err != nil {
+        logging.Errorf("Error deleting schedule of word %d: %v", id, err)
+        return nil, err
+    }
+
+    result, err = tx.Exec("DELETE FROM words_groups WHERE word_id = ?", id)
+    if err != nil {
+        logging.Errorf("Error deleting group memberships of word %d: %v", id, err)
+        return nil, err
+    }
+    if response.RemovedGroupMemberships, err = result.RowsAffected(); err != nil {
+        return nil, err
+    }
+
+    if _, err = tx.Exec("DELETE FROM words WHERE id = ?", id); err != nil {
+        logging.Errorf("Error deleting word %d: %v", id, err)
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return response, nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "database/sql"
+    "encoding/hex"
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+)
+
+// LoginSessionTTL is how long a login stays valid
+var LoginSessionTTL = 30 * 24 * time.Hour
+
+// MinPasswordLength is the shortest password accepted on registration
+const MinPasswordLength = 8
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// User roles. Teachers can also study like any learner.
+const (
+    RoleLearner = "learner"
+    RoleTeacher = "teacher"
+)
+
+// User represents a learner account
+type User struct {
+    ID          int64     `json:"id"`
+    Username    string    `json:"username"`
+    DisplayName string    `json:"display_name"`
+    Role        string    `json:"role"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// RegisterRequest represents the request body for creating a learner account
+type RegisterRequest struct {
+    Username    string `json:"username" binding:"required"`
+    Password    string `json:"password" binding:"required"`
+    DisplayName string `json:"display_name"`
+}
+
+// LoginRequest represents the request body for logging in
+type LoginRequest struct {
+    Username string `json:"username" binding:"required"`
+    Password string `json:"password" binding:"required"`
+}
+
+// UserRoleRequest represents the request body for changing a user's role
+type UserRoleRequest struct {
+    Role string `json:"role" binding:"required"`
+}
+
+// LoginResponse represents a successful login
+type LoginResponse struct {
+    Token     string    `json:"token"`
+    ExpiresAt time.Time `json:"expires_at"`
+    User      *User     `json:"user"`
+}
+
+// usernameTaken is returned when the requested username is taken
+func usernameTaken(username string) error {
+    return &ConflictError{
+        Entity:  "username",
+        Reason:  ReasonTaken,
+        Message: fmt.Sprintf("username %q is already taken", username),
+    }
+}
+
+// userScope returns the value to compare study_sessions.user_id with, using
+// "user_id IS ?", for a learner. userID 0 is the guest learner, who owns the
+// sessions without a user.
+func userScope(userID int64) interface{} {
+    if userID == 0 {
+        return nil
+    }
+    return userID
+}
+
+// CreateUser registers a learner account
+func (s *Service) CreateUser(req *RegisterRequest) (*User, error) {
+    db := s.db
+
+    username := strings.TrimSpace(req.Username)
+    if !usernamePattern.MatchString(username) {
+        return nil, &ValidationError{
+            Entity:  "user",
+            Field:   "username",
+            Message: "must be 3 to 32 letters, digits, dots, dashes or underscores",
+        }
+    }
+    if len(req.Password) < MinPasswordLength {
+        return nil, &ValidationError{
+            Entity:  "user",
+            Field:   "password",
+            Message: fmt.Sprintf("must be at least %d characters", MinPasswordLength),
+        }
+    }
+    displayName := strings.TrimSpace(req.DisplayName)
+    if displayName == "" {
+        displayName = username
+    }
+
+    var taken bool
+    err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", username).Scan(&taken)
+    if err != nil {
+        logging.Errorf("Error checking username: %v", err)
+        return nil, err
+    }
+    if taken {
+        return nil, usernameTaken(username)
+    }
+
+    hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, err
+    }
+
+    result, err := db.Exec(`
+        INSERT INTO users (username, display_name, password_hash, created_at)
+        VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+        username, displayName, string(hash))
+    if err != nil {
+        logging.Errorf("Error creating user: %v", err)
+        return nil, err
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        logging.Errorf("Error getting last insert ID: %v", err)
+        return nil, err
+    }
+
+    return s.GetUser(id)
+}
+
+// GetUser returns a learner account by ID
+func (s *Service) GetUser(id int64) (*User, error) {
+    db := s.db
+
+    var user User
+    err := db.QueryRow(`
+        SELECT id, username, display_name, role, created_at
+        FROM users
+        WHERE id = ?`,
+        id).Scan(&user.ID, &user.Username, &user.DisplayName, &user.Role, &user.CreatedAt)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            logging.Errorf("Error getting user %d: %v", id, err)
+        }
+        return nil, notFound(err, "user")
+    }
+
+    return &user, nil
+}
+
+// SetUserRole makes a user a learner or a teacher
+func (s *Service) SetUserRole(id int64, role string) (*User, error) {
+    db := s.db
+
+    if role != RoleLearner && role != RoleTeacher {
+        return nil, &ValidationError{Entity: "user", Field: "role", Message: "must be learner or teacher"}
+    }
+
+    result, err := db.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+    if err != nil {
+        logging.Errorf("Error setting role of user %d: %v", id, err)
+        return nil, err
+    }
+    updated, err := result.RowsAffected()
+    if err != nil {
+        logging.Errorf("Error getting affected rows: %v", err)
+        return nil, err
+    }
+    if updated == 0 {
+        return nil, &NotFoundError{Entity: "user"}
+    }
+
+    return s.GetUser(id)
+}
+
+// isTeacher reports whether a user has the teacher role
+func isTeacher(q queryer, userID int64) (bool, error) {
+    var teacher bool
+    err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ? AND role = ?)", userID, RoleTeacher).Scan(&teacher)
+    if err != nil {
+        logging.Errorf("Error checking role of user %d: %v", userID, err)
+        return false, err
+    }
+    return teacher, nil
+}
+
+// Login checks a username and password and starts a login session. It returns
+// ErrInvalidCredentials for an unknown user or a wrong password alike.
+func (s *Service) Login(req *LoginRequest) (*LoginResponse, error) {
+    db := s.db
+
+    var userID int64
+    var hash string
+    err := db.QueryRow(
+        "SELECT id, password_hash FROM users WHERE username = ?",
+        strings.TrimSpace(req.Username)).Scan(&userID, &hash)
+    if err != nil && err != sql.ErrNoRows {
+        logging.Errorf("Error looking up user: %v", err)
+        return nil, err
+    }
+    if err == sql.ErrNoRows || bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+        return nil, ErrInvalidCredentials
+    }
+
+    secret := make([]byte, 32)
+    if _, err := rand.Read(secret); err != nil {
+        return nil, err
+    }
+    token := hex.EncodeToString(secret)
+    expiresAt := time.Now().UTC().Add(LoginSessionTTL)
+
+    // Only a hash of the token is kept, so a copy of the database cannot be used to log in
+    _, err = db.Exec(`
+        INSERT INTO login_sessions (token_hash, user_id, created_at, expires_at)
+        VALUES (?, ?, CURRENT_TIMESTAMP, ?)`,
+        hashLoginToken(token), userID, expiresAt.Format(sqliteTimeFormat))
+    if err != nil {
+        logging.Errorf("Error creating login session: %v", err)
+        return nil, err
+    }
+
+    user, err := s.GetUser(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return &LoginResponse{Token: token, ExpiresAt: expiresAt, User: user}, nil
+}
+
+// Logout ends the login session of a token
+func (s *Service) Logout(token string) error {
+    db := s.db
+
+    if _, err := db.Exec("DELETE FROM login_sessions WHERE token_hash = ?", hashLoginToken(token)); err != nil {
+        logging.Errorf("Error deleting login session: %v", err)
+        return err
+    }
+    return nil
+}
+
+// AuthenticateUser returns the ID of the user logged in with a token, or 0 for
+// unknown and expired tokens
+func (s *Service) AuthenticateUser(token string) (int64, error) {
+    db := s.db
+
+    var userID int64
+    err := db.QueryRow(`
+        SELECT user_id
+        FROM login_sessions
+        WHERE token_hash = ? AND julianday(expires_at) > julianday('now')`,
+        hashLoginToken(token)).Scan(&userID)
+    if err == sql.ErrNoRows {
+        return 0, nil
+    }
+    if err != nil {
+        logging.Errorf("Error checking login session: %v", err)
+        return 0, err
+    }
+
+    return userID, nil
+}
+
+func hashLoginToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "unicode"
+)
+
+// ValidationError reports a request field that failed validation
+type ValidationError struct {
+    // Entity names what was invalid, such as "word" or "import", when known
+    Entity  string `json:"-"`
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+    return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+func (e *ValidationError) Is(target error) bool {
+    return target == ErrValidation
+}
+
+// Code is INVALID_<ENTITY>, or INVALID_REQUEST when the entity is not known
+func (e *ValidationError) Code() string {
+    if e.Entity == "" {
+        return "INVALID_REQUEST"
+    }
+    return "INVALID_" + strings.ToUpper(e.Entity)
+}
+
+func (e *ValidationError) Status() int {
+    return http.StatusBadRequest
+}
+
+// Details is the error itself, naming the invalid field
+func (e *ValidationError) Details() interface{} {
+    return e
+}
+
+// requireText trims a field and rejects it when nothing is left
+func requireText(field, value string) (string, error) {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return "", &ValidationError{Field: field, Message: "must not be empty"}
+    }
+    return value, nil
+}
+
+// isArabicText reports whether every letter in s is written in Arabic script.
+// Spaces, punctuation and diacritics are allowed; at least one letter is required.
+func isArabicText(s string) bool {
+    letters := 0
+    for _, r := range s {
+        if !unicode.IsLetter(r) {
+            continue
+        }
+        if !unicode.Is(unicode.Arabic, r) {
+            return false
+        }
+        letters++
+    }
+    return letters > 0
+}
@@ -1,74 +1,25 @@
 package service
 
 import (
-    "fmt"
-    "log"
+    "database/sql"
+    "encoding/json"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/arabic"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
     "github.com/minhalzubairi/lang-portal/backend-go/internal/models"
 )
 
-// GetWords returns a paginated list of words with their stats
-func GetWords(page, perPage int) ([]WordWithStats, *models.Pagination, error) {
-    db := GetDB()
-    if db == nil {
-        log.Printf("Database connection is nil")
-        return nil, nil, fmt.Errorf("database connection not initialized")
-    }
-
-    offset := (page - 1) * perPage
-
-    // Get total count for pagination
-    var total int
-    err := db.QueryRow("SELECT COUNT(*) FROM words").Scan(&total)
-    if err != nil {
-        log.Printf("Error counting words: %v", err)
+// GetWords returns a paginated list of words with a learner's stats
+func (s *Service) GetWords(userID int64, opts ListOptions, page, perPage int) ([]WordWithStats, *models.Pagination, error) {
+    if err := wordListSpec.validate(opts); err != nil {
         return nil, nil, err
     }
+    offset := (page - 1) * perPage
 
-    // Get words with their stats
-    rows, err := db.Query(`
-        SELECT 
-            w.arabic,
-            w.roman,
-            w.english,
-            COALESCE(correct.count, 0) as correct_count,
-            COALESCE(wrong.count, 0) as wrong_count
-        FROM words w
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 1
-            GROUP BY word_id
-        ) correct ON w.id = correct.word_id
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 0
-            GROUP BY word_id
-        ) wrong ON w.id = wrong.word_id
-        ORDER BY w.id
-        LIMIT ? OFFSET ?`,
-        perPage, offset)
+    words, total, err := s.Words.List(userID, opts, perPage, offset)
     if err != nil {
-        log.Printf("Error querying words: %v", err)
         return nil, nil, err
     }
-    defer rows.Close()
-
-    var words []WordWithStats
-    for rows.Next() {
-        var w WordWithStats
-        if err := rows.Scan(
-            &w.Arabic,
-            &w.Roman,
-            &w.English,
-            &w.CorrectCount,
-            &w.WrongCount,
-        ); err != nil {
-            log.Printf("Error scanning word: %v", err)
-            return nil, nil, err
-        }
-        words = append(words, w)
-    }
 
     pagination := &models.Pagination{
         CurrentPage:  page,
@@ -82,85 +33,191 @@ func GetWords(page, perPage int) ([]WordWithStats, *models.Pagination, error) {
 
 // WordDetailResponse represents a single word with stats and groups
 type WordDetailResponse struct {
-    Arabic  string   `json:"arabic"`
-    Roman   string   `json:"roman"`
-    English string   `json:"english"`
+    ID      int64           `json:"id"`
+    Arabic  string          `json:"arabic"`
+    Roman   string          `json:"roman"`
+    English string          `json:"english"`
+    Parts   json.RawMessage `json:"parts,omitempty"`
     Stats   struct {
         CorrectCount int `json:"correct_count"`
         WrongCount   int `json:"wrong_count"`
     } `json:"stats"`
-    Groups []struct {
-        Name string `json:"name"`
-    } `json:"groups"`
+    Groups []WordGroup `json:"groups"`
+}
+
+// WordGroup names a group a word belongs to
+type WordGroup struct {
+    Name string `json:"name"`
+}
+
+// GetWord returns a single word by ID with a learner's stats and groups
+func (s *Service) GetWord(id, userID int64) (*WordDetailResponse, error) {
+    return s.Words.Get(id, userID)
+}
+
+// CreateWordRequest represents the request body for creating or replacing a word
+type CreateWordRequest struct {
+    Arabic  string          `json:"arabic" binding:"required"`
+    Roman   string          `json:"roman" binding:"required"`
+    English string          `json:"english" binding:"required"`
+    Parts   json.RawMessage `json:"parts"`
+}
+
+// UpdateWordRequest represents the request body for partially updating a word.
+// Fields left out of the request keep their current value.
+type UpdateWordRequest struct {
+    Arabic  *string         `json:"arabic"`
+    Roman   *string         `json:"roman"`
+    English *string         `json:"english"`
+    Parts   json.RawMessage `json:"parts"`
+}
+
+// DeleteWordResponse represents the result of deleting a word
+type DeleteWordResponse struct {
+    ID                      int64 `json:"id"`
+    RemovedReviews          int64 `json:"removed_reviews"`
+    RemovedGroupMemberships int64 `json:"removed_group_memberships"`
+}
+
+// normalizeWord validates a word's fields and returns them trimmed
+func normalizeWord(arabic, roman, english string, parts json.RawMessage) (*CreateWordRequest, error) {
+    var err error
+    word := &CreateWordRequest{}
+
+    if word.Arabic, err = requireText("arabic", arabic); err != nil {
+        return nil, err
+    }
+    if !isArabicText(word.Arabic) {
+        return nil, &ValidationError{Field: "arabic", Message: "must be written in Arabic script"}
+    }
+    if word.Roman, err = requireText("roman", roman); err != nil {
+        return nil, err
+    }
+    if word.English, err = requireText("english", english); err != nil {
+        return nil, err
+    }
+
+    if len(parts) > 0 && string(parts) != "null" {
+        if !json.Valid(parts) {
+            return nil, &ValidationError{Field: "parts", Message: "must be valid JSON"}
+        }
+        word.Parts = parts
+    }
+
+    return word, nil
+}
+
+// storedParts returns the words.parts value of a normalized word
+func storedParts(word *CreateWordRequest) sql.NullString {
+    if len(word.Parts) == 0 {
+        return sql.NullString{}
+    }
+    return sql.NullString{String: string(word.Parts), Valid: true}
+}
+
+// findDuplicateWord returns the ID of another word with the same Arabic and English text, or 0 if
+// there is none. Arabic is compared normalized, so tashkeel and letter forms make no difference,
+// and English case-insensitively; excludeID skips the word being updated.
+func findDuplicateWord(q queryer, arabicText, english string, excludeID int64) (int64, error) {
+    var id int64
+    err := q.QueryRow(`
+        SELECT id
+        FROM words
+        WHERE COALESCE(NULLIF(arabic_normalized, ''), arabic) = ? AND LOWER(english) = LOWER(?) AND id != ?
+        LIMIT 1`,
+        arabic.Normalize(arabicText), english, excludeID).Scan(&id)
+    if err == sql.ErrNoRows {
+        return 0, nil
+    }
+    if err != nil {
+        logging.Errorf("Error checking for duplicate word: %v", err)
+        return 0, err
+    }
+    return id, nil
 }
 
-// GetWord returns a single word by ID with stats and groups
-func GetWord(id int64) (*WordDetailResponse, error) {
-    db := GetDB()
-    if db == nil {
-        return nil, fmt.Errorf("database connection not initialized")
-    }
-
-    var word WordDetailResponse
-
-    // Get word details with stats
-    err := db.QueryRow(`
-        SELECT 
-            w.arabic,
-            w.roman,
-            w.english,
-            COALESCE(correct.count, 0) as correct_count,
-            COALESCE(wrong.count, 0) as wrong_count
-        FROM words w
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 1 AND word_id = ?
-            GROUP BY word_id
-        ) correct ON w.id = correct.word_id
-        LEFT JOIN (
-            SELECT word_id, COUNT(*) as count
-            FROM word_review_items
-            WHERE correct = 0 AND word_id = ?
-            GROUP BY word_id
-        ) wrong ON w.id = wrong.word_id
-        WHERE w.id = ?`,
-        id, id, id).Scan(
-            &word.Arabic,
-            &word.Roman,
-            &word.English,
-            &word.Stats.CorrectCount,
-            &word.Stats.WrongCount,
-        )
+// CreateWord validates and stores a new word
+func (s *Service) CreateWord(req *CreateWordRequest) (*WordDetailResponse, error) {
+    word, err := normalizeWord(req.Arabic, req.Roman, req.English, req.Parts)
+    if err != nil {
+        return nil, invalid(err, "word")
+    }
+
+    existingID, err := s.Words.FindDuplicate(word.Arabic, word.English, 0)
     if err != nil {
-        log.Printf("Error getting word %d: %v", id, err)
         return nil, err
     }
+    if existingID != 0 {
+        return nil, duplicateWord(existingID)
+    }
 
-    // Get groups for this word
-    rows, err := db.Query(`
-        SELECT g.name
-        FROM groups g
-        JOIN words_groups wg ON g.id = wg.group_id
-        WHERE wg.word_id = ?
-        ORDER BY g.name`,
-        id)
+    id, err := s.Words.Create(word)
     if err != nil {
-        log.Printf("Error getting word groups: %v", err)
         return nil, err
     }
-    defer rows.Close()
 
-    for rows.Next() {
-        var group struct {
-            Name string `json:"name"`
-        }
-        if err := rows.Scan(&group.Name); err != nil {
-            log.Printf("Error scanning group: %v", err)
-            return nil, err
-        }
-        word.Groups = append(word.Groups, group)
+    // A new word has no reviews, so whose stats are returned makes no difference
+    return s.GetWord(id, 0)
+}
+
+// UpdateWord replaces all fields of an existing word, returning it with a learner's stats
+func (s *Service) UpdateWord(id, userID int64, req *CreateWordRequest) (*WordDetailResponse, error) {
+    word, err := normalizeWord(req.Arabic, req.Roman, req.English, req.Parts)
+    if err != nil {
+        return nil, invalid(err, "word")
     }
 
-    return &word, nil
+    existingID, err := s.Words.FindDuplicate(word.Arabic, word.English, id)
+    if err != nil {
+        return nil, err
+    }
+    if existingID != 0 {
+        return nil, duplicateWord(existingID)
+    }
+
+    if err := s.Words.Update(id, word); err != nil {
+        return nil, err
+    }
+
+    return s.GetWord(id, userID)
+}
+
+// PatchWord updates only the fields present in the request, returning the word with a
+// learner's stats
+func (s *Service) PatchWord(id, userID int64, req *UpdateWordRequest) (*WordDetailResponse, error) {
+    current, err := s.Words.GetFields(id)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Arabic != nil {
+        current.Arabic = *req.Arabic
+    }
+    if req.Roman != nil {
+        current.Roman = *req.Roman
+    }
+    if req.English != nil {
+        current.English = *req.English
+    }
+    if req.Parts != nil {
+        current.Parts = req.Parts
+    }
+
+    return s.UpdateWord(id, userID, current)
+}
+
+// DeleteWord removes a word together with its group memberships. A word that has been
+// reviewed is only deleted when force is set, in which case its reviews are removed too.
+func (s *Service) DeleteWord(id int64, force bool) (*DeleteWordResponse, error) {
+    return s.Words.Delete(id, force)
+}
+
+// duplicateWord reports that a word with the same Arabic and English already exists
+func duplicateWord(existingID int64) error {
+    return &ConflictError{
+        Entity:     "word",
+        Reason:     ReasonAlreadyExists,
+        Message:    "Word already exists",
+        ExistingID: existingID,
+    }
 }
@@ -0,0 +1,667 @@
+package service
+
+import (
+    "bytes"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/logging"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/srs"
+    "github.com/minhalzubairi/lang-portal/backend-go/internal/xapi"
+)
+
+// xapiTimeFormat stores statement times with millisecond precision in a form that
+// sorts and compares correctly as text
+const xapiTimeFormat = "2006-01-02 15:04:05.000"
+
+// Limits on the number of statements returned by a single query
+const (
+    DefaultXAPIStatementLimit = 100
+    MaxXAPIStatementLimit     = 500
+)
+
+var (
+    // wordActivityPattern matches activity IRIs of words, e.g. http://host/api/words/5
+    wordActivityPattern = regexp.MustCompile(`/words/(\d+)/?$`)
+    // sessionActivityPattern matches activity IRIs of study sessions
+    sessionActivityPattern = regexp.MustCompile(`/study_sessions/(\d+)/?$`)
+)
+
+// statementConflict is returned when a statement ID is already used by a
+// different statement
+func statementConflict(id string) error {
+    return &ConflictError{
+        Entity:  "statement",
+        Reason:  ReasonConflict,
+        Message: fmt.Sprintf("statement %s already exists with different content", id),
+    }
+}
+
+// XAPIStatementQuery filters the statements returned by QueryXAPIStatements
+type XAPIStatementQuery struct {
+    // Agent is an xAPI agent object matched by its identifier
+    Agent        string
+    Verb         string
+    Activity     string
+    Registration string
+    Since        *time.Time
+    Until        *time.Time
+    Limit        int
+    Offset       int
+    Ascending    bool
+    // SessionID limits the query to the statements of a study session; 0 queries
+    // the statements of every session
+    SessionID int64
+}
+
+// xapiSubmission is a validated statement waiting to be stored
+type xapiSubmission struct {
+    statement xapi.Statement
+    fields    map[string]json.RawMessage
+    // canonical is the statement as submitted, with its ID, for conflict checks
+    canonical []byte
+    exists    bool
+}
+
+// StoreXAPIStatements validates and stores a batch of statements, returning their
+// IDs in order. The batch is stored in one transaction, and the whole batch is
+// rejected if any statement is invalid or reuses the ID of a different statement.
+// Statements already stored unchanged are accepted again without effect.
+// "answered" statements about a word are recorded as reviews in the study session
+// given by their context. A non-zero sessionID, from the session token of the
+// client, is the only session the statements may record reviews in or void the
+// statements of.
+func (s *Service) StoreXAPIStatements(raw []json.RawMessage, sessionID int64) ([]string, error) {
+    db := s.db
+
+    // Sessions gone idle are abandoned first, as they are before any review
+    if _, err := s.AbandonIdleSessions(); err != nil {
+        return nil, err
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        logging.Errorf("Error starting transaction: %v", err)
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    submissions := make([]*xapiSubmission, len(raw))
+    ids := make([]string, len(raw))
+    seen := make(map[string]bool)
+    for i, body := range raw {
+        submission, err := parseXAPIStatement(body)
+        if err != nil {
+            var validationErr *ValidationError
+            if len(raw) > 1 && errors.As(err, &validationErr) {
+                validationErr.Field = fmt.Sprintf("statements[%d].%s", i, validationErr.Field)
+            }
+            return nil, err
+        }
+
+        id := submission.statement.ID
+        if seen[id] {
+            return nil, &ValidationError{Entity: "statement", Field: "id", Message: fmt.Sprintf("statement %s appears twice in the batch", id)}
+        }
+        seen[id] = true
+
+        var existing string
+        err = tx.QueryRow("SELECT statement FROM xapi_statements WHERE id = ?", id).Scan(&existing)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error checking xAPI statement %s: %v", id, err)
+            return nil, err
+        }
+        if err == nil {
+            if !sameXAPIStatement(submission, existing) {
+                return nil, statementConflict(id)
+            }
+            submission.exists = true
+        }
+
+        submissions[i] = submission
+        ids[i] = id
+    }
+
+    for _, submission := range submissions {
+        if submission.exists {
+            continue
+        }
+        if err := storeXAPIStatement(tx, s.opts.Scheduler, submission, sessionID); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        logging.Errorf("Error committing transaction: %v", err)
+        return nil, err
+    }
+
+    return ids, nil
+}
+
+// PutXAPIStatement stores a single statement under the given ID, limited to a
+// session like StoreXAPIStatements
+func (s *Service) PutXAPIStatement(id string, body json.RawMessage, sessionID int64) error {
+    if !xapi.IsUUID(id) {
+        return &ValidationError{Entity: "statement", Field: "statementId", Message: "must be a UUID"}
+    }
+
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(body, &fields); err != nil {
+        return &ValidationError{Entity: "statement", Field: "statement", Message: "must be a JSON object"}
+    }
+    if given, ok := fields["id"]; ok {
+        var givenID string
+        if json.Unmarshal(given, &givenID) != nil || !strings.EqualFold(givenID, id) {
+            return &ValidationError{Entity: "statement", Field: "id", Message: "does not match statementId"}
+        }
+    }
+    fields["id"], _ = json.Marshal(strings.ToLower(id))
+
+    body, err := json.Marshal(fields)
+    if err != nil {
+        return err
+    }
+    _, err = s.StoreXAPIStatements([]json.RawMessage{body}, sessionID)
+    return err
+}
+
+// parseXAPIStatement validates a statement and assigns it an ID if it has none
+func parseXAPIStatement(body json.RawMessage) (*xapiSubmission, error) {
+    submission := &xapiSubmission{}
+    if err := json.Unmarshal(body, &submission.fields); err != nil || submission.fields == nil {
+        return nil, &ValidationError{Entity: "statement", Field: "statement", Message: "must be a JSON object"}
+    }
+    // Both are set by the LRS
+    delete(submission.fields, "stored")
+    delete(submission.fields, "authority")
+    if err := json.Unmarshal(body, &submission.statement); err != nil {
+        return nil, &ValidationError{Entity: "statement", Field: "statement", Message: err.Error()}
+    }
+
+    if err := submission.statement.Validate(); err != nil {
+        var validationErr *xapi.ValidationError
+        if errors.As(err, &validationErr) {
+            return nil, &ValidationError{Entity: "statement", Field: validationErr.Field, Message: validationErr.Message}
+        }
+        return nil, err
+    }
+
+    if submission.statement.ID == "" {
+        submission.statement.ID = xapi.NewUUID()
+    }
+    submission.statement.ID = strings.ToLower(submission.statement.ID)
+    submission.fields["id"], _ = json.Marshal(submission.statement.ID)
+
+    canonical, err := canonicalJSON(submission.fields)
+    if err != nil {
+        return nil, err
+    }
+    submission.canonical = canonical
+    return submission, nil
+}
+
+// sameXAPIStatement compares a submission with a stored statement, ignoring the
+// properties the LRS added when storing it
+func sameXAPIStatement(submission *xapiSubmission, stored string) bool {
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal([]byte(stored), &fields); err != nil {
+        return false
+    }
+    delete(fields, "stored")
+    delete(fields, "authority")
+    if _, ok := submission.fields["version"]; !ok {
+        delete(fields, "version")
+    }
+    if _, ok := submission.fields["timestamp"]; !ok {
+        delete(fields, "timestamp")
+    }
+
+    canonical, err := canonicalJSON(fields)
+    return err == nil && bytes.Equal(canonical, submission.canonical)
+}
+
+// canonicalJSON re-encodes JSON with sorted keys and no insignificant whitespace
+func canonicalJSON(fields map[string]json.RawMessage) ([]byte, error) {
+    encoded, err := json.Marshal(fields)
+    if err != nil {
+        return nil, err
+    }
+    var value interface{}
+    if err := json.Unmarshal(encoded, &value); err != nil {
+        return nil, err
+    }
+    return json.Marshal(value)
+}
+
+// storeXAPIStatement records a new statement as part of the batch's transaction.
+// The statement row is inserted first, so a statement stored meanwhile under the
+// same ID fails the batch before anything else is written, and its effects are
+// applied after: voiding the statement it refers to or recording the review it
+// describes. A statement sent with a session token belongs to the token's session,
+// a non-zero scope, whatever its verb; otherwise it belongs to the session of the
+// review it records, if any.
+func storeXAPIStatement(tx *sql.Tx, scheduler srs.Scheduler, submission *xapiSubmission, scope int64) error {
+    statement := &submission.statement
+    stored := time.Now().UTC()
+    timestamp := stored
+    if statement.Timestamp != "" {
+        timestamp, _ = time.Parse(time.RFC3339Nano, statement.Timestamp)
+        timestamp = timestamp.UTC()
+    } else {
+        submission.fields["timestamp"], _ = json.Marshal(stored.Format(time.RFC3339Nano))
+    }
+    submission.fields["stored"], _ = json.Marshal(stored.Format(time.RFC3339Nano))
+    if _, ok := submission.fields["version"]; !ok {
+        submission.fields["version"], _ = json.Marshal("1.0.0")
+    }
+    body, err := json.Marshal(submission.fields)
+    if err != nil {
+        return err
+    }
+
+    var registration sql.NullString
+    if statement.Context != nil && statement.Context.Registration != "" {
+        registration = sql.NullString{String: strings.ToLower(statement.Context.Registration), Valid: true}
+    }
+
+    var scopeID sql.NullInt64
+    if scope != 0 {
+        scopeID = sql.NullInt64{Int64: scope, Valid: true}
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO xapi_statements (
+            id, actor_id, verb_id, object_id, registration, statement, timestamp, stored,
+            study_session_id
+        )
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        statement.ID, statement.Actor.Identifier(), statement.Verb.ID, statement.Object.ID,
+        registration, string(body), timestamp.Format(xapiTimeFormat), stored.Format(xapiTimeFormat),
+        scopeID)
+    if err != nil {
+        logging.Errorf("Error storing xAPI statement %s: %v", statement.ID, err)
+        return err
+    }
+
+    switch statement.Verb.ID {
+    case xapi.VerbVoided:
+        return voidXAPIStatement(tx, scheduler, statement.Object.ID, scope)
+    case xapi.VerbAnswered:
+        session, review, reviewErr, err := recordXAPIAnswer(tx, scheduler, statement, scope)
+        if err != nil {
+            return err
+        }
+
+        var sessionID, reviewID sql.NullInt64
+        var reviewError sql.NullString
+        if session != 0 {
+            sessionID = sql.NullInt64{Int64: session, Valid: true}
+        }
+        if review != 0 {
+            reviewID = sql.NullInt64{Int64: review, Valid: true}
+        }
+        if reviewErr != "" {
+            reviewError = sql.NullString{String: reviewErr, Valid: true}
+        }
+
+        _, err = tx.Exec(`
+            UPDATE xapi_statements
+            SET study_session_id = COALESCE(study_session_id, ?),
+                word_review_item_id = ?, review_error = ?
+            WHERE id = ?`,
+            sessionID, reviewID, reviewError, statement.ID)
+        if err != nil {
+            logging.Errorf("Error linking xAPI statement %s to its review: %v", statement.ID, err)
+            return err
+        }
+    }
+
+    return nil
+}
+
+// voidXAPIStatement marks a statement as voided and removes the review it recorded.
+// Voiding statements cannot themselves be voided, and unknown targets are ignored,
+// as are targets outside a non-zero scope session. The word of a removed review is
+// rescheduled with scheduler.
+func voidXAPIStatement(tx *sql.Tx, scheduler srs.Scheduler, id string, scope int64) error {
+    var reviewID sql.NullInt64
+    err := tx.QueryRow(`
+        SELECT word_review_item_id
+        FROM xapi_statements
+        WHERE id = ? AND verb_id != ? AND (? = 0 OR study_session_id = ?)`,
+        strings.ToLower(id), xapi.VerbVoided, scope, scope).Scan(&reviewID)
+    if err == sql.ErrNoRows {
+        return nil
+    }
+    if err != nil {
+        logging.Errorf("Error finding voided xAPI statement %s: %v", id, err)
+        return err
+    }
+
+    _, err = tx.Exec(
+        "UPDATE xapi_statements SET voided = 1, word_review_item_id = NULL WHERE id = ?",
+        strings.ToLower(id))
+    if err != nil {
+        logging.Errorf("Error voiding xAPI statement %s: %v", id, err)
+        return err
+    }
+
+    if reviewID.Valid {
+        var wordID int64
+        err := tx.QueryRow("SELECT word_id FROM word_review_items WHERE id = ?", reviewID.Int64).Scan(&wordID)
+        if err != nil && err != sql.ErrNoRows {
+            logging.Errorf("Error finding review %d: %v", reviewID.Int64, err)
+            return err
+        }
+        if err == nil {
+            if _, err := tx.Exec("DELETE FROM word_review_items WHERE id = ?", reviewID.Int64); err != nil {
+                logging.Errorf("Error deleting review %d: %v", reviewID.Int64, err)
+                return err
+            }
+            if err := rebuildSchedule(tx, scheduler, wordID); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// recordXAPIAnswer records an "answered" statement about a word as a review in the
+// study session named by its context, as part of the batch's transaction. Like any
+// review it counts as taking place when received, whatever the statement
+// timestamp. A non-zero scope is the only session reviews may be recorded in. It
+// returns the session and review IDs, or a reason the statement could not be
+// recorded as a review.
+func recordXAPIAnswer(tx *sql.Tx, scheduler srs.Scheduler, statement *xapi.Statement, scope int64) (int64, int64, string, error) {
+    match := wordActivityPattern.FindStringSubmatch(statement.Object.ID)
+    if match == nil {
+        return 0, 0, "", nil
+    }
+    wordID, _ := strconv.ParseInt(match[1], 10, 64)
+
+    sessionID := xapiSessionID(statement.Context)
+    if sessionID == 0 {
+        return 0, 0, "no study session in the statement context", nil
+    }
+    if scope != 0 && sessionID != scope {
+        return 0, 0, fmt.Sprintf("study session %d is not the session the token was issued for", sessionID), nil
+    }
+
+    req := &CreateWordReviewRequest{}
+    if result := statement.Result; result != nil {
+        req.IsCorrect = result.Success
+        req.Answer = result.Response
+        if result.Duration != "" {
+            duration, _ := xapi.ParseDuration(result.Duration)
+            ms := duration.Milliseconds()
+            req.ResponseTimeMs = &ms
+        }
+        if grade, ok := xapiScoreGrade(result.Score); ok {
+            req.Grade = &grade
+        }
+    }
+    if statement.Context != nil {
+        for key, value := range statement.Context.Extensions {
+            if strings.HasSuffix(key, "/direction") {
+                json.Unmarshal(value, &req.Direction)
+            }
+        }
+    }
+
+    var status string
+    var userID sql.NullInt64
+    err := tx.QueryRow("SELECT status, user_id FROM study_sessions WHERE id = ?", sessionID).Scan(&status, &userID)
+    if err == sql.ErrNoRows {
+        return 0, 0, fmt.Sprintf("study session %d not found", sessionID), nil
+    }
+    if err != nil {
+        logging.Errorf("Error getting state of session %d: %v", sessionID, err)
+        return 0, 0, "", err
+    }
+
+    grade, correct, err := resolveReview(req)
+    if err != nil {
+        return sessionID, 0, invalid(err, "review").Error(), nil
+    }
+    if status != SessionStatusActive {
+        return sessionID, 0, errSessionNotActive.Error(), nil
+    }
+
+    var wordExists bool
+    err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM words WHERE id = ?)", wordID).Scan(&wordExists)
+    if err != nil {
+        logging.Errorf("Error checking word existence: %v", err)
+        return 0, 0, "", err
+    }
+    if !wordExists {
+        return sessionID, 0, (&NotFoundError{Entity: "word"}).Error(), nil
+    }
+
+    review, err := insertReview(tx, scheduler, &WordReview{
+        SessionID:      sessionID,
+        WordID:         wordID,
+        UserID:         userID.Int64,
+        Grade:          grade,
+        Correct:        correct,
+        Answer:         req.Answer,
+        Direction:      req.Direction,
+        ResponseTimeMs: req.ResponseTimeMs,
+    })
+    if err != nil {
+        return 0, 0, "", err
+    }
+    return sessionID, review.ID, "", nil
+}
+
+// xapiSessionID finds the study session a statement belongs to: a parent, grouping
+// or other context activity whose IRI ends in /study_sessions/{id}, or a
+// study_session_id context extension
+func xapiSessionID(context *xapi.Context) int64 {
+    if context == nil {
+        return 0
+    }
+    if activities := context.ContextActivities; activities != nil {
+        for _, list := range []xapi.Objects{activities.Parent, activities.Grouping, activities.Other} {
+            for _, activity := range list {
+                if match := sessionActivityPattern.FindStringSubmatch(activity.ID); match != nil {
+                    id, _ := strconv.ParseInt(match[1], 10, 64)
+                    return id
+                }
+            }
+        }
+    }
+    for key, value := range context.Extensions {
+        if !strings.HasSuffix(key, "/study_session_id") {
+            continue
+        }
+        var id int64
+        if json.Unmarshal(value, &id) == nil {
+            return id
+        }
+        var text string
+        if json.Unmarshal(value, &text) == nil {
+            id, _ = strconv.ParseInt(text, 10, 64)
+            return id
+        }
+    }
+    return 0
+}
+
+// xapiScoreGrade converts a scaled score, or a raw score with a maximum, to a grade
+// on the 0-5 scale
+func xapiScoreGrade(score *xapi.Score) (ReviewGrade, bool) {
+    if score == nil {
+        return 0, false
+    }
+
+    var fraction float64
+    switch {
+    case score.Scaled != nil:
+        fraction = *score.Scaled
+    case score.Raw != nil && score.Max != nil:
+        min := 0.0
+        if score.Min != nil {
+            min = *score.Min
+        }
+        if *score.Max <= min {
+            return 0, false
+        }
+        fraction = (*score.Raw - min) / (*score.Max - min)
+    default:
+        return 0, false
+    }
+
+    fraction = math.Max(0, math.Min(1, fraction))
+    return ReviewGrade(math.Round(fraction * 5)), true
+}
+
+// GetXAPIStatement returns a single statement. Voided statements are only returned
+// when voided is set, and only voided statements are. When sessionID is not 0,
+// statements recorded against other study sessions are not found.
+func (s *Service) GetXAPIStatement(id string, voided bool, sessionID int64) (json.RawMessage, error) {
+    db := s.db
+
+    var statement string
+    err := db.QueryRow(`
+        SELECT statement FROM xapi_statements
+        WHERE id = ? AND voided = ? AND (? = 0 OR study_session_id = ?)`,
+        strings.ToLower(id), voided, sessionID, sessionID).Scan(&statement)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            logging.Errorf("Error getting xAPI statement %s: %v", id, err)
+        }
+        return nil, notFound(err, "statement")
+    }
+    return json.RawMessage(statement), nil
+}
+
+// QueryXAPIStatements returns the statements matching a query, newest first unless
+// ascending, and whether more statements follow. Voided statements are excluded.
+func (s *Service) QueryXAPIStatements(query XAPIStatementQuery) ([]json.RawMessage, bool, error) {
+    db := s.db
+
+    conditions := []string{"voided = 0"}
+    var args []interface{}
+    if query.SessionID != 0 {
+        conditions = append(conditions, "study_session_id = ?")
+        args = append(args, query.SessionID)
+    }
+    if query.Agent != "" {
+        var agent xapi.Agent
+        if err := json.Unmarshal([]byte(query.Agent), &agent); err != nil || agent.Identifier() == "" {
+            return nil, false, &ValidationError{Entity: "statement", Field: "agent", Message: "must be an agent object with an identifier"}
+        }
+        conditions = append(conditions, "actor_id = ?")
+        args = append(args, agent.Identifier())
+    }
+    if query.Verb != "" {
+        conditions = append(conditions, "verb_id = ?")
+        args = append(args, query.Verb)
+    }
+    if query.Activity != "" {
+        conditions = append(conditions, "object_id = ?")
+        args = append(args, query.Activity)
+    }
+    if query.Registration != "" {
+        conditions = append(conditions, "registration = ?")
+        args = append(args, strings.ToLower(query.Registration))
+    }
+    if query.Since != nil {
+        conditions = append(conditions, "stored > ?")
+        args = append(args, query.Since.UTC().Format(xapiTimeFormat))
+    }
+    if query.Until != nil {
+        conditions = append(conditions, "stored <= ?")
+        args = append(args, query.Until.UTC().Format(xapiTimeFormat))
+    }
+
+    limit := query.Limit
+    if limit <= 0 || limit > MaxXAPIStatementLimit {
+        limit = MaxXAPIStatementLimit
+    }
+    order := "DESC"
+    if query.Ascending {
+        order = "ASC"
+    }
+    args = append(args, limit+1, query.Offset)
+
+    rows, err := db.Query(`
+        SELECT statement
+        FROM xapi_statements
+        WHERE `+strings.Join(conditions, " AND ")+`
+        ORDER BY stored `+order+`, rowid `+order+`
+        LIMIT ? OFFSET ?`,
+        args...)
+    if err != nil {
+        logging.Errorf("Error querying xAPI statements: %v", err)
+        return nil, false, err
+    }
+    defer rows.Close()
+
+    statements := []json.RawMessage{}
+    for rows.Next() {
+        var statement string
+        if err := rows.Scan(&statement); err != nil {
+            logging.Errorf("Error scanning xAPI statement: %v", err)
+            return nil, false, err
+        }
+        statements = append(statements, json.RawMessage(statement))
+    }
+    if err := rows.Err(); err != nil {
+        return nil, false, err
+    }
+
+    more := len(statements) > limit
+    if more {
+        statements = statements[:limit]
+    }
+    return statements, more, nil
+}
+
+// XAPISessionStatement is a statement recorded against a study session, with the
+// outcome of recording it as a review
+type XAPISessionStatement struct {
+    Statement   json.RawMessage `json:"statement"`
+    Voided      bool            `json:"voided"`
+    ReviewID    *int64          `json:"review_id,omitempty"`
+    ReviewError *string         `json:"review_error,omitempty"`
+}
+
+// GetStudySessionStatements returns the xAPI statements recorded against a study
+// session in the order they were stored
+func (s *Service) GetStudySessionStatements(sessionID int64) ([]XAPISessionStatement, error) {
+    db := s.db
+
+    rows, err := db.Query(`
+        SELECT statement, voided, word_review_item_id, review_error
+        FROM xapi_statements
+        WHERE study_session_id = ?
+        ORDER BY stored, rowid`,
+        sessionID)
+    if err != nil {
+        logging.Errorf("Error getting statements of session %d: %v", sessionID, err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    statements := []XAPISessionStatement{}
+    for rows.Next() {
+        var statement XAPISessionStatement
+        var body string
+        if err := rows.Scan(&body, &statement.Voided, &statement.ReviewID, &statement.ReviewError); err != nil {
+            logging.Errorf("Error scanning session statement: %v", err)
+            return nil, err
+        }
+        statement.Statement = json.RawMessage(body)
+        statements = append(statements, statement)
+    }
+    return statements, rows.Err()
+}
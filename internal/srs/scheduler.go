@@ -0,0 +1,50 @@
+// Package srs decides when words are due for review using spaced repetition.
+package srs
+
+import "time"
+
+// Review grades on the 0-5 scale used by SM-2. Grades of 3 and above count as a
+// successful recall.
+const (
+	GradeBlackout  = 0
+	GradeIncorrect = 1
+	GradeFamiliar  = 2
+	GradeHard      = 3
+	GradeGood      = 4
+	GradePerfect   = 5
+
+	// PassingGrade is the lowest grade treated as a correct answer
+	PassingGrade = 3
+)
+
+// State is the scheduling state of a single word
+type State struct {
+	EaseFactor     float64
+	IntervalDays   int
+	Repetitions    int
+	DueAt          time.Time
+	LastReviewedAt time.Time
+}
+
+// Scheduler computes the next state of a word after a review. Implementations must
+// accept the zero State as the state of a word that has never been reviewed.
+type Scheduler interface {
+	// Name identifies the algorithm, e.g. "sm2"
+	Name() string
+	// Next returns the state after a review with the given grade at reviewedAt
+	Next(state State, grade int, reviewedAt time.Time) State
+}
+
+// ratings maps the four-button answers used by flashcard apps onto grades
+var ratings = map[string]int{
+	"again": GradeIncorrect,
+	"hard":  GradeHard,
+	"good":  GradeGood,
+	"easy":  GradePerfect,
+}
+
+// GradeFromRating converts an again/hard/good/easy rating into a grade
+func GradeFromRating(rating string) (int, bool) {
+	grade, ok := ratings[rating]
+	return grade, ok
+}
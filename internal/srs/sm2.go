@@ -0,0 +1,61 @@
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	sm2InitialEase = 2.5
+	sm2MinimumEase = 1.3
+)
+
+// SM2 implements the SuperMemo 2 algorithm
+type SM2 struct{}
+
+// Name returns the algorithm name
+func (SM2) Name() string {
+	return "sm2"
+}
+
+// Next applies a review to the word's state
+func (SM2) Next(state State, grade int, reviewedAt time.Time) State {
+	if grade < GradeBlackout {
+		grade = GradeBlackout
+	}
+	if grade > GradePerfect {
+		grade = GradePerfect
+	}
+
+	next := state
+	if next.EaseFactor == 0 {
+		next.EaseFactor = sm2InitialEase
+	}
+
+	if grade >= PassingGrade {
+		switch next.Repetitions {
+		case 0:
+			next.IntervalDays = 1
+		case 1:
+			next.IntervalDays = 6
+		default:
+			next.IntervalDays = int(math.Round(float64(next.IntervalDays) * next.EaseFactor))
+		}
+		next.Repetitions++
+	} else {
+		// A failed recall restarts the repetition sequence
+		next.Repetitions = 0
+		next.IntervalDays = 1
+	}
+
+	q := float64(GradePerfect - grade)
+	next.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if next.EaseFactor < sm2MinimumEase {
+		next.EaseFactor = sm2MinimumEase
+	}
+
+	next.LastReviewedAt = reviewedAt
+	next.DueAt = reviewedAt.AddDate(0, 0, next.IntervalDays)
+
+	return next
+}
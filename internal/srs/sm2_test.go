@@ -0,0 +1,107 @@
+package srs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSM2Next(t *testing.T) {
+	reviewedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		state State
+		grade int
+		want  State
+	}{
+		{
+			name:  "first review",
+			grade: GradeGood,
+			want:  State{EaseFactor: 2.5, IntervalDays: 1, Repetitions: 1},
+		},
+		{
+			name:  "second review",
+			state: State{EaseFactor: 2.5, IntervalDays: 1, Repetitions: 1},
+			grade: GradePerfect,
+			want:  State{EaseFactor: 2.6, IntervalDays: 6, Repetitions: 2},
+		},
+		{
+			name:  "interval grows by the ease factor",
+			state: State{EaseFactor: 2.5, IntervalDays: 6, Repetitions: 2},
+			grade: GradeGood,
+			want:  State{EaseFactor: 2.5, IntervalDays: 15, Repetitions: 3},
+		},
+		{
+			name:  "hard recall lowers the ease factor",
+			state: State{EaseFactor: 2.5, IntervalDays: 15, Repetitions: 3},
+			grade: GradeHard,
+			want:  State{EaseFactor: 2.36, IntervalDays: 38, Repetitions: 4},
+		},
+		{
+			name:  "failed recall restarts the sequence",
+			state: State{EaseFactor: 2.5, IntervalDays: 15, Repetitions: 3},
+			grade: GradeIncorrect,
+			want:  State{EaseFactor: 1.96, IntervalDays: 1, Repetitions: 0},
+		},
+		{
+			name:  "ease factor has a minimum",
+			state: State{EaseFactor: 1.4, IntervalDays: 6, Repetitions: 2},
+			grade: GradeBlackout,
+			want:  State{EaseFactor: 1.3, IntervalDays: 1, Repetitions: 0},
+		},
+		{
+			name:  "grade above the scale",
+			grade: 9,
+			want:  State{EaseFactor: 2.6, IntervalDays: 1, Repetitions: 1},
+		},
+		{
+			name:  "grade below the scale",
+			grade: -3,
+			want:  State{EaseFactor: 1.7, IntervalDays: 1, Repetitions: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SM2{}.Next(tt.state, tt.grade, reviewedAt)
+			if math.Abs(got.EaseFactor-tt.want.EaseFactor) > 1e-9 {
+				t.Errorf("EaseFactor = %v, want %v", got.EaseFactor, tt.want.EaseFactor)
+			}
+			if got.IntervalDays != tt.want.IntervalDays {
+				t.Errorf("IntervalDays = %d, want %d", got.IntervalDays, tt.want.IntervalDays)
+			}
+			if got.Repetitions != tt.want.Repetitions {
+				t.Errorf("Repetitions = %d, want %d", got.Repetitions, tt.want.Repetitions)
+			}
+			if !got.LastReviewedAt.Equal(reviewedAt) {
+				t.Errorf("LastReviewedAt = %v, want %v", got.LastReviewedAt, reviewedAt)
+			}
+			if due := reviewedAt.AddDate(0, 0, tt.want.IntervalDays); !got.DueAt.Equal(due) {
+				t.Errorf("DueAt = %v, want %v", got.DueAt, due)
+			}
+		})
+	}
+}
+
+func TestGradeFromRating(t *testing.T) {
+	tests := []struct {
+		rating string
+		grade  int
+		ok     bool
+	}{
+		{"again", GradeIncorrect, true},
+		{"hard", GradeHard, true},
+		{"good", GradeGood, true},
+		{"easy", GradePerfect, true},
+		{"Good", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		grade, ok := GradeFromRating(tt.rating)
+		if grade != tt.grade || ok != tt.ok {
+			t.Errorf("GradeFromRating(%q) = %d, %v, want %d, %v", tt.rating, grade, ok, tt.grade, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package xapi implements the parts of the Experience API (xAPI 1.0.3) statement
+// model the learning record store needs: parsing, validation and identifiers.
+package xapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the xAPI version reported by the LRS
+const Version = "1.0.3"
+
+// Verbs with special meaning to the LRS
+const (
+	VerbAnswered = "http://adlnet.gov/expapi/verbs/answered"
+	VerbVoided   = "http://adlnet.gov/expapi/verbs/voided"
+)
+
+// Statement is the typed view of a statement used for validation and mapping.
+// Statements are stored as submitted, so fields the LRS does not interpret are
+// not modelled here.
+type Statement struct {
+	ID        string   `json:"id"`
+	Actor     *Agent   `json:"actor"`
+	Verb      *Verb    `json:"verb"`
+	Object    *Object  `json:"object"`
+	Result    *Result  `json:"result"`
+	Context   *Context `json:"context"`
+	Timestamp string   `json:"timestamp"`
+}
+
+// Agent identifies a person or group by one inverse functional identifier
+type Agent struct {
+	ObjectType  string   `json:"objectType"`
+	Name        string   `json:"name"`
+	Mbox        string   `json:"mbox"`
+	MboxSHA1Sum string   `json:"mbox_sha1sum"`
+	OpenID      string   `json:"openid"`
+	Account     *Account `json:"account"`
+	Member      []Agent  `json:"member"`
+}
+
+// Account is an agent identifier on some system
+type Account struct {
+	HomePage string `json:"homePage"`
+	Name     string `json:"name"`
+}
+
+// Verb is the action of a statement
+type Verb struct {
+	ID string `json:"id"`
+}
+
+// Object is the target of a statement. Only activities and statement references
+// are interpreted.
+type Object struct {
+	ObjectType string `json:"objectType"`
+	ID         string `json:"id"`
+}
+
+// Result is the outcome of a statement
+type Result struct {
+	Success  *bool  `json:"success"`
+	Response string `json:"response"`
+	Duration string `json:"duration"`
+	Score    *Score `json:"score"`
+}
+
+// Score is a result score. Scaled runs from -1 to 1.
+type Score struct {
+	Scaled *float64 `json:"scaled"`
+	Raw    *float64 `json:"raw"`
+	Min    *float64 `json:"min"`
+	Max    *float64 `json:"max"`
+}
+
+// Context places a statement within a registration and related activities
+type Context struct {
+	Registration      string                     `json:"registration"`
+	ContextActivities *ContextActivities         `json:"contextActivities"`
+	Extensions        map[string]json.RawMessage `json:"extensions"`
+}
+
+// ContextActivities lists activities related to a statement. Each may be given as
+// a single object or an array in JSON.
+type ContextActivities struct {
+	Parent   Objects `json:"parent"`
+	Grouping Objects `json:"grouping"`
+	Category Objects `json:"category"`
+	Other    Objects `json:"other"`
+}
+
+// Objects accepts a single object or an array of objects
+type Objects []Object
+
+// UnmarshalJSON accepts either form allowed for context activities
+func (o *Objects) UnmarshalJSON(data []byte) error {
+	var single Object
+	if err := json.Unmarshal(data, &single); err == nil {
+		*o = Objects{single}
+		return nil
+	}
+	var list []Object
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*o = list
+	return nil
+}
+
+// ValidationError describes why a statement was rejected
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether s is a UUID in its canonical text form
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// NewUUID returns a random (version 4) UUID
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("xapi: reading random bytes: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Validate checks the required parts of a statement
+func (s *Statement) Validate() error {
+	if s.ID != "" && !IsUUID(s.ID) {
+		return &ValidationError{Field: "id", Message: "must be a UUID"}
+	}
+	if s.Actor == nil {
+		return &ValidationError{Field: "actor", Message: "is required"}
+	}
+	if err := s.Actor.validate("actor"); err != nil {
+		return err
+	}
+	if s.Verb == nil || !isIRI(s.Verb.ID) {
+		return &ValidationError{Field: "verb.id", Message: "must be an absolute IRI"}
+	}
+	if s.Object == nil {
+		return &ValidationError{Field: "object", Message: "is required"}
+	}
+	switch s.Object.ObjectType {
+	case "", "Activity":
+		if !isIRI(s.Object.ID) {
+			return &ValidationError{Field: "object.id", Message: "must be an absolute IRI"}
+		}
+	case "StatementRef":
+		if !IsUUID(s.Object.ID) {
+			return &ValidationError{Field: "object.id", Message: "must be a UUID"}
+		}
+	}
+	if s.Verb.ID == VerbVoided && s.Object.ObjectType != "StatementRef" {
+		return &ValidationError{Field: "object.objectType", Message: "voiding statements must target a StatementRef"}
+	}
+	if s.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339Nano, s.Timestamp); err != nil {
+			return &ValidationError{Field: "timestamp", Message: "must be an ISO 8601 timestamp"}
+		}
+	}
+	if s.Result != nil && s.Result.Duration != "" {
+		if _, err := ParseDuration(s.Result.Duration); err != nil {
+			return &ValidationError{Field: "result.duration", Message: err.Error()}
+		}
+	}
+	if s.Context != nil && s.Context.Registration != "" && !IsUUID(s.Context.Registration) {
+		return &ValidationError{Field: "context.registration", Message: "must be a UUID"}
+	}
+	return nil
+}
+
+func (a *Agent) validate(field string) error {
+	identifiers := 0
+	if a.Mbox != "" {
+		if !strings.HasPrefix(a.Mbox, "mailto:") {
+			return &ValidationError{Field: field + ".mbox", Message: `must start with "mailto:"`}
+		}
+		identifiers++
+	}
+	if a.MboxSHA1Sum != "" {
+		identifiers++
+	}
+	if a.OpenID != "" {
+		identifiers++
+	}
+	if a.Account != nil {
+		if a.Account.HomePage == "" || a.Account.Name == "" {
+			return &ValidationError{Field: field + ".account", Message: "needs homePage and name"}
+		}
+		identifiers++
+	}
+	if identifiers > 1 {
+		return &ValidationError{Field: field, Message: "must have exactly one identifier"}
+	}
+	// Only anonymous groups, identified by their members, may go without one
+	if identifiers == 0 && !(a.ObjectType == "Group" && len(a.Member) > 0) {
+		return &ValidationError{Field: field, Message: "needs an mbox, mbox_sha1sum, openid or account"}
+	}
+	return nil
+}
+
+// Identifier returns a stable string for the agent's inverse functional identifier,
+// used to match agents in queries
+func (a *Agent) Identifier() string {
+	switch {
+	case a.Mbox != "":
+		return "mbox:" + strings.ToLower(a.Mbox)
+	case a.MboxSHA1Sum != "":
+		return "mbox_sha1sum:" + strings.ToLower(a.MboxSHA1Sum)
+	case a.OpenID != "":
+		return "openid:" + a.OpenID
+	case a.Account != nil:
+		return "account:" + a.Account.HomePage + "|" + a.Account.Name
+	}
+	return ""
+}
+
+func isIRI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration parses the day and time parts of an ISO 8601 duration such as
+// "PT4.25S" or "P1DT2H"
+func ParseDuration(s string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(s)
+	if match == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("must be an ISO 8601 duration such as PT4.5S")
+	}
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var total time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	return total, nil
+}
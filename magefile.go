@@ -1,20 +1,48 @@
 //go:build mage
+
+// The mage tasks open the database like the server does, which needs SQLite with
+// FTS5 for word search. Run them with GOFLAGS=-tags=sqlite_fts5; Build and Test
+// pass the tag to the go commands they run themselves.
 package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/minhalzubairi/lang-portal/backend-go/db/migrations"
+	"github.com/minhalzubairi/lang-portal/backend-go/internal/service"
 )
 
 const dbName = "words.db"
 
+// buildTags are needed by every build of the server: word search uses FTS5, which
+// go-sqlite3 only compiles in on request
+const buildTags = "sqlite_fts5"
+
+// goCommand runs the go command with args and the build tags of the server
+func goCommand(command string, args ...string) error {
+	cmd := exec.Command("go", append([]string{command, "-tags", buildTags}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Build compiles the server to bin/server
+func Build() error {
+	return goCommand("build", "-o", filepath.Join("bin", "server"), "./cmd/server")
+}
+
+// Test runs the tests, the contract test included
+func Test() error {
+	return goCommand("test", "./...")
+}
+
 // InitDB initializes the SQLite database
 func InitDB() error {
 	if _, err := os.Stat(dbName); err == nil {
@@ -32,144 +60,308 @@ func InitDB() error {
 	return nil
 }
 
-// Migrate runs all pending migrations
+// dryRun reports whether DRY_RUN is set, in which case the migration tasks only
+// print what they would do
+func dryRun() bool {
+	value, _ := strconv.ParseBool(os.Getenv("DRY_RUN"))
+	return value
+}
+
+// Migrate runs all pending migrations. With DRY_RUN=1 it prints them instead.
 func Migrate() error {
-	db, err := sql.Open("sqlite3", dbName)
+	db, err := service.OpenDB(dbName)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if dryRun() {
+		plan, err := migrations.Plan(db)
+		if err != nil {
+			return err
+		}
+		if len(plan) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		for _, m := range plan {
+			printScript("Would apply migration: "+m.Name, m.SQL)
+		}
+		return nil
+	}
+
+	applied, err := migrations.Apply(db)
+	for _, name := range applied {
+		fmt.Printf("Applied migration: %s\n", name)
+	}
+	return err
+}
+
+// Status lists every migration and whether it has been applied
+func Status() error {
+	db, err := service.OpenDB(dbName)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
 
-	// Create migrations table if it doesn't exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS migrations (
-			id INTEGER PRIMARY KEY,
-			name TEXT NOT NULL,
-			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
+	statuses, err := migrations.Statuses(db)
 	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+		return err
+	}
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied " + status.AppliedAt
+		}
+		var notes []string
+		if status.Edited {
+			notes = append(notes, "edited since applied")
+		}
+		if status.Unknown {
+			notes = append(notes, "unknown to this version")
+		} else if status.Down == "" {
+			notes = append(notes, "no down migration")
+		}
+		line := fmt.Sprintf("%-40s %s", status.Name, state)
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Println(line)
 	}
+	return nil
+}
 
-	// Get list of migration files
-	files, err := filepath.Glob("db/migrations/*.sql")
+// Rollback undoes the most recently applied migration. With DRY_RUN=1 it prints
+// the down migration instead.
+func Rollback() error {
+	db, err := service.OpenDB(dbName)
 	if err != nil {
-		return fmt.Errorf("failed to read migration files: %w", err)
+		return fmt.Errorf("failed to open database: %w", err)
 	}
-	sort.Strings(files)
+	defer db.Close()
 
-	// Apply each migration
-	for _, file := range files {
-		name := filepath.Base(file)
-		var exists bool
-		err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM migrations WHERE name = ?)", name).Scan(&exists)
+	if dryRun() {
+		m, err := migrations.Latest(db)
 		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
+			return err
 		}
-
-		if exists {
-			continue
+		if m.Down == "" {
+			return fmt.Errorf("migration %s has no down migration", m.Name)
 		}
+		printScript("Would roll back migration: "+m.Name, m.Down)
+		return nil
+	}
 
-		content, err := os.ReadFile(file)
+	m, err := migrations.Rollback(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rolled back migration: %s\n", m.Name)
+	return nil
+}
+
+// Redo rolls back the most recently applied migration and migrates again.
+// With DRY_RUN=1 it prints both scripts instead.
+func Redo() error {
+	if dryRun() {
+		if err := Rollback(); err != nil {
+			return err
+		}
+		db, err := service.OpenDB(dbName)
 		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", name, err)
+			return fmt.Errorf("failed to open database: %w", err)
 		}
-
-		tx, err := db.Begin()
+		defer db.Close()
+		m, err := migrations.Latest(db)
 		if err != nil {
-			return fmt.Errorf("failed to start transaction: %w", err)
+			return err
 		}
+		printScript("Would apply migration: "+m.Name, m.SQL)
+		return nil
+	}
 
-		// Split the file content into individual statements
-		statements := strings.Split(string(content), ";")
-		for _, stmt := range statements {
-			stmt = strings.TrimSpace(stmt)
-			if stmt == "" {
-				continue
-			}
-
-			if _, err := tx.Exec(stmt); err != nil {
-				tx.Rollback()
-				return fmt.Errorf("failed to execute migration %s: %w", name, err)
-			}
-		}
+	if err := Rollback(); err != nil {
+		return err
+	}
+	return Migrate()
+}
 
-		if _, err := tx.Exec("INSERT INTO migrations (name) VALUES (?)", name); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to record migration %s: %w", name, err)
-		}
+// printScript prints a migration script statement by statement under a heading
+func printScript(heading, script string) {
+	fmt.Println(heading)
+	for _, stmt := range migrations.Split(script) {
+		fmt.Printf("    %s;\n", strings.ReplaceAll(stmt, "\n", "\n    "))
+	}
+}
+
+// Seed imports seed data into the database
+func Seed() error {
+	return importFile("db/seeds/basic_greetings.json", "Basic Greetings")
+}
+
+// Import imports a CSV, TSV or JSON vocabulary file, adding its words to group
+// unless group is empty: mage import words.csv "Food"
+func Import(path, group string) error {
+	return importFile(path, group)
+}
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", name, err)
+func importFile(path, group string) error {
+	db, err := service.OpenDB(dbName)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	svc := service.New(db, service.Options{})
+
+	format := service.DetectImportFormat(path, "")
+	if format == "" {
+		return fmt.Errorf("cannot tell the format of %s, expected a .csv, .tsv or .json file", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read import file: %w", err)
+	}
+	defer file.Close()
+
+	report, err := svc.ImportWords(file, service.ImportOptions{
+		Format:    format,
+		GroupName: group,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == service.ImportStatusInvalid {
+			fmt.Printf("Row %d is invalid: %s\n", row.Row, row.Message)
 		}
+	}
+	fmt.Printf("Imported %s: %d inserted, %d duplicates skipped, %d invalid\n",
+		path, report.Inserted, report.Skipped, report.Invalid)
+	return nil
+}
 
-		fmt.Printf("Applied migration: %s\n", name)
+// ImportAnki imports the notes of an Anki .apkg package, adding them to groups named
+// after their decks, along with their review history: mage importAnki deck.apkg
+func ImportAnki(path string) error {
+	db, err := service.OpenDB(dbName)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	svc := service.New(db, service.Options{})
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read Anki package: %w", err)
 	}
+	defer file.Close()
 
+	report, err := svc.ImportAnkiPackage(file, service.AnkiImportOptions{Reviews: true})
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == service.ImportStatusInvalid {
+			fmt.Printf("Note %d is invalid: %s\n", row.Row, row.Message)
+		}
+	}
+	fmt.Printf("Imported %s: %d inserted, %d duplicates skipped, %d invalid, %d reviews\n",
+		path, report.Inserted, report.Skipped, report.Invalid, report.ReviewsImported)
 	return nil
 }
 
-// Seed imports seed data into the database
-func Seed() error {
-	db, err := sql.Open("sqlite3", dbName)
+// ExportAnki writes the words of a group to an Anki .apkg package: mage exportAnki 1 greetings.apkg
+func ExportAnki(groupID int, path string) error {
+	db, err := service.OpenDB(dbName)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
+	svc := service.New(db, service.Options{})
 
-	// Create Basic Greetings group
-	result, err := db.Exec("INSERT INTO groups (name) VALUES (?)", "Basic Greetings")
+	file, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to create group: %w", err)
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := svc.ExportGroupAnki(int64(groupID), file); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to export group %d: %w", groupID, err)
 	}
 
-	groupID, err := result.LastInsertId()
+	fmt.Printf("Exported group %d to %s\n", groupID, path)
+	return nil
+}
+
+// Backup exports the whole learning record to a .json or .zip archive: mage backup backup.zip
+func Backup(path string) error {
+	db, err := service.OpenDB(dbName)
 	if err != nil {
-		return fmt.Errorf("failed to get group id: %w", err)
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	defer db.Close()
+	svc := service.New(db, service.Options{})
 
-	// Read and parse seed file
-	data, err := os.ReadFile("db/seeds/basic_greetings.json")
+	format := service.ArchiveFormatJSON
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		format = service.ArchiveFormatZip
+	}
+
+	file, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to read seed file: %w", err)
+		return fmt.Errorf("failed to create %s: %w", path, err)
 	}
+	defer file.Close()
 
-	var words []struct {
-		Arabic  string `json:"arabic"`
-		Roman   string `json:"roman"`
-		English string `json:"english"`
+	if err := svc.ExportArchive(file, format); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to export archive: %w", err)
 	}
 
-	if err := json.Unmarshal(data, &words); err != nil {
-		return fmt.Errorf("failed to parse seed file: %w", err)
+	fmt.Printf("Exported archive to %s\n", path)
+	return nil
+}
+
+// Restore restores an archive written by Backup or GET /api/export: mage restore backup.zip
+func Restore(path string) error {
+	db, err := service.OpenDB(dbName)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	defer db.Close()
+	svc := service.New(db, service.Options{})
 
-	// Insert words and create word-group associations
-	for _, word := range words {
-		result, err := db.Exec(
-			"INSERT INTO words (arabic, roman, english) VALUES (?, ?, ?)",
-			word.Arabic, word.Roman, word.English,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert word: %w", err)
-		}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer file.Close()
 
-		wordID, err := result.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get word id: %w", err)
-		}
+	report, err := svc.RestoreArchive(file)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
 
-		_, err = db.Exec(
-			"INSERT INTO words_groups (word_id, group_id) VALUES (?, ?)",
-			wordID, groupID,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create word-group association: %w", err)
+	for _, conflict := range report.Conflicts {
+		if conflict.Status == service.RestoreStatusSkipped {
+			fmt.Printf("Skipped %s %d: %s\n", conflict.Table, conflict.ArchiveID, conflict.Reason)
 		}
 	}
-
-	fmt.Println("Seed data imported successfully")
+	for _, table := range service.ArchiveTables() {
+		counts := report.Tables[table]
+		fmt.Printf("%s: %d inserted, %d merged, %d skipped\n", table, counts.Inserted, counts.Merged, counts.Skipped)
+	}
 	return nil
 }
+
+// Contract checks that the API answers as its OpenAPI document says, running
+// TestContract in internal/openapi: it serves the API on a temporary database,
+// sends a request to every endpoint and validates each response against the
+// document.
+func Contract() error {
+	return goCommand("test", "-count=1", "-v", "-run", "^TestContract$", "./internal/openapi")
+}